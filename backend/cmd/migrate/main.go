@@ -76,8 +76,14 @@ var collections = []string{
 	"user_profiles",
 	"workspaces",
 	"workspace_members",
+	"workspace_invites",
+	"workspace_invite_links",
+	"workspace_transfers",
 	"projects",
 	"documents",
+	"document_versions",
+	"document_grants",
+	"document_shares",
 }
 
 // setupCollections creates collections and their indexes.
@@ -119,6 +125,40 @@ func setupCollections(ctx context.Context, database *mongo.Database) error {
 	}
 	fmt.Println("  ✅ Index: workspaces (slug) UNIQUE")
 
+	// workspace_invites: unique token, lookups by workspace and expiry
+	invCol := database.Collection("workspace_invites")
+	_, err = invCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_invites indexes: %w", err)
+	}
+	fmt.Println("  ✅ Indexes: workspace_invites (token UNIQUE, workspace_id, expires_at)")
+
+	// workspace_invite_links: unique token, lookups by workspace and expiry
+	linkCol := database.Collection("workspace_invite_links")
+	_, err = linkCol.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_invite_links indexes: %w", err)
+	}
+	fmt.Println("  ✅ Indexes: workspace_invite_links (token UNIQUE, workspace_id, expires_at)")
+
+	// workspace_transfers: keyed by workspace_id (_id), index on expiry
+	transferCol := database.Collection("workspace_transfers")
+	_, err = transferCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "expires_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create workspace_transfers index: %w", err)
+	}
+	fmt.Println("  ✅ Index: workspace_transfers (expires_at)")
+
 	// documents: indexes for common queries
 	docCol := database.Collection("documents")
 	docIndexes := []mongo.IndexModel{
@@ -134,6 +174,39 @@ func setupCollections(ctx context.Context, database *mongo.Database) error {
 	}
 	fmt.Println("  ✅ Indexes: documents (project_id, workspace_id, created_by, updated_at, diagram_type)")
 
+	// document_versions: one snapshot per (document_id, version)
+	verCol := database.Collection("document_versions")
+	_, err = verCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "document_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create document_versions index: %w", err)
+	}
+	fmt.Println("  ✅ Index: document_versions (document_id, version) UNIQUE")
+
+	// document_grants: one grant per (document_id, user_id)
+	grantCol := database.Collection("document_grants")
+	_, err = grantCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "document_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create document_grants index: %w", err)
+	}
+	fmt.Println("  ✅ Index: document_grants (document_id, user_id) UNIQUE")
+
+	// document_shares: keyed by document_id (_id), unique token
+	shareCol := database.Collection("document_shares")
+	_, err = shareCol.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "token", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create document_shares index: %w", err)
+	}
+	fmt.Println("  ✅ Index: document_shares (token) UNIQUE")
+
 	// projects: index on workspace_id
 	projCol := database.Collection("projects")
 	_, err = projCol.Indexes().CreateOne(ctx, mongo.IndexModel{
@@ -4,24 +4,33 @@
 package app
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	goredis "github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/config"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/db"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/handler"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/middleware"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/repository"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/router"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/service"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/ws"
 )
 
-// Instance holds the initialized Fiber app and DB connection.
+// Instance holds the initialized Fiber app, DB and Redis connections.
 type Instance struct {
-	App *fiber.App
-	DB  *mongo.Database
-	Cfg *config.Config
+	App   *fiber.App
+	DB    *mongo.Database
+	Redis *goredis.Client // nil when Redis is unavailable
+	Hub   *ws.Hub
+	Trash *service.TrashService
+	Cfg   *config.Config
 }
 
 // New creates a fully wired Fiber application with all middleware,
@@ -37,6 +46,24 @@ func New() *Instance {
 	}
 	log.Println("✓ Connected to MongoDB")
 
+	// Connect to Redis (optional: without it rate limiting is disabled and
+	// collaboration rooms are local to this instance)
+	var redisClient *goredis.Client
+	var limiter middleware.Limiter
+	var cluster ws.Cluster
+	if client, err := redis.Connect(cfg.RedisURL); err != nil {
+		log.Printf("⚠ Redis unavailable, rate limiting and multi-instance collaboration disabled: %v", err)
+	} else {
+		redisClient = client
+		limiter = redis.NewRateLimiter(client)
+		cluster = ws.Cluster{
+			Broker:   redis.NewRoomBroker(client),
+			Locks:    redis.NewLockService(client),
+			Presence: redis.NewPresenceService(client),
+		}
+		log.Println("✓ Connected to Redis")
+	}
+
 	// --- Repository layer ---
 	userRepo := repository.NewUserRepo(database)
 	wsRepo := repository.NewWorkspaceRepo(database)
@@ -45,9 +72,17 @@ func New() *Instance {
 
 	// --- Service layer ---
 	authSvc := service.NewAuthService(userRepo)
-	wsSvc := service.NewWorkspaceService(wsRepo)
+	wsSvc := service.NewWorkspaceService(wsRepo, userRepo, cfg.AdminEmails)
 	projSvc := service.NewProjectService(projRepo, wsSvc)
-	docSvc := service.NewDocumentService(docRepo, projRepo, wsSvc)
+	docSvc := service.NewDocumentService(docRepo, projRepo, userRepo, wsSvc)
+	exportSvc := service.NewExportService(docRepo, wsSvc)
+	trashSvc := service.NewTrashService(docRepo, projRepo, wsSvc, time.Duration(cfg.TrashRetentionDays)*24*time.Hour)
+
+	// --- Background jobs ---
+	trashSvc.StartPurge()
+
+	// --- Realtime collaboration ---
+	hub := ws.NewHub(docSvc, cluster)
 
 	// --- Handler layer ---
 	handlers := router.Handlers{
@@ -55,7 +90,9 @@ func New() *Instance {
 		Auth:      handler.NewAuthHandler(authSvc, cfg),
 		Workspace: handler.NewWorkspaceHandler(wsSvc),
 		Project:   handler.NewProjectHandler(projSvc),
-		Document:  handler.NewDocumentHandler(docSvc),
+		Document:  handler.NewDocumentHandler(docSvc, exportSvc),
+		Collab:    handler.NewCollabHandler(docSvc, authSvc, hub),
+		Trash:     handler.NewTrashHandler(trashSvc),
 	}
 
 	// Fiber app
@@ -65,20 +102,35 @@ func New() *Instance {
 	})
 
 	// Register routes with middleware stack
-	router.Setup(app, cfg, handlers)
+	router.Setup(app, cfg, handlers, limiter)
 
 	return &Instance{
-		App: app,
-		DB:  database,
-		Cfg: cfg,
+		App:   app,
+		DB:    database,
+		Redis: redisClient,
+		Hub:   hub,
+		Trash: trashSvc,
+		Cfg:   cfg,
 	}
 }
 
 // Close gracefully shuts down the application (closes DB, etc).
 func (inst *Instance) Close() {
+	if inst.Hub != nil {
+		// Persist pending realtime edits while the DB is still connected
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		inst.Hub.Close(ctx)
+		cancel()
+	}
+	if inst.Trash != nil {
+		inst.Trash.Close()
+	}
 	if inst.DB != nil {
 		db.Disconnect(inst.DB)
 	}
+	if inst.Redis != nil {
+		_ = inst.Redis.Close()
+	}
 }
 
 // fiberErrorHandler returns JSON errors for any unhandled Fiber errors.
@@ -0,0 +1,569 @@
+// Package ascii turns box-and-arrow ASCII art (Konsep Aplikasi §5.3) into
+// DocumentContent, as produced by LLMs and pasted from READMEs:
+//
+//	+-------+     +----------+     +---------+
+//	| Start | --> | Process  | --> |   End   |
+//	+-------+     +----------+     +---------+
+//
+// Detection is heuristic. Boxes are rectangles drawn with + - | (or the
+// Unicode box-drawing characters); their interior text is the node label.
+// Connectors are runs of - and | that touch two or more boxes, possibly with
+// bends (+), arrowheads (> < v ^) and an inline label (--yes-->). Positions
+// and sizes come from the character grid.
+package ascii
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// Size of one character cell on the canvas.
+const (
+	cellWidth  = 10.0
+	cellHeight = 20.0
+)
+
+// maxGap is how many blank cells may separate a connector end from a box.
+const maxGap = 2
+
+// Largest diagram Parse accepts; lines are padded to the longest one.
+const (
+	MaxRows    = 500
+	MaxColumns = 500
+	MaxBoxes   = 1000
+)
+
+var (
+	// ErrNoBoxes is returned when the text contains no box.
+	ErrNoBoxes = errors.New("no boxes found")
+	// ErrTooLarge is returned when the text exceeds MaxRows or MaxColumns.
+	ErrTooLarge = fmt.Errorf("diagram is larger than %d lines of %d characters", MaxRows, MaxColumns)
+	// ErrTooManyBoxes is returned when the text has more than MaxBoxes boxes.
+	ErrTooManyBoxes = fmt.Errorf("diagram has more than %d boxes", MaxBoxes)
+)
+
+// Result is a parsed diagram with notes about connectors that were skipped.
+type Result struct {
+	Content  *document.DocumentContent
+	Warnings []string
+}
+
+// normalize maps Unicode box-drawing and arrow characters to their ASCII
+// equivalents.
+var normalize = strings.NewReplacer(
+	"┌", "+", "┐", "+", "└", "+", "┘", "+", "├", "+", "┤", "+", "┬", "+", "┴", "+", "┼", "+",
+	"╭", "+", "╮", "+", "╰", "+", "╯", "+",
+	"─", "-", "━", "-", "│", "|", "┃", "|",
+	"▶", ">", "►", ">", "→", ">", "◀", "<", "◄", "<", "←", "<",
+	"▼", "v", "↓", "v", "▲", "^", "↑", "^",
+	"\t", "    ",
+)
+
+// labelRe finds text inside a horizontal connector, e.g. the "yes" of --yes-->.
+var labelRe = regexp.MustCompile(`-{2,} ?([^\s\-|+<>](?:[^\-|+<>]*[^\s\-|+<>])?) ?-+`)
+
+type point struct{ row, col int }
+
+// box is a rectangle with corners at (top, left) and (bottom, right).
+type box struct {
+	top, left, bottom, right int
+	id                       string
+}
+
+func (b *box) onBorder(p point) bool {
+	if p.row < b.top || p.row > b.bottom || p.col < b.left || p.col > b.right {
+		return false
+	}
+	return p.row == b.top || p.row == b.bottom || p.col == b.left || p.col == b.right
+}
+
+func (b *box) inside(p point) bool {
+	return p.row > b.top && p.row < b.bottom && p.col > b.left && p.col < b.right
+}
+
+// contains reports whether o lies within b's interior.
+func (b *box) contains(o *box) bool {
+	return o.top > b.top && o.bottom < b.bottom && o.left > b.left && o.right < b.right
+}
+
+// grid is the text as a rectangle of runes, padded with spaces.
+type grid [][]rune
+
+func (g grid) at(p point) rune {
+	if p.row < 0 || p.row >= len(g) || p.col < 0 || p.col >= len(g[p.row]) {
+		return ' '
+	}
+	return g[p.row][p.col]
+}
+
+func newGrid(src string) (grid, error) {
+	lines := strings.Split(strings.ReplaceAll(normalize.Replace(src), "\r\n", "\n"), "\n")
+	if len(lines) > MaxRows {
+		return nil, ErrTooLarge
+	}
+	width := 0
+	g := make(grid, len(lines))
+	for i, line := range lines {
+		g[i] = []rune(strings.TrimRight(line, " "))
+		width = max(width, len(g[i]))
+	}
+	if width > MaxColumns {
+		return nil, ErrTooLarge
+	}
+	for i := range g {
+		for len(g[i]) < width {
+			g[i] = append(g[i], ' ')
+		}
+	}
+	return g, nil
+}
+
+// Parse detects the boxes and connectors of src. Boxes become nodes, typed
+// for diagramType: flowcharts and activity diagrams get start-end nodes for
+// boxes labelled Start/End and decisions for labels ending in "?"; any other
+// box is a process.
+func Parse(src, diagramType string) (*Result, error) {
+	g, err := newGrid(src)
+	if err != nil {
+		return nil, err
+	}
+	boxes := findBoxes(g)
+	switch {
+	case len(boxes) == 0:
+		return nil, ErrNoBoxes
+	case len(boxes) > MaxBoxes:
+		return nil, ErrTooManyBoxes
+	}
+
+	res := &Result{Content: &document.DocumentContent{
+		Nodes: make([]document.Node, 0, len(boxes)),
+		Edges: []document.Edge{},
+	}}
+
+	// borders maps each border cell to the first box drawn through it.
+	used := make(map[point]bool)
+	borders := make(map[point]*box)
+	for _, b := range boxes {
+		for r := b.top; r <= b.bottom; r++ {
+			for c := b.left; c <= b.right; c++ {
+				if p := (point{r, c}); b.onBorder(p) {
+					used[p] = true
+					if borders[p] == nil {
+						borders[p] = b
+					}
+				}
+			}
+		}
+	}
+
+	nested := nestedBoxes(boxes)
+	labels := extractEdgeLabels(g, boxes, nested, used)
+	res.Content.Edges, res.Warnings = traceConnectors(g, boxes, nested, borders, used, labels)
+
+	for _, b := range boxes {
+		label := boxLabel(g, b, nested[b], used)
+		width := float64(b.right-b.left+1) * cellWidth
+		height := float64(b.bottom-b.top+1) * cellHeight
+		res.Content.Nodes = append(res.Content.Nodes, document.Node{
+			ID:       b.id,
+			Type:     nodeType(label, diagramType),
+			Position: document.Position{X: float64(b.left) * cellWidth, Y: float64(b.top) * cellHeight},
+			Width:    &width,
+			Height:   &height,
+			Label:    label,
+		})
+	}
+	return res, nil
+}
+
+// findBoxes returns every rectangle in g, in reading order of their top-left
+// corners, with IDs n1, n2, ... assigned in that order. It stops once more
+// than MaxBoxes are found.
+func findBoxes(g grid) []*box {
+	var boxes []*box
+	for r := range g {
+		for c := range g[r] {
+			if b := boxAt(g, r, c); b != nil {
+				b.id = fmt.Sprintf("n%d", len(boxes)+1)
+				boxes = append(boxes, b)
+				if len(boxes) > MaxBoxes {
+					return boxes
+				}
+			}
+		}
+	}
+	return boxes
+}
+
+// boxAt returns the box whose top-left corner is (top, left). The other
+// corners are the first + along the top edge with a line going down and the
+// first + down the left edge with a line going right, so each corner costs
+// one walk along its edges rather than a search of every pair.
+func boxAt(g grid, top, left int) *box {
+	if g.at(point{top, left}) != '+' || !isHorizontal(g.at(point{top, left + 1})) || !isVertical(g.at(point{top + 1, left})) {
+		return nil
+	}
+	right := left + 1
+	for ; right < len(g[top]) && isHorizontal(g[top][right]); right++ {
+		if g[top][right] == '+' && isVertical(g.at(point{top + 1, right})) {
+			break
+		}
+	}
+	bottom := top + 2 // a box has at least one line of interior
+	for ; bottom < len(g) && isVertical(g[bottom][left]); bottom++ {
+		if g[bottom][left] == '+' && isHorizontal(g.at(point{bottom, left + 1})) {
+			break
+		}
+	}
+	if g.at(point{top, right}) != '+' || g.at(point{bottom, left}) != '+' || !closes(g, top, left, bottom, right) {
+		return nil
+	}
+	return &box{top: top, left: left, bottom: bottom, right: right}
+}
+
+// closes reports whether the right and bottom edges of a box are drawn.
+func closes(g grid, top, left, bottom, right int) bool {
+	if bottom-top < 2 || g.at(point{bottom, right}) != '+' {
+		return false
+	}
+	for r := top + 1; r < bottom; r++ {
+		if !isVertical(g.at(point{r, right})) {
+			return false
+		}
+	}
+	for c := left + 1; c < right; c++ {
+		if !isHorizontal(g.at(point{bottom, c})) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHorizontal(ch rune) bool { return ch == '-' || ch == '+' }
+func isVertical(ch rune) bool   { return ch == '|' || ch == '+' }
+
+// nestedBoxes maps each box to the boxes inside it. Boxes are in reading
+// order, so the candidates for a box are those that start above its bottom.
+func nestedBoxes(boxes []*box) map[*box][]*box {
+	nested := make(map[*box][]*box)
+	for i, b := range boxes {
+		for _, o := range boxes[i+1:] {
+			if o.top >= b.bottom {
+				break
+			}
+			if b.contains(o) {
+				nested[b] = append(nested[b], o)
+			}
+		}
+	}
+	return nested
+}
+
+// boxLabel joins the interior lines of b that are not part of a nested box
+// or a connector.
+func boxLabel(g grid, b *box, nested []*box, used map[point]bool) string {
+	var lines []string
+	for r := b.top + 1; r < b.bottom; r++ {
+		var line strings.Builder
+		for c := b.left + 1; c < b.right; c++ {
+			p := point{r, c}
+			if used[p] || insideAny(nested, p) {
+				line.WriteRune(' ')
+				continue
+			}
+			line.WriteRune(g.at(p))
+		}
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func insideAny(boxes []*box, p point) bool {
+	for _, o := range boxes {
+		if o.inside(p) || o.onBorder(p) {
+			return true
+		}
+	}
+	return false
+}
+
+var startEndRe = regexp.MustCompile(`(?i)^(start|begin|mulai|end|finish|stop|selesai)$`)
+
+func nodeType(label, diagramType string) string {
+	if diagramType != "flowchart" && diagramType != "activity" {
+		return "process"
+	}
+	switch {
+	case startEndRe.MatchString(label):
+		return "start-end"
+	case strings.HasSuffix(label, "?"):
+		return "decision"
+	}
+	return "process"
+}
+
+// extractEdgeLabels blanks out the text inside horizontal connectors, so that
+// the connector can be traced through it, and returns the text by position.
+// Text inside a box that holds no other box is its label and is left alone.
+func extractEdgeLabels(g grid, boxes []*box, nested map[*box][]*box, used map[point]bool) map[point]string {
+	labels := make(map[point]string)
+	for r := range g {
+		row := []rune(string(g[r]))
+		for c := range row {
+			if used[point{r, c}] {
+				row[c] = ' '
+			}
+		}
+		line := string(row)
+		for _, m := range labelRe.FindAllStringSubmatchIndex(line, -1) {
+			start := len([]rune(line[:m[2]]))
+			end := len([]rune(line[:m[3]]))
+			if insideLeaf(boxes, nested, point{r, start}) {
+				continue
+			}
+			labels[point{r, start}] = line[m[2]:m[3]]
+			for c := start - 1; c <= end; c++ {
+				if g[r][c] != '-' {
+					g[r][c] = '-'
+				}
+			}
+		}
+	}
+	return labels
+}
+
+// insideLeaf reports whether p is inside a box that contains no other box.
+func insideLeaf(boxes []*box, nested map[*box][]*box, p point) bool {
+	for _, b := range boxes {
+		if b.top >= p.row {
+			break // boxes below p cannot contain it
+		}
+		if b.inside(p) && len(nested[b]) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// attachment is where a connector touches a box.
+type attachment struct {
+	box  *box
+	head bool // the connector ends in an arrowhead pointing at the box
+}
+
+// traceConnectors follows each connected run of connector characters and
+// turns the ones that touch two or more boxes into edges.
+func traceConnectors(g grid, boxes []*box, nested map[*box][]*box, borders map[point]*box, used map[point]bool, labels map[point]string) ([]document.Edge, []string) {
+	var edges []document.Edge
+	var warnings []string
+	seen := make(map[point]bool)
+
+	for r := range g {
+		for c := range g[r] {
+			start := point{r, c}
+			if seen[start] || used[start] || !isConnector(g, start) {
+				continue
+			}
+			cells := trace(g, start, used, seen)
+			attached, bends := attachments(g, borders, cells)
+			if len(attached) < 2 {
+				if len(cells) > 1 && !insideLeaf(boxes, nested, start) {
+					warnings = append(warnings, fmt.Sprintf("line %d, column %d: connector does not join two boxes", r+1, c+1))
+				}
+				continue
+			}
+
+			label := ""
+			for _, p := range cells {
+				if l, ok := labels[p]; ok {
+					label = l
+					break
+				}
+			}
+			for _, p := range cells {
+				used[p] = true
+			}
+
+			edgeType := "straight"
+			if bends {
+				edgeType = "step"
+			}
+			for _, pair := range direct(attached) {
+				edges = append(edges, document.Edge{
+					ID:     fmt.Sprintf("e%d", len(edges)+1),
+					Source: pair[0].id,
+					Target: pair[1].id,
+					Type:   edgeType,
+					Label:  label,
+				})
+			}
+		}
+	}
+	return edges, warnings
+}
+
+// isConnector reports whether the character at p can be part of a connector.
+// v and ^ only count as arrowheads at the end of a vertical line.
+func isConnector(g grid, p point) bool {
+	switch g.at(p) {
+	case '-', '|', '+', '>', '<':
+		return true
+	case 'v', 'V':
+		return isVertical(g.at(point{p.row - 1, p.col}))
+	case '^':
+		return isVertical(g.at(point{p.row + 1, p.col}))
+	}
+	return false
+}
+
+func horizontalLink(ch rune) bool { return ch == '-' || ch == '+' || ch == '<' || ch == '>' }
+func verticalLink(ch rune) bool {
+	return ch == '|' || ch == '+' || ch == 'v' || ch == 'V' || ch == '^'
+}
+
+// trace returns the cells connected to start, in the order visited.
+func trace(g grid, start point, used, seen map[point]bool) []point {
+	cells := []point{start}
+	seen[start] = true
+	for i := 0; i < len(cells); i++ {
+		p := cells[i]
+		ch := g.at(p)
+		var next []point
+		if horizontalLink(ch) {
+			next = append(next, point{p.row, p.col - 1}, point{p.row, p.col + 1})
+		}
+		if verticalLink(ch) {
+			next = append(next, point{p.row - 1, p.col}, point{p.row + 1, p.col})
+		}
+		for _, q := range next {
+			if seen[q] || used[q] || !isConnector(g, q) {
+				continue
+			}
+			qch := g.at(q)
+			if (q.row == p.row && !horizontalLink(qch)) || (q.col == p.col && !verticalLink(qch)) {
+				continue
+			}
+			seen[q] = true
+			cells = append(cells, q)
+		}
+	}
+	return cells
+}
+
+// attachments finds the boxes a connector touches, looking past up to maxGap
+// blank cells from each end, and whether the connector bends.
+func attachments(g grid, borders map[point]*box, cells []point) ([]attachment, bool) {
+	in := make(map[point]bool, len(cells))
+	rows, cols := make(map[int]bool), make(map[int]bool)
+	for _, p := range cells {
+		in[p] = true
+		rows[p.row] = true
+		cols[p.col] = true
+	}
+
+	var attached []attachment
+	index := make(map[*box]int)
+	for _, p := range cells {
+		ch := g.at(p)
+		var dirs []point
+		if horizontalLink(ch) {
+			dirs = append(dirs, point{0, -1}, point{0, 1})
+		}
+		if verticalLink(ch) {
+			dirs = append(dirs, point{-1, 0}, point{1, 0})
+		}
+		for _, d := range dirs {
+			b := boxInDirection(g, borders, in, p, d)
+			if b == nil {
+				continue
+			}
+			head := pointsAt(ch, d)
+			if i, ok := index[b]; ok {
+				attached[i].head = attached[i].head || head
+				continue
+			}
+			index[b] = len(attached)
+			attached = append(attached, attachment{box: b, head: head})
+		}
+	}
+	return attached, len(rows) > 1 && len(cols) > 1
+}
+
+// boxInDirection returns the box reached by stepping from p in direction d
+// over at most maxGap blanks, or nil if the step leads back into the
+// connector or to anything else.
+func boxInDirection(g grid, borders map[point]*box, in map[point]bool, p, d point) *box {
+	q := point{p.row + d.row, p.col + d.col}
+	for gap := 0; gap <= maxGap; gap++ {
+		if in[q] {
+			return nil
+		}
+		if b := borders[q]; b != nil {
+			return b
+		}
+		if g.at(q) != ' ' {
+			return nil
+		}
+		q = point{q.row + d.row, q.col + d.col}
+	}
+	return nil
+}
+
+func pointsAt(ch rune, d point) bool {
+	switch ch {
+	case '>':
+		return d.col > 0
+	case '<':
+		return d.col < 0
+	case 'v', 'V':
+		return d.row > 0
+	case '^':
+		return d.row < 0
+	}
+	return false
+}
+
+// direct orders the boxes a connector joins into (source, target) pairs.
+// Boxes without an arrowhead are sources and boxes with one are targets; a
+// connector without arrowheads runs from the first box it touches, in
+// reading order, to the others.
+func direct(attached []attachment) [][2]*box {
+	sort.SliceStable(attached, func(i, j int) bool {
+		a, b := attached[i].box, attached[j].box
+		if a.top != b.top {
+			return a.top < b.top
+		}
+		return a.left < b.left
+	})
+
+	var sources, targets []*box
+	for _, a := range attached {
+		if a.head {
+			targets = append(targets, a.box)
+		} else {
+			sources = append(sources, a.box)
+		}
+	}
+	switch {
+	case len(targets) == 0:
+		sources, targets = sources[:1], sources[1:]
+	case len(sources) == 0:
+		// Arrowheads at every end: a two-way connector.
+		sources, targets = targets[:1], targets[1:]
+	}
+
+	var pairs [][2]*box
+	for _, s := range sources {
+		for _, t := range targets {
+			pairs = append(pairs, [2]*box{s, t})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,145 @@
+package ascii
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	type edge struct{ source, target, label string }
+	tests := []struct {
+		name   string
+		src    string
+		labels []string
+		types  []string
+		edges  []edge
+	}{
+		{
+			name: "chain",
+			src: `
++-------+     +---------+     +-----+
+| Start | --> | Process | --> | End |
++-------+     +---------+     +-----+
+`,
+			labels: []string{"Start", "Process", "End"},
+			types:  []string{"start-end", "process", "start-end"},
+			edges:  []edge{{"n1", "n2", ""}, {"n2", "n3", ""}},
+		},
+		{
+			name: "vertical",
+			src: `
++--------+
+| Valid? |
++--------+
+    |
+    v
++------+
+| Save |
++------+
+`,
+			labels: []string{"Valid?", "Save"},
+			types:  []string{"decision", "process"},
+			edges:  []edge{{"n1", "n2", ""}},
+		},
+		{
+			name: "inline edge label",
+			src: `
++---+          +---+
+| A | --yes--> | B |
++---+          +---+
+`,
+			labels: []string{"A", "B"},
+			types:  []string{"process", "process"},
+			edges:  []edge{{"n1", "n2", "yes"}},
+		},
+		{
+			name: "unicode box drawing",
+			src: `
+┌───┐     ┌───┐
+│ A │ ──▶ │ B │
+└───┘     └───┘
+`,
+			labels: []string{"A", "B"},
+			types:  []string{"process", "process"},
+			edges:  []edge{{"n1", "n2", ""}},
+		},
+		{
+			name: "nested box",
+			src: `
++--------------+
+| Outer        |
+|  +-------+   |
+|  | Inner |   |
+|  +-------+   |
++--------------+
+`,
+			labels: []string{"Outer", "Inner"},
+			types:  []string{"process", "process"},
+		},
+		{
+			name: "adjacent boxes sharing a wall",
+			src: `
++---+---+
+| A | B |
++---+---+
+`,
+			labels: []string{"A", "B"},
+			types:  []string{"process", "process"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(tt.src, "flowchart")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			nodes := res.Content.Nodes
+			if len(nodes) != len(tt.labels) {
+				t.Fatalf("got %d nodes %+v, want labels %v", len(nodes), nodes, tt.labels)
+			}
+			for i, n := range nodes {
+				if n.Label != tt.labels[i] || n.Type != tt.types[i] {
+					t.Errorf("node %d = %s %q, want %s %q", i, n.Type, n.Label, tt.types[i], tt.labels[i])
+				}
+			}
+			var got []edge
+			for _, e := range res.Content.Edges {
+				got = append(got, edge{e.Source, e.Target, e.Label})
+			}
+			if len(got) != len(tt.edges) {
+				t.Fatalf("edges = %+v, want %+v (warnings %v)", got, tt.edges, res.Warnings)
+			}
+			for i := range got {
+				if got[i] != tt.edges[i] {
+					t.Errorf("edge %d = %+v, want %+v", i, got[i], tt.edges[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	row := func(cells, n int) string {
+		return strings.Repeat(strings.Repeat("+-", cells)+"\n"+strings.Repeat("| ", cells)+"\n", n)
+	}
+	tests := []struct {
+		name string
+		src  string
+		err  error
+	}{
+		{"no boxes", "just some text\n--> and an arrow", ErrNoBoxes},
+		{"too many lines", strings.Repeat("x\n", MaxRows+1), ErrTooLarge},
+		{"too many columns", strings.Repeat("x", MaxColumns+1), ErrTooLarge},
+		{"too many boxes", row(40, 30), ErrTooManyBoxes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.src, "flowchart"); !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -46,6 +47,12 @@ type Config struct {
 	// Rate Limits
 	RateLimits RateLimitConfig
 
+	// Trash
+	TrashRetentionDays int // 30 default; trashed items older than this are purged
+
+	// Admins (platform-wide; may override workspace ownership transfers)
+	AdminEmails []string
+
 	// Logging
 	LogLevel  string // debug | info | warn | error
 	LogFormat string // json | text
@@ -74,8 +81,10 @@ func Load() *Config {
 			Write:  getEnvInt("RATE_LIMIT_WRITE", 30),
 			Export: getEnvInt("RATE_LIMIT_EXPORT", 10),
 		},
-		LogLevel:  getEnv("LOG_LEVEL", "debug"),
-		LogFormat: getEnv("LOG_FORMAT", "text"),
+		TrashRetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+		AdminEmails:        getEnvList("ADMIN_EMAILS"),
+		LogLevel:           getEnv("LOG_LEVEL", "debug"),
+		LogFormat:          getEnv("LOG_FORMAT", "text"),
 	}
 
 	// Fail fast in production if critical config is missing
@@ -111,3 +120,14 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvList reads a comma-separated list, skipping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
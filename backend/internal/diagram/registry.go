@@ -0,0 +1,184 @@
+package diagram
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// Type describes a diagram type: the node and edge types its documents may
+// use, the content a new document starts with and how nodes may connect.
+type Type struct {
+	Name string
+	// NodeTypes are the type's own shapes. Every shape of the editor's
+	// palettes is allowed as well, since the sidebar offers all of them.
+	NodeTypes []string
+	EdgeTypes []string
+	// DefaultContent is used when a document is created without content.
+	DefaultContent document.DocumentContent
+	// Connect rejects an edge between nodes of the given types with a reason;
+	// nil allows any pair.
+	Connect func(source, target string) string
+}
+
+// AllowsNode reports whether nodes of nodeType may be used in the diagram.
+func (t *Type) AllowsNode(nodeType string) bool {
+	return slices.Contains(t.NodeTypes, nodeType) || slices.Contains(editorNodeTypes, nodeType)
+}
+
+// AllowsEdge reports whether edges of edgeType may be used in the diagram.
+// The empty type is the editor's default edge.
+func (t *Type) AllowsEdge(edgeType string) bool {
+	return edgeType == "" || slices.Contains(t.EdgeTypes, edgeType)
+}
+
+var (
+	// editorNodeTypes are the shapes of every palette of the editor
+	// (NODE_SHAPES in the frontend) plus the sequence lifeline. The sidebar
+	// shows all palettes whatever the diagram type, so each is allowed in
+	// every diagram.
+	editorNodeTypes = []string{
+		// General
+		"process", "rounded", "ellipse", "triangle", "diamond", "parallelogram", "hexagon",
+		"octagon", "trapezoid", "star", "cloud", "note", "callout", "cylinder", "cube",
+		"cross", "text",
+		// Flowchart
+		"start-end", "decision", "terminator", "input-output", "manual-input",
+		"manual-operation", "preparation", "delay", "display", "document",
+		"multi-document", "database", "internal-storage", "collate", "off-page",
+		// UML
+		"actor", "usecase", "class", "interface", "package", "lifeline",
+		// ERD
+		"entity", "weak-entity", "attribute", "relationship",
+		// BPMN
+		"start-event", "intermediate-event", "end-event", "gateway",
+		// Network and arrows
+		"server", "arrow-left", "arrow-right",
+	}
+	// edgeTypes are the edge styles of the editor.
+	edgeTypes = []string{"default", "step", "straight", "bezier"}
+)
+
+var registry = map[string]*Type{}
+
+// Register adds a diagram type. It panics if the name is already taken.
+func Register(t Type) {
+	if _, ok := registry[t.Name]; ok {
+		panic(fmt.Sprintf("diagram: type %q registered twice", t.Name))
+	}
+	if t.EdgeTypes == nil {
+		t.EdgeTypes = edgeTypes
+	}
+	// Empty slices, so that the default content encodes as [] rather than null.
+	if t.DefaultContent.Nodes == nil {
+		t.DefaultContent.Nodes = []document.Node{}
+	}
+	if t.DefaultContent.Edges == nil {
+		t.DefaultContent.Edges = []document.Edge{}
+	}
+	registry[t.Name] = &t
+}
+
+// Lookup returns the registered diagram type called name.
+func Lookup(name string) (*Type, bool) {
+	t, ok := registry[name]
+	return t, ok
+}
+
+// Names returns the names of all registered diagram types, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(Type{
+		Name: "flowchart",
+		NodeTypes: []string{
+			"start-end", "decision", "terminator", "input-output", "manual-input",
+			"manual-operation", "preparation", "delay", "display", "document",
+			"multi-document", "database", "internal-storage", "collate", "off-page",
+		},
+	})
+	Register(Type{
+		Name:      "erd",
+		NodeTypes: []string{"entity", "weak-entity", "attribute", "relationship"},
+		Connect: func(source, target string) string {
+			if source == "relationship" && target == "relationship" {
+				return "a relationship cannot connect to another relationship"
+			}
+			return ""
+		},
+	})
+	Register(Type{
+		Name:      "usecase",
+		NodeTypes: []string{"actor", "usecase", "package"},
+		Connect: func(source, target string) string {
+			if (source == "actor" && target == "package") || (source == "package" && target == "actor") {
+				return "an actor connects to use cases, not to the system boundary"
+			}
+			return ""
+		},
+	})
+	Register(Type{
+		Name:      "sequence",
+		NodeTypes: []string{"actor", "lifeline"},
+		DefaultContent: document.DocumentContent{
+			Nodes: []document.Node{
+				{ID: "user", Type: "actor", Label: "User", Position: document.Position{X: 100, Y: 100}},
+				{ID: "system", Type: "lifeline", Label: "System", Position: document.Position{X: 350, Y: 100}},
+			},
+		},
+		Connect: func(source, target string) string {
+			if source == "note" || target == "note" {
+				return "messages are sent between actors and lifelines, not notes"
+			}
+			return ""
+		},
+	})
+	Register(Type{
+		Name:      "class",
+		NodeTypes: []string{"class", "interface", "package"},
+		Connect: func(source, target string) string {
+			if source == "interface" && target == "class" {
+				return "an interface cannot extend or depend on a class"
+			}
+			return ""
+		},
+	})
+	Register(Type{
+		Name:      "activity",
+		NodeTypes: []string{"start-end", "decision"},
+		DefaultContent: document.DocumentContent{
+			Nodes: []document.Node{
+				{ID: "start", Type: "start-end", Label: "Start", Position: document.Position{X: 100, Y: 100}},
+			},
+		},
+	})
+	Register(Type{
+		Name:      "bpmn",
+		NodeTypes: []string{"start-event", "intermediate-event", "end-event", "gateway"},
+		DefaultContent: document.DocumentContent{
+			Nodes: []document.Node{
+				{ID: "start", Type: "start-event", Label: "Start", Position: document.Position{X: 100, Y: 100}},
+				{ID: "end", Type: "end-event", Label: "End", Position: document.Position{X: 400, Y: 100}},
+			},
+			Edges: []document.Edge{{ID: "flow", Source: "start", Target: "end"}},
+		},
+		Connect: func(source, target string) string {
+			switch {
+			case source == "end-event":
+				return "an end event cannot have outgoing flows"
+			case target == "start-event":
+				return "a start event cannot have incoming flows"
+			}
+			return ""
+		},
+	})
+}
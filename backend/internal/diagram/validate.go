@@ -0,0 +1,178 @@
+// Package diagram is the registry of diagram types and checks document
+// content and view against the rules of their type: which node and edge
+// types may be used, that every ID is unique and that edges and view entries
+// refer to elements that exist.
+package diagram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// maxErrors caps the number of errors reported for a single document.
+const maxErrors = 50
+
+// FieldError is a single validation failure. Field is a path into the
+// request body, e.g. "content.edges[2].target".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateContent checks nodes and edges against the rules of diagramType.
+// Unregistered diagram types only get the structural checks.
+func ValidateContent(diagramType string, content *document.DocumentContent) []FieldError {
+	t, _ := Lookup(diagramType)
+	var errs errorList
+
+	nodeTypes := make(map[string]string, len(content.Nodes))
+	for i, n := range content.Nodes {
+		field := fmt.Sprintf("content.nodes[%d]", i)
+		switch {
+		case n.ID == "":
+			errs.add(field+".id", "is required")
+		case nodeTypes[n.ID] != "":
+			errs.add(field+".id", fmt.Sprintf("duplicate node id %q", n.ID))
+		default:
+			nodeTypes[n.ID] = n.Type
+		}
+
+		checkNode(&errs, t, diagramType, field, n)
+	}
+
+	edgeIDs := make(map[string]bool, len(content.Edges))
+	for i, e := range content.Edges {
+		field := fmt.Sprintf("content.edges[%d]", i)
+		switch {
+		case e.ID == "":
+			errs.add(field+".id", "is required")
+		case edgeIDs[e.ID]:
+			errs.add(field+".id", fmt.Sprintf("duplicate edge id %q", e.ID))
+		case nodeTypes[e.ID] != "":
+			errs.add(field+".id", fmt.Sprintf("id %q is already used by a node", e.ID))
+		default:
+			edgeIDs[e.ID] = true
+		}
+
+		sourceType, sourceOK := nodeTypes[e.Source]
+		targetType, targetOK := nodeTypes[e.Target]
+		if !sourceOK {
+			errs.add(field+".source", fmt.Sprintf("node %q does not exist", e.Source))
+		}
+		if !targetOK {
+			errs.add(field+".target", fmt.Sprintf("node %q does not exist", e.Target))
+		}
+		if !sourceOK || !targetOK {
+			// Connection rules need both endpoint types.
+			sourceType, targetType = "", ""
+		}
+		checkEdge(&errs, t, field, e, sourceType, targetType)
+	}
+
+	return errs.list()
+}
+
+// ValidateNode checks a single node against the rules of diagramType, for
+// edits that add or change one node at a time. IDs are not checked.
+func ValidateNode(diagramType string, n document.Node) []FieldError {
+	t, _ := Lookup(diagramType)
+	var errs errorList
+	checkNode(&errs, t, diagramType, "node", n)
+	return errs.list()
+}
+
+// ValidateEdge checks a single edge between nodes of sourceType and
+// targetType against the rules of diagramType. IDs are not checked.
+func ValidateEdge(diagramType string, e document.Edge, sourceType, targetType string) []FieldError {
+	t, _ := Lookup(diagramType)
+	var errs errorList
+	checkEdge(&errs, t, "edge", e, sourceType, targetType)
+	return errs.list()
+}
+
+// checkNode checks a node's type and size. t is nil for unregistered types.
+func checkNode(errs *errorList, t *Type, diagramType, field string, n document.Node) {
+	if n.Type == "" {
+		errs.add(field+".type", "is required")
+	} else if t != nil && !t.AllowsNode(n.Type) {
+		errs.add(field+".type", fmt.Sprintf("%q is not a %s node type", n.Type, diagramType))
+	}
+	if n.Width != nil && *n.Width <= 0 {
+		errs.add(field+".width", "must be positive")
+	}
+	if n.Height != nil && *n.Height <= 0 {
+		errs.add(field+".height", "must be positive")
+	}
+}
+
+// checkEdge checks an edge's type and, when both endpoint types are known,
+// the connection rules of t. t is nil for unregistered types.
+func checkEdge(errs *errorList, t *Type, field string, e document.Edge, sourceType, targetType string) {
+	if t == nil {
+		return
+	}
+	if !t.AllowsEdge(e.Type) {
+		errs.add(field+".type", fmt.Sprintf("must be one of: %s", strings.Join(t.EdgeTypes, ", ")))
+	}
+	if sourceType != "" && targetType != "" && t.Connect != nil {
+		if reason := t.Connect(sourceType, targetType); reason != "" {
+			errs.add(field, reason)
+		}
+	}
+}
+
+// ValidateView checks that view entries refer to elements of content:
+// positions to nodes, routing to edges and styles to either.
+func ValidateView(content *document.DocumentContent, view *document.DocumentView) []FieldError {
+	nodes := make(map[string]bool, len(content.Nodes))
+	for _, n := range content.Nodes {
+		nodes[n.ID] = true
+	}
+	edges := make(map[string]bool, len(content.Edges))
+	for _, e := range content.Edges {
+		edges[e.ID] = true
+	}
+
+	var errs errorList
+	for _, id := range sortedKeys(view.Positions) {
+		if !nodes[id] {
+			errs.add("view.positions."+id, "node does not exist")
+		}
+	}
+	for _, id := range sortedKeys(view.Styles) {
+		if !nodes[id] && !edges[id] {
+			errs.add("view.styles."+id, "node or edge does not exist")
+		}
+	}
+	for _, id := range sortedKeys(view.Routing) {
+		if !edges[id] {
+			errs.add("view.routing."+id, "edge does not exist")
+		}
+	}
+	return errs.list()
+}
+
+// errorList collects up to maxErrors field errors.
+type errorList []FieldError
+
+func (l *errorList) add(field, message string) {
+	if len(*l) < maxErrors {
+		*l = append(*l, FieldError{Field: field, Message: message})
+	}
+}
+
+func (l errorList) list() []FieldError {
+	return l
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
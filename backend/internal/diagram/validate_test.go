@@ -0,0 +1,84 @@
+package diagram
+
+import (
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+func TestValidateContent(t *testing.T) {
+	node := func(id, nodeType string) document.Node { return document.Node{ID: id, Type: nodeType} }
+	tests := []struct {
+		name        string
+		diagramType string
+		content     document.DocumentContent
+		fields      []string
+	}{
+		{
+			name:        "flowchart shapes",
+			diagramType: "flowchart",
+			content: document.DocumentContent{
+				Nodes: []document.Node{node("a", "start-end"), node("b", "decision")},
+				Edges: []document.Edge{{ID: "e", Source: "a", Target: "b", Type: "step"}},
+			},
+		},
+		{
+			name:        "shapes from other palettes",
+			diagramType: "flowchart",
+			content: document.DocumentContent{
+				Nodes: []document.Node{node("a", "server"), node("b", "entity"), node("c", "actor"), node("d", "class")},
+			},
+		},
+		{
+			name:        "gateway in a use case diagram",
+			diagramType: "usecase",
+			content:     document.DocumentContent{Nodes: []document.Node{node("a", "gateway")}},
+		},
+		{
+			name:        "unknown node and edge types",
+			diagramType: "flowchart",
+			content: document.DocumentContent{
+				Nodes: []document.Node{node("a", "spaceship"), node("b", "process")},
+				Edges: []document.Edge{{ID: "e", Source: "a", Target: "b", Type: "wavy"}},
+			},
+			fields: []string{"content.nodes[0].type", "content.edges[0].type"},
+		},
+		{
+			name:        "unregistered diagram type only checks structure",
+			diagramType: "mindmap",
+			content:     document.DocumentContent{Nodes: []document.Node{node("a", "spaceship")}},
+		},
+		{
+			name:        "duplicate and dangling",
+			diagramType: "flowchart",
+			content: document.DocumentContent{
+				Nodes: []document.Node{node("a", "process"), node("a", "process")},
+				Edges: []document.Edge{{ID: "a", Source: "a", Target: "x"}},
+			},
+			fields: []string{"content.nodes[1].id", "content.edges[0].id", "content.edges[0].target"},
+		},
+		{
+			name:        "connection rule",
+			diagramType: "bpmn",
+			content: document.DocumentContent{
+				Nodes: []document.Node{node("s", "start-event"), node("e", "end-event")},
+				Edges: []document.Edge{{ID: "f", Source: "e", Target: "s"}},
+			},
+			fields: []string{"content.edges[0]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateContent(tt.diagramType, &tt.content)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("errors = %+v, want fields %v", errs, tt.fields)
+			}
+			for i, e := range errs {
+				if e.Field != tt.fields[i] {
+					t.Errorf("error %d field = %q (%s), want %q", i, e.Field, e.Message, tt.fields[i])
+				}
+			}
+		})
+	}
+}
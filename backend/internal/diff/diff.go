@@ -0,0 +1,237 @@
+// Package diff computes a structural diff between two diagram versions.
+// Nodes and edges are matched by ID; modified elements carry field-level changes
+// so that clients can highlight exactly what moved, was relabelled or restyled.
+// The view is overlaid on the content the way the renderer does, so a node
+// dragged or recolored in the editor shows up as a change.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// FieldChange is a single changed field of a node or edge.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// ElementChange lists the changed fields of a node or edge present in both versions.
+type ElementChange struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// Result is the structural difference between two versions.
+// Added and modified elements follow the order of the newer content,
+// removed elements the order of the older one.
+type Result struct {
+	AddedNodes    []document.Node `json:"added_nodes"`
+	RemovedNodes  []document.Node `json:"removed_nodes"`
+	ModifiedNodes []ElementChange `json:"modified_nodes"`
+	AddedEdges    []document.Edge `json:"added_edges"`
+	RemovedEdges  []document.Edge `json:"removed_edges"`
+	ModifiedEdges []ElementChange `json:"modified_edges"`
+}
+
+// IsEmpty reports whether the two contents were structurally identical.
+func (r *Result) IsEmpty() bool {
+	return len(r.AddedNodes) == 0 && len(r.RemovedNodes) == 0 && len(r.ModifiedNodes) == 0 &&
+		len(r.AddedEdges) == 0 && len(r.RemovedEdges) == 0 && len(r.ModifiedEdges) == 0
+}
+
+// Compare returns the changes needed to go from `from` to `to`. Each content
+// is read through its view (nil for none): a view position replaces the node
+// position, and view styles and routing are compared as the "style" and
+// "routing" fields.
+func Compare(from, to *document.DocumentContent, fromView, toView *document.DocumentView) *Result {
+	if fromView == nil {
+		fromView = &document.DocumentView{}
+	}
+	if toView == nil {
+		toView = &document.DocumentView{}
+	}
+
+	r := &Result{
+		AddedNodes:    []document.Node{},
+		RemovedNodes:  []document.Node{},
+		ModifiedNodes: []ElementChange{},
+		AddedEdges:    []document.Edge{},
+		RemovedEdges:  []document.Edge{},
+		ModifiedEdges: []ElementChange{},
+	}
+
+	// --- Nodes ---
+	oldNodes := make(map[string]*document.Node, len(from.Nodes))
+	for i := range from.Nodes {
+		oldNodes[from.Nodes[i].ID] = &from.Nodes[i]
+	}
+	newNodes := make(map[string]bool, len(to.Nodes))
+	for i := range to.Nodes {
+		n := &to.Nodes[i]
+		newNodes[n.ID] = true
+		old, ok := oldNodes[n.ID]
+		if !ok {
+			r.AddedNodes = append(r.AddedNodes, *n)
+			continue
+		}
+		if changes := compareNodes(overlayNode(old, fromView), overlayNode(n, toView), fromView.Styles[n.ID], toView.Styles[n.ID]); len(changes) > 0 {
+			r.ModifiedNodes = append(r.ModifiedNodes, ElementChange{ID: n.ID, Changes: changes})
+		}
+	}
+	for _, n := range from.Nodes {
+		if !newNodes[n.ID] {
+			r.RemovedNodes = append(r.RemovedNodes, n)
+		}
+	}
+
+	// --- Edges ---
+	oldEdges := make(map[string]*document.Edge, len(from.Edges))
+	for i := range from.Edges {
+		oldEdges[from.Edges[i].ID] = &from.Edges[i]
+	}
+	newEdges := make(map[string]bool, len(to.Edges))
+	for i := range to.Edges {
+		e := &to.Edges[i]
+		newEdges[e.ID] = true
+		old, ok := oldEdges[e.ID]
+		if !ok {
+			r.AddedEdges = append(r.AddedEdges, *e)
+			continue
+		}
+		if changes := compareEdges(old, e, fromView, toView); len(changes) > 0 {
+			r.ModifiedEdges = append(r.ModifiedEdges, ElementChange{ID: e.ID, Changes: changes})
+		}
+	}
+	for _, e := range from.Edges {
+		if !newEdges[e.ID] {
+			r.RemovedEdges = append(r.RemovedEdges, e)
+		}
+	}
+
+	return r
+}
+
+// overlayNode returns n at the position its view gives it.
+func overlayNode(n *document.Node, view *document.DocumentView) *document.Node {
+	p, ok := view.Positions[n.ID]
+	if !ok {
+		return n
+	}
+	overlaid := *n
+	overlaid.Position = p
+	return &overlaid
+}
+
+func compareNodes(a, b *document.Node, styleA, styleB map[string]interface{}) []FieldChange {
+	var changes []FieldChange
+	if a.Label != b.Label {
+		changes = append(changes, FieldChange{Field: "label", From: a.Label, To: b.Label})
+	}
+	if a.Type != b.Type {
+		changes = append(changes, FieldChange{Field: "type", From: a.Type, To: b.Type})
+	}
+	if a.Position != b.Position {
+		changes = append(changes, FieldChange{Field: "position", From: a.Position, To: b.Position})
+	}
+	if !equalFloatPtr(a.Width, b.Width) {
+		changes = append(changes, FieldChange{Field: "width", From: a.Width, To: b.Width})
+	}
+	if !equalFloatPtr(a.Height, b.Height) {
+		changes = append(changes, FieldChange{Field: "height", From: a.Height, To: b.Height})
+	}
+	if a.Color != b.Color {
+		changes = append(changes, FieldChange{Field: "color", From: a.Color, To: b.Color})
+	}
+	if !equalJSON(a.Data, b.Data) {
+		changes = append(changes, FieldChange{Field: "data", From: rawOrNil(a.Data), To: rawOrNil(b.Data)})
+	}
+	if !equalJSON(a.Properties, b.Properties) {
+		changes = append(changes, FieldChange{Field: "properties", From: rawOrNil(a.Properties), To: rawOrNil(b.Properties)})
+	}
+	if !equalValue(styleA, styleB) {
+		changes = append(changes, FieldChange{Field: "style", From: styleA, To: styleB})
+	}
+	return changes
+}
+
+func compareEdges(a, b *document.Edge, viewA, viewB *document.DocumentView) []FieldChange {
+	var changes []FieldChange
+	if a.Label != b.Label {
+		changes = append(changes, FieldChange{Field: "label", From: a.Label, To: b.Label})
+	}
+	if a.Type != b.Type {
+		changes = append(changes, FieldChange{Field: "type", From: a.Type, To: b.Type})
+	}
+	if a.Source != b.Source {
+		changes = append(changes, FieldChange{Field: "source", From: a.Source, To: b.Source})
+	}
+	if a.Target != b.Target {
+		changes = append(changes, FieldChange{Field: "target", From: a.Target, To: b.Target})
+	}
+	if styleA, styleB := viewA.Styles[a.ID], viewB.Styles[b.ID]; !equalValue(styleA, styleB) {
+		changes = append(changes, FieldChange{Field: "style", From: styleA, To: styleB})
+	}
+	if routeA, routeB := viewA.Routing[a.ID], viewB.Routing[b.ID]; !equalValue(routeA, routeB) {
+		changes = append(changes, FieldChange{Field: "routing", From: routeA, To: routeB})
+	}
+	return changes
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// equalJSON compares two JSON values semantically (key order and whitespace are ignored).
+func equalJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	if isNullJSON(a) || isNullJSON(b) {
+		return isNullJSON(a) && isNullJSON(b)
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// equalValue compares decoded view values; a missing entry equals an empty one.
+func equalValue(a, b any) bool {
+	if isEmptyValue(a) && isEmptyValue(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isEmptyValue(v any) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Map, reflect.Slice:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+func isNullJSON(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// rawOrNil keeps empty data as JSON null instead of an invalid empty RawMessage.
+func rawOrNil(raw json.RawMessage) any {
+	if isNullJSON(raw) {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+func TestCompare(t *testing.T) {
+	base := func() *document.DocumentContent {
+		return &document.DocumentContent{
+			Nodes: []document.Node{
+				{ID: "a", Type: "process", Label: "A", Position: document.Position{X: 0, Y: 0}},
+				{ID: "b", Type: "process", Label: "B", Position: document.Position{X: 200, Y: 0}},
+			},
+			Edges: []document.Edge{{ID: "e1", Source: "a", Target: "b"}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		to       func(*document.DocumentContent)
+		fromView *document.DocumentView
+		toView   *document.DocumentView
+		nodes    []ElementChange
+		edges    []ElementChange
+	}{
+		{
+			name: "identical",
+		},
+		{
+			name: "node moved in content",
+			to:   func(c *document.DocumentContent) { c.Nodes[0].Position = document.Position{X: 50, Y: 80} },
+			nodes: []ElementChange{{ID: "a", Changes: []FieldChange{
+				{Field: "position", From: document.Position{X: 0, Y: 0}, To: document.Position{X: 50, Y: 80}},
+			}}},
+		},
+		{
+			name:   "node moved in view",
+			toView: &document.DocumentView{Positions: map[string]document.Position{"b": {X: 300, Y: 40}}},
+			nodes: []ElementChange{{ID: "b", Changes: []FieldChange{
+				{Field: "position", From: document.Position{X: 200, Y: 0}, To: document.Position{X: 300, Y: 40}},
+			}}},
+		},
+		{
+			name:     "view position matching content",
+			fromView: &document.DocumentView{Positions: map[string]document.Position{"a": {X: 0, Y: 0}}},
+		},
+		{
+			name:   "node recolored in view",
+			toView: &document.DocumentView{Styles: map[string]map[string]interface{}{"a": {"color": "red"}}},
+			nodes: []ElementChange{{ID: "a", Changes: []FieldChange{
+				{Field: "style", From: map[string]interface{}(nil), To: map[string]interface{}{"color": "red"}},
+			}}},
+		},
+		{
+			name:     "empty style equals none",
+			fromView: &document.DocumentView{Styles: map[string]map[string]interface{}{"a": {}}},
+		},
+		{
+			name: "node resized in properties",
+			to:   func(c *document.DocumentContent) { c.Nodes[1].Properties = json.RawMessage(`{"width":180}`) },
+			nodes: []ElementChange{{ID: "b", Changes: []FieldChange{
+				{Field: "properties", From: nil, To: json.RawMessage(`{"width":180}`)},
+			}}},
+		},
+		{
+			name:   "edge rerouted in view",
+			toView: &document.DocumentView{Routing: map[string]interface{}{"e1": "orthogonal"}},
+			edges: []ElementChange{{ID: "e1", Changes: []FieldChange{
+				{Field: "routing", From: nil, To: "orthogonal"},
+			}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := base(), base()
+			if tt.to != nil {
+				tt.to(to)
+			}
+			r := Compare(from, to, tt.fromView, tt.toView)
+
+			if len(r.AddedNodes)+len(r.RemovedNodes)+len(r.AddedEdges)+len(r.RemovedEdges) != 0 {
+				t.Errorf("unexpected added or removed elements: %+v", r)
+			}
+			if tt.nodes == nil {
+				tt.nodes = []ElementChange{}
+			}
+			if tt.edges == nil {
+				tt.edges = []ElementChange{}
+			}
+			if !reflect.DeepEqual(r.ModifiedNodes, tt.nodes) {
+				t.Errorf("modified nodes = %+v, want %+v", r.ModifiedNodes, tt.nodes)
+			}
+			if !reflect.DeepEqual(r.ModifiedEdges, tt.edges) {
+				t.Errorf("modified edges = %+v, want %+v", r.ModifiedEdges, tt.edges)
+			}
+			if r.IsEmpty() != (len(tt.nodes) == 0 && len(tt.edges) == 0) {
+				t.Errorf("IsEmpty() = %v", r.IsEmpty())
+			}
+		})
+	}
+}
+
+func TestCompareAddedAndRemoved(t *testing.T) {
+	from := &document.DocumentContent{
+		Nodes: []document.Node{{ID: "a", Type: "process"}, {ID: "b", Type: "process"}},
+		Edges: []document.Edge{{ID: "e1", Source: "a", Target: "b"}},
+	}
+	to := &document.DocumentContent{
+		Nodes: []document.Node{{ID: "a", Type: "process"}, {ID: "c", Type: "decision"}},
+		Edges: []document.Edge{{ID: "e2", Source: "a", Target: "c"}},
+	}
+
+	r := Compare(from, to, nil, nil)
+	if len(r.AddedNodes) != 1 || r.AddedNodes[0].ID != "c" {
+		t.Errorf("added nodes = %+v, want c", r.AddedNodes)
+	}
+	if len(r.RemovedNodes) != 1 || r.RemovedNodes[0].ID != "b" {
+		t.Errorf("removed nodes = %+v, want b", r.RemovedNodes)
+	}
+	if len(r.AddedEdges) != 1 || r.AddedEdges[0].ID != "e2" {
+		t.Errorf("added edges = %+v, want e2", r.AddedEdges)
+	}
+	if len(r.RemovedEdges) != 1 || r.RemovedEdges[0].ID != "e1" {
+		t.Errorf("removed edges = %+v, want e1", r.RemovedEdges)
+	}
+}
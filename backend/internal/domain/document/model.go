@@ -15,6 +15,9 @@ type Node struct {
 	Label    string          `json:"label"           bson:"label"`
 	Color    string          `json:"color,omitempty" bson:"color,omitempty"`
 	Data     json.RawMessage `json:"data,omitempty"  bson:"data,omitempty"`
+	// Properties holds editor settings such as width, height and locked,
+	// plus custom keys; the backend stores them as-is.
+	Properties json.RawMessage `json:"properties,omitempty" bson:"properties,omitempty"`
 }
 
 type Position struct {
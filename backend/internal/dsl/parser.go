@@ -0,0 +1,246 @@
+// Package dsl implements the GraDiOl text DSL (Konsep Aplikasi §5.1).
+// It mirrors the frontend engine in frontend/src/lib/dsl:
+// Parser (text → AST), Transformer (AST → DocumentContent) and
+// Serializer (DocumentContent → text).
+//
+// Example:
+//
+//	@flowchart "Login Process"
+//
+//	start "Mulai"
+//	process "Input Credentials"
+//	decision "Valid?"
+//	end "Selesai"
+//
+//	start -> "Input Credentials"
+//	"Input Credentials" -> "Valid?"
+//	"Valid?" --yes--> end
+//	"Valid?" --no--> "Input Credentials"
+package dsl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AST is the parsed form of a DSL document.
+type AST struct {
+	DiagramType string
+	Title       string
+	Nodes       []NodeDecl
+	Edges       []EdgeDecl
+}
+
+// NodeDecl is a node declaration, e.g. `process "Input Credentials"`.
+type NodeDecl struct {
+	Keyword    string   // DSL keyword as written: start, end, process, entity, ...
+	Label      string   // Display label, also used to reference the node from edges
+	Attributes []string // Lines inside a `{ ... }` block (entity/class attributes)
+	Line       int
+}
+
+// EdgeDecl is an edge declaration, e.g. `"A" --yes--> "B"`.
+type EdgeDecl struct {
+	Source string
+	Target string
+	Label  string
+	Line   int
+}
+
+// ParseError reports a syntax error with its 1-based line number.
+type ParseError struct {
+	Line int    `json:"line"`
+	Msg  string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+var metaRe = regexp.MustCompile(`^@(\w+)(?:\s+(.*))?$`)
+
+// Parse converts DSL text into an AST.
+// Blank lines and `//` comments are ignored. Unlike the frontend parser,
+// unrecognized lines are reported as a *ParseError instead of being skipped.
+func Parse(text string) (*AST, error) {
+	ast := &AST{DiagramType: "flowchart"}
+
+	var block *NodeDecl
+	for i, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		lineNo := i + 1
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		// Inside an attribute block every line is taken verbatim until `}`
+		if block != nil {
+			if line == "}" {
+				ast.Nodes = append(ast.Nodes, *block)
+				block = nil
+				continue
+			}
+			block.Attributes = append(block.Attributes, line)
+			continue
+		}
+
+		// Meta: @flowchart "Title"
+		if m := metaRe.FindStringSubmatch(line); m != nil {
+			ast.DiagramType = m[1]
+			title, err := unquoteLabel(strings.TrimSpace(m[2]))
+			if err != nil {
+				return nil, &ParseError{Line: lineNo, Msg: "invalid title: " + err.Error()}
+			}
+			ast.Title = title
+			continue
+		}
+
+		toks, err := lex(line)
+		if err != nil {
+			return nil, &ParseError{Line: lineNo, Msg: err.Error()}
+		}
+
+		switch {
+		// Edge: A -> B | A --label--> B | A -> B : label
+		case len(toks) >= 3 && toks[0].isRef() && toks[1].kind == tokArrow && toks[2].isRef():
+			edge := EdgeDecl{Source: toks[0].text, Target: toks[2].text, Label: toks[1].text, Line: lineNo}
+			switch {
+			case len(toks) == 3:
+			case len(toks) == 4 && toks[3].kind == tokColon && toks[1].text == "":
+				edge.Label = toks[3].text
+			default:
+				return nil, &ParseError{Line: lineNo, Msg: "unexpected tokens after edge"}
+			}
+			ast.Edges = append(ast.Edges, edge)
+
+		// Block start: entity "Customer" {
+		case len(toks) == 3 && toks[0].kind == tokWord && toks[1].isRef() && toks[2].kind == tokLBrace:
+			block = &NodeDecl{Keyword: toks[0].text, Label: toks[1].text, Attributes: []string{}, Line: lineNo}
+
+		// Node: process "Label" | process Label
+		case len(toks) == 2 && toks[0].kind == tokWord && toks[1].isRef():
+			ast.Nodes = append(ast.Nodes, NodeDecl{Keyword: toks[0].text, Label: toks[1].text, Line: lineNo})
+
+		default:
+			return nil, &ParseError{Line: lineNo, Msg: fmt.Sprintf("unrecognized statement %q", line)}
+		}
+	}
+
+	if block != nil {
+		return nil, &ParseError{Line: block.Line, Msg: fmt.Sprintf("unterminated block for %q", block.Label)}
+	}
+
+	return ast, nil
+}
+
+// ─── Lexer ──────────────────────────────────────────────
+
+type tokKind int
+
+const (
+	tokWord   tokKind = iota // bare identifier
+	tokString                // "quoted label"
+	tokArrow                 // -> or --label--> (text holds the label)
+	tokColon                 // : rest-of-line label (text holds the label)
+	tokLBrace                // {
+)
+
+type token struct {
+	kind tokKind
+	text string
+}
+
+func (t token) isRef() bool {
+	return t.kind == tokWord || t.kind == tokString
+}
+
+// lex splits a single statement line into tokens.
+func lex(line string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(line) {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '"':
+			end := closingQuote(line, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			s, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %s", line[i:end+1])
+			}
+			toks = append(toks, token{kind: tokString, text: s})
+			i = end + 1
+
+		case strings.HasPrefix(line[i:], "->"):
+			toks = append(toks, token{kind: tokArrow})
+			i += 2
+
+		case strings.HasPrefix(line[i:], "--"):
+			end := strings.Index(line[i+2:], "-->")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated labeled arrow, expected --label-->")
+			}
+			label := strings.TrimSpace(line[i+2 : i+2+end])
+			toks = append(toks, token{kind: tokArrow, text: label})
+			i += 2 + end + 3
+
+		case c == ':':
+			label, err := unquoteLabel(strings.TrimSpace(line[i+1:]))
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokColon, text: label})
+			i = len(line)
+
+		case c == '{':
+			toks = append(toks, token{kind: tokLBrace})
+			i++
+
+		default:
+			start := i
+			for i < len(line) && !isWordBreak(line, i) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected character %q", line[i])
+			}
+			toks = append(toks, token{kind: tokWord, text: line[start:i]})
+		}
+	}
+	return toks, nil
+}
+
+func isWordBreak(line string, i int) bool {
+	switch line[i] {
+	case ' ', '\t', '"', '{', '}', ':':
+		return true
+	}
+	return strings.HasPrefix(line[i:], "->") || strings.HasPrefix(line[i:], "--")
+}
+
+// closingQuote returns the index of the quote closing the string opened at start, or -1.
+func closingQuote(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// unquoteLabel unquotes s if it is a quoted string, otherwise returns it unchanged.
+func unquoteLabel(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	}
+	return s, nil
+}
@@ -0,0 +1,128 @@
+package dsl
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// typeToKeyword maps frontend NodeType values back to DSL keywords
+// (frontend/src/lib/dsl/serializer.ts TYPE_TO_DSL).
+var typeToKeyword = map[string]string{
+	"start-end":    "start",
+	"process":      "process",
+	"decision":     "decision",
+	"entity":       "entity",
+	"actor":        "actor",
+	"input-output": "io",
+	"database":     "db",
+	"text":         "text",
+	"lifeline":     "lifeline",
+	"usecase":      "usecase",
+	"relationship": "rel",
+	"attribute":    "attr",
+}
+
+// Serialize converts DocumentContent back to DSL text.
+//
+// Output is deterministic: a header line, node declarations in content order,
+// then edge declarations in content order, each separated by a blank line.
+// Labels are always quoted so that Text → Diagram → Text round-trips.
+// A start-end node is written as `end` when it has incoming but no outgoing edges.
+// Positions and styles are not part of the DSL and are recomputed on import.
+func Serialize(content *document.DocumentContent, diagramType, title string) string {
+	var b strings.Builder
+
+	b.WriteString("@" + diagramType + " " + strconv.Quote(title) + "\n")
+
+	outgoing := make(map[string]int)
+	incoming := make(map[string]int)
+	labels := make(map[string]string, len(content.Nodes))
+	for _, n := range content.Nodes {
+		labels[n.ID] = n.Label
+	}
+	for _, e := range content.Edges {
+		outgoing[e.Source]++
+		incoming[e.Target]++
+	}
+
+	if len(content.Nodes) > 0 {
+		b.WriteString("\n")
+	}
+	for _, n := range content.Nodes {
+		keyword, ok := typeToKeyword[n.Type]
+		if !ok {
+			keyword = "process"
+		}
+		if n.Type == "start-end" && outgoing[n.ID] == 0 && incoming[n.ID] > 0 {
+			keyword = "end"
+		}
+
+		attrs := nodeAttributes(n.Data)
+		if len(attrs) == 0 {
+			b.WriteString(keyword + " " + strconv.Quote(n.Label) + "\n")
+			continue
+		}
+		b.WriteString(keyword + " " + strconv.Quote(n.Label) + " {\n")
+		for _, attr := range attrs {
+			b.WriteString("  " + attr + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	if len(content.Edges) > 0 {
+		b.WriteString("\n")
+	}
+	for _, e := range content.Edges {
+		source, ok := labels[e.Source]
+		if !ok {
+			source = e.Source
+		}
+		target, ok := labels[e.Target]
+		if !ok {
+			target = e.Target
+		}
+
+		switch {
+		case e.Label == "":
+			b.WriteString(strconv.Quote(source) + " -> " + strconv.Quote(target) + "\n")
+		case inlineLabelSafe(e.Label):
+			b.WriteString(strconv.Quote(source) + " --" + e.Label + "--> " + strconv.Quote(target) + "\n")
+		default:
+			b.WriteString(strconv.Quote(source) + " -> " + strconv.Quote(target) + " : " + strconv.Quote(e.Label) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// nodeAttributes extracts the attribute list from Node.Data, if any.
+func nodeAttributes(data json.RawMessage) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	var d nodeData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil
+	}
+	// Attribute lines must survive re-parsing inside a block
+	attrs := make([]string, 0, len(d.Attributes))
+	for _, a := range d.Attributes {
+		if a = strings.TrimSpace(a); a != "" && a != "}" && !strings.HasPrefix(a, "//") {
+			attrs = append(attrs, a)
+		}
+	}
+	return attrs
+}
+
+// inlineLabelSafe reports whether label can be written in `--label-->` form
+// and parsed back unchanged; otherwise the quoted `: "label"` form is used.
+func inlineLabelSafe(label string) bool {
+	return label == strings.TrimSpace(label) &&
+		!strings.HasPrefix(label, "-") &&
+		!strings.HasSuffix(label, "-") &&
+		!strings.Contains(label, "-->") &&
+		!strings.ContainsAny(label, "\"\n\r")
+}
@@ -0,0 +1,92 @@
+package dsl
+
+import "testing"
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "flowchart",
+			src: `@flowchart "Login Process"
+
+start "Mulai"
+process "Input Credentials"
+decision "Valid?"
+end "Selesai"
+
+start -> "Input Credentials"
+"Input Credentials" -> "Valid?"
+"Valid?" --yes--> end
+"Valid?" --no--> "Input Credentials"
+`,
+		},
+		{
+			name: "erd with attributes",
+			src: `@erd "Shop"
+
+entity "User" {
+  id PK
+  email
+}
+rel "places"
+entity "Order"
+
+"User" -> "places"
+"places" -> "Order"
+`,
+		},
+		{
+			name: "quotes and keywords in labels",
+			src: `@flowchart "Say \"hi\""
+
+process "end"
+io "Read \"file\""
+
+"end" -> "Read \"file\""
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, content, err := ParseContent(tt.src)
+			if err != nil {
+				t.Fatalf("ParseContent: %v", err)
+			}
+			text := Serialize(content, ast.DiagramType, ast.Title)
+
+			again, contentAgain, err := ParseContent(text)
+			if err != nil {
+				t.Fatalf("ParseContent(Serialize): %v\n%s", err, text)
+			}
+			if again.DiagramType != ast.DiagramType || again.Title != ast.Title {
+				t.Errorf("header = @%s %q, want @%s %q", again.DiagramType, again.Title, ast.DiagramType, ast.Title)
+			}
+			if len(contentAgain.Nodes) != len(content.Nodes) || len(contentAgain.Edges) != len(content.Edges) {
+				t.Fatalf("round trip has %d nodes and %d edges, want %d and %d\n%s",
+					len(contentAgain.Nodes), len(contentAgain.Edges), len(content.Nodes), len(content.Edges), text)
+			}
+			for i, n := range content.Nodes {
+				got := contentAgain.Nodes[i]
+				if got.ID != n.ID || got.Type != n.Type || got.Label != n.Label || got.Position != n.Position {
+					t.Errorf("node %d = %+v, want %+v", i, got, n)
+				}
+				if string(got.Data) != string(n.Data) {
+					t.Errorf("node %d data = %s, want %s", i, got.Data, n.Data)
+				}
+			}
+			for i, e := range content.Edges {
+				if got := contentAgain.Edges[i]; got != e {
+					t.Errorf("edge %d = %+v, want %+v", i, got, e)
+				}
+			}
+
+			// Serializing is stable once the text has been through a round trip.
+			if text2 := Serialize(contentAgain, again.DiagramType, again.Title); text2 != text {
+				t.Errorf("second serialization differs:\n%s\nwant:\n%s", text2, text)
+			}
+		})
+	}
+}
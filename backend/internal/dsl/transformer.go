@@ -0,0 +1,213 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// keywordToType maps DSL keywords to frontend NodeType values
+// (frontend/src/lib/dsl/transformer.ts NODE_TYPE_MAP).
+var keywordToType = map[string]string{
+	"start":        "start-end",
+	"end":          "start-end",
+	"start-end":    "start-end",
+	"process":      "process",
+	"decision":     "decision",
+	"entity":       "entity",
+	"actor":        "actor",
+	"io":           "input-output",
+	"input-output": "input-output",
+	"db":           "database",
+	"database":     "database",
+	"text":         "text",
+	"lifeline":     "lifeline",
+	"usecase":      "usecase",
+	"rel":          "relationship",
+	"relationship": "relationship",
+	"attr":         "attribute",
+	"attribute":    "attribute",
+}
+
+// Layout constants — kept in sync with the frontend transformer.
+const (
+	nodeWidth  = 140.0
+	nodeHeight = 60.0
+	gapX       = 180.0
+	gapY       = 100.0
+	originY    = 60.0
+)
+
+// nodeData is the shape of Node.Data produced for nodes with attribute blocks.
+type nodeData struct {
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+// Transform converts an AST into DocumentContent.
+// Node IDs are assigned in declaration order (n1, n2, ...) and edge IDs likewise (e1, e2, ...).
+// Edges reference nodes by label; the bare keywords `start` and `end` also resolve to the
+// first start/end node. Positions are computed with a layered top-down layout.
+func Transform(ast *AST) (*document.DocumentContent, error) {
+	content := &document.DocumentContent{
+		Nodes: make([]document.Node, 0, len(ast.Nodes)),
+		Edges: make([]document.Edge, 0, len(ast.Edges)),
+	}
+
+	byLabel := make(map[string]string, len(ast.Nodes))
+	byKeyword := make(map[string]string, 2)
+
+	for i, decl := range ast.Nodes {
+		nodeType, ok := keywordToType[decl.Keyword]
+		if !ok {
+			return nil, &ParseError{Line: decl.Line, Msg: fmt.Sprintf("unknown node type %q", decl.Keyword)}
+		}
+
+		node := document.Node{
+			ID:    fmt.Sprintf("n%d", i+1),
+			Type:  nodeType,
+			Label: decl.Label,
+		}
+
+		width, height := nodeWidth, nodeHeight
+		if len(decl.Attributes) > 0 {
+			// Header + one row per attribute + padding (matches frontend sizing)
+			height = 30 + float64(len(decl.Attributes))*16 + 10
+			data, err := json.Marshal(nodeData{Attributes: decl.Attributes})
+			if err != nil {
+				return nil, err
+			}
+			node.Data = data
+		}
+		node.Width = &width
+		node.Height = &height
+
+		if _, dup := byLabel[decl.Label]; !dup {
+			byLabel[decl.Label] = node.ID
+		}
+		if decl.Keyword == "start" || decl.Keyword == "end" {
+			if _, seen := byKeyword[decl.Keyword]; !seen {
+				byKeyword[decl.Keyword] = node.ID
+			}
+		}
+
+		content.Nodes = append(content.Nodes, node)
+	}
+
+	resolve := func(ref string) (string, bool) {
+		if id, ok := byLabel[ref]; ok {
+			return id, true
+		}
+		id, ok := byKeyword[ref]
+		return id, ok
+	}
+
+	for i, decl := range ast.Edges {
+		source, ok := resolve(decl.Source)
+		if !ok {
+			return nil, &ParseError{Line: decl.Line, Msg: fmt.Sprintf("unknown node %q", decl.Source)}
+		}
+		target, ok := resolve(decl.Target)
+		if !ok {
+			return nil, &ParseError{Line: decl.Line, Msg: fmt.Sprintf("unknown node %q", decl.Target)}
+		}
+		content.Edges = append(content.Edges, document.Edge{
+			ID:     fmt.Sprintf("e%d", i+1),
+			Source: source,
+			Target: target,
+			Type:   "step",
+			Label:  decl.Label,
+		})
+	}
+
+	layout(content)
+	return content, nil
+}
+
+// ParseContent parses DSL text straight into DocumentContent.
+func ParseContent(text string) (*AST, *document.DocumentContent, error) {
+	ast, err := Parse(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := Transform(ast)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ast, content, nil
+}
+
+// layout assigns positions using BFS layering from root nodes (no incoming edges).
+// Nodes within a layer keep declaration order, and each layer is centered on the widest one.
+func layout(content *document.DocumentContent) {
+	if len(content.Nodes) == 0 {
+		return
+	}
+
+	index := make(map[string]int, len(content.Nodes))
+	for i, n := range content.Nodes {
+		index[n.ID] = i
+	}
+
+	children := make([][]int, len(content.Nodes))
+	indegree := make([]int, len(content.Nodes))
+	for _, e := range content.Edges {
+		s, t := index[e.Source], index[e.Target]
+		if s == t {
+			continue
+		}
+		children[s] = append(children[s], t)
+		indegree[t]++
+	}
+
+	layer := make([]int, len(content.Nodes))
+	visited := make([]bool, len(content.Nodes))
+	var queue []int
+	for i := range content.Nodes {
+		if indegree[i] == 0 {
+			visited[i] = true
+			queue = append(queue, i)
+		}
+	}
+	if len(queue) == 0 {
+		visited[0] = true
+		queue = append(queue, 0)
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, child := range children[cur] {
+			if !visited[child] {
+				visited[child] = true
+				layer[child] = layer[cur] + 1
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	// Group by layer; unreachable nodes (cycles without a root) stay in layer 0
+	var layers [][]int
+	for i := range content.Nodes {
+		for len(layers) <= layer[i] {
+			layers = append(layers, nil)
+		}
+		layers[layer[i]] = append(layers[layer[i]], i)
+	}
+
+	widest := 0
+	for _, l := range layers {
+		widest = max(widest, len(l))
+	}
+	center := float64(widest-1) * gapX / 2
+
+	for depth, l := range layers {
+		startX := center - float64(len(l)-1)*gapX/2
+		for pos, i := range l {
+			content.Nodes[i].Position = document.Position{
+				X: startX + float64(pos)*gapX,
+				Y: originY + float64(depth)*gapY,
+			}
+		}
+	}
+}
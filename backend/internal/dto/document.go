@@ -3,24 +3,31 @@ package dto
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/diff"
 )
 
 // CreateDocumentReq is the body for POST /api/documents.
+// Mermaid imports a flowchart or erDiagram as the content instead of
+// Content; DiagramType may then be omitted and is taken from the diagram.
 type CreateDocumentReq struct {
 	WorkspaceID string           `json:"workspace_id" validate:"required,uuid"`
 	ProjectID   *string          `json:"project_id"   validate:"omitempty,uuid"`
 	Title       string           `json:"title"        validate:"omitempty,max=200"`
-	DiagramType string           `json:"diagram_type" validate:"required,oneof=flowchart erd usecase"`
-	Content     *json.RawMessage `json:"content"`
+	DiagramType string           `json:"diagram_type" validate:"required_without=Mermaid"`
+	Content     *json.RawMessage `json:"content"      validate:"excluded_with=Mermaid"`
 	View        *json.RawMessage `json:"view"`
+	Mermaid     *string          `json:"mermaid"`
 }
 
 // UpdateDocumentReq is the body for PUT /api/documents/:id.
+// ExpectedVersion (or an If-Match ETag) makes the update conditional on the stored version.
 type UpdateDocumentReq struct {
-	Title     *string          `json:"title"      validate:"omitempty,max=200"`
-	ProjectID *string          `json:"project_id"` // nullable — can move or set to null
-	Content   *json.RawMessage `json:"content"`
-	View      *json.RawMessage `json:"view"`
+	Title           *string          `json:"title"            validate:"omitempty,max=200"`
+	ProjectID       *string          `json:"project_id"` // nullable — can move or set to null
+	Content         *json.RawMessage `json:"content"`
+	View            *json.RawMessage `json:"view"`
+	ExpectedVersion *int             `json:"expected_version" validate:"omitempty,min=1"`
 }
 
 // DocumentResp is the full response for a single document (GET /api/documents/:id).
@@ -74,10 +81,119 @@ type RecentDocumentResp struct {
 	Data []RecentDocumentItem `json:"data"`
 }
 
+// DocumentVersionResp is the response for GET /api/documents/:id/versions/:version.
+type DocumentVersionResp struct {
+	DocumentID string          `json:"document_id"`
+	Version    int             `json:"version"`
+	Title      string          `json:"title"`
+	Content    json.RawMessage `json:"content"`
+	View       json.RawMessage `json:"view"`
+	CreatedBy  *string         `json:"created_by"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Current    bool            `json:"current"`
+}
+
+// DocumentVersionListItem is a version snapshot without content/view for list responses.
+type DocumentVersionListItem struct {
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	CreatedBy *string   `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DocumentVersionListResp is the paginated response for GET /api/documents/:id/versions.
+type DocumentVersionListResp struct {
+	Data []DocumentVersionListItem `json:"data"`
+	Meta PaginationMeta            `json:"meta"`
+}
+
+// DocumentDiffResp is the response for GET /api/documents/:id/diff?from=N&to=M.
+type DocumentDiffResp struct {
+	DocumentID string `json:"document_id"`
+	From       int    `json:"from"`
+	To         int    `json:"to"`
+	diff.Result
+}
+
+// ImportDSLReq is the JSON body for POST /api/documents/:id/dsl.
+// The endpoint also accepts the DSL as a raw text/plain body.
+type ImportDSLReq struct {
+	Source string `json:"source" validate:"required"`
+}
+
+// ImportASCIIReq is the body for POST /api/documents/import/ascii.
+// DiagramType defaults to flowchart. With Preview set the parsed content is
+// returned without creating a document; otherwise WorkspaceID is required.
+type ImportASCIIReq struct {
+	Source      string  `json:"source"       validate:"required,max=65536"`
+	Preview     bool    `json:"preview"`
+	WorkspaceID string  `json:"workspace_id" validate:"omitempty,uuid"`
+	ProjectID   *string `json:"project_id"   validate:"omitempty,uuid"`
+	Title       string  `json:"title"        validate:"omitempty,max=200"`
+	DiagramType string  `json:"diagram_type"`
+}
+
+// ASCIIPreviewResp is the response for an ASCII import preview. Warnings
+// list connectors that were found but did not join two boxes.
+type ASCIIPreviewResp struct {
+	DiagramType string          `json:"diagram_type"`
+	Content     json.RawMessage `json:"content"`
+	Warnings    []string        `json:"warnings"`
+}
+
 // ExportDocumentReq is the body for POST /api/documents/:id/export.
+// Scale defaults to 2 for PNG and 1 for PDF, Background to #ffffff
+// ("transparent" for none) and Padding to 20 diagram units. PageSize (a4) and
+// Orientation (auto: follows the diagram's aspect ratio) apply to PDF only.
+// Format mermaid writes flowchart and erd documents as Mermaid text.
 type ExportDocumentReq struct {
-	Format     string  `json:"format"     validate:"required,oneof=png svg"`
-	Scale      float64 `json:"scale"      validate:"omitempty,min=0.5,max=4"`
-	Background string  `json:"background" validate:"omitempty"`
-	Padding    int     `json:"padding"    validate:"omitempty,min=0,max=200"`
+	Format      string  `json:"format"      validate:"required,oneof=png svg pdf mermaid"`
+	Scale       float64 `json:"scale"       validate:"omitempty,min=0.5,max=4"`
+	Background  string  `json:"background"  validate:"omitempty"`
+	Padding     *int    `json:"padding"     validate:"omitempty,min=0,max=200"`
+	PageSize    string  `json:"page_size"   validate:"omitempty,oneof=a4 a3 letter legal"`
+	Orientation string  `json:"orientation" validate:"omitempty,oneof=portrait landscape auto"`
+}
+
+// PresenceUserResp is a user connected to a document's collaboration room.
+// Connections counts their open tabs; Since is when the earliest one joined.
+type PresenceUserResp struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	Role        string    `json:"role"`
+	Connections int       `json:"connections"`
+	Since       time.Time `json:"since"`
+}
+
+// DocumentPresenceResp is the response for GET /api/documents/:id/presence.
+type DocumentPresenceResp struct {
+	DocumentID string             `json:"document_id"`
+	Users      []PresenceUserResp `json:"users"`
+}
+
+// DocumentGrantReq is the body for POST /api/documents/:id/grants.
+// Granting to a user who already has a grant changes its role.
+type DocumentGrantReq struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role"  validate:"required,oneof=editor viewer"`
+}
+
+// DocumentGrantResp is a user's grant on a single document.
+type DocumentGrantResp struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	FullName  *string   `json:"full_name"`
+	AvatarURL *string   `json:"avatar_url"`
+	Role      string    `json:"role"`
+	GrantedBy string    `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DocumentShareResp is the public read-only link of a document. Token is
+// used with GET /api/public/documents/:token and .../:token/svg.
+type DocumentShareResp struct {
+	DocumentID string    `json:"document_id"`
+	Token      string    `json:"token"`
+	CreatedAt  time.Time `json:"created_at"`
 }
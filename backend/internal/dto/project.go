@@ -43,3 +43,21 @@ type ProjectListResp struct {
 	Data []ProjectListItem `json:"data"`
 	Meta PaginationMeta    `json:"meta"`
 }
+
+// TrashProjectResp is the response for DELETE /api/projects/:id: the
+// project and its documents were moved to the trash.
+type TrashProjectResp struct {
+	ID               string    `json:"id"`
+	TrashedDocuments int       `json:"trashed_documents"`
+	DeletedAt        time.Time `json:"deleted_at"`
+}
+
+// DeleteProjectResp is the response for DELETE /api/trash/projects/:id: the
+// number of child records permanently removed with the project.
+type DeleteProjectResp struct {
+	ID               string `json:"id"`
+	Documents        int    `json:"deleted_documents"`
+	DocumentVersions int    `json:"deleted_document_versions"`
+	DocumentGrants   int    `json:"deleted_document_grants"`
+	DocumentShares   int    `json:"deleted_document_shares"`
+}
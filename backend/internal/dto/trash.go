@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// TrashItemResp is a project or document in GET /api/workspaces/:id/trash.
+// Documents trashed together with their project are not listed on their own;
+// DocumentCount tells how many come back when the project is restored.
+type TrashItemResp struct {
+	Type          string    `json:"type"` // project | document
+	ID            string    `json:"id"`
+	Title         string    `json:"title"` // project name or document title
+	DiagramType   *string   `json:"diagram_type,omitempty"`
+	ProjectID     *string   `json:"project_id,omitempty"`
+	DocumentCount *int      `json:"document_count,omitempty"`
+	DeletedBy     *string   `json:"deleted_by"`
+	DeletedAt     time.Time `json:"deleted_at"`
+	PurgeAt       time.Time `json:"purge_at"` // when the purge job removes it for good
+}
+
+// RestoreProjectResp is the response for POST /api/trash/projects/:id/restore.
+type RestoreProjectResp struct {
+	Project           *ProjectResp `json:"project"`
+	RestoredDocuments int          `json:"restored_documents"`
+}
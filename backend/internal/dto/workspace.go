@@ -43,3 +43,90 @@ type WorkspaceListResp struct {
 	Data []WorkspaceListItem `json:"data"`
 	Meta PaginationMeta      `json:"meta"`
 }
+
+// WorkspaceMemberResp is a member in GET /api/workspaces/:id/members.
+type WorkspaceMemberResp struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	FullName  *string   `json:"full_name"`
+	AvatarURL *string   `json:"avatar_url"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// InviteMemberReq is the body for POST /api/workspaces/:id/invites.
+type InviteMemberReq struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role"  validate:"required,oneof=editor viewer"`
+}
+
+// WorkspaceInviteResp is a pending invite. Token is what the invitee
+// passes to POST /api/invites/:token/accept.
+type WorkspaceInviteResp struct {
+	ID          string    `json:"id"`
+	WorkspaceID string    `json:"workspace_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	Token       string    `json:"token"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// UpdateMemberRoleReq is the body for PUT /api/workspaces/:id/members/:userId.
+type UpdateMemberRoleReq struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+// CreateInviteLinkReq is the body for POST /api/workspaces/:id/invite-links.
+// ExpiresInHours defaults to 168 (7 days); MaxUses is unlimited when omitted.
+type CreateInviteLinkReq struct {
+	Role           string `json:"role"             validate:"required,oneof=editor viewer"`
+	ExpiresInHours int    `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+	MaxUses        *int   `json:"max_uses"         validate:"omitempty,min=1,max=1000"`
+}
+
+// InviteLinkResp is a shareable invite link. Token is what joining users
+// pass to POST /api/invite-links/:token/join.
+type InviteLinkResp struct {
+	ID          string     `json:"id"`
+	WorkspaceID string     `json:"workspace_id"`
+	Token       string     `json:"token"`
+	Role        string     `json:"role"`
+	MaxUses     *int       `json:"max_uses"`
+	Uses        int        `json:"uses"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+}
+
+// DeleteWorkspaceResp is the response for DELETE /api/workspaces/:id: the
+// number of child records removed with the workspace.
+type DeleteWorkspaceResp struct {
+	ID               string `json:"id"`
+	Members          int    `json:"deleted_members"`
+	Invites          int    `json:"deleted_invites"`
+	InviteLinks      int    `json:"deleted_invite_links"`
+	Projects         int    `json:"deleted_projects"`
+	Documents        int    `json:"deleted_documents"`
+	DocumentVersions int    `json:"deleted_document_versions"`
+	DocumentGrants   int    `json:"deleted_document_grants"`
+	DocumentShares   int    `json:"deleted_document_shares"`
+}
+
+// TransferOwnershipReq is the body for POST /api/workspaces/:id/transfer and
+// POST /api/admin/workspaces/:id/transfer. The previous owner stays in the
+// workspace with PreviousOwnerRole (default editor).
+type TransferOwnershipReq struct {
+	UserID            string `json:"user_id"             validate:"required,uuid"`
+	PreviousOwnerRole string `json:"previous_owner_role" validate:"omitempty,oneof=editor viewer"`
+}
+
+// OwnershipTransferResp is a pending ownership transfer.
+type OwnershipTransferResp struct {
+	WorkspaceID       string    `json:"workspace_id"`
+	FromUserID        string    `json:"from_user_id"`
+	ToUserID          string    `json:"to_user_id"`
+	PreviousOwnerRole string    `json:"previous_owner_role"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
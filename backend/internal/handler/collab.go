@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/dto"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/middleware"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/service"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/ws"
+)
+
+// CollabHandler handles realtime collaboration WebSocket connections.
+type CollabHandler struct {
+	docSvc  *service.DocumentService
+	authSvc *service.AuthService
+	hub     *ws.Hub
+	connect fiber.Handler
+}
+
+// NewCollabHandler creates a new CollabHandler serving rooms from hub.
+func NewCollabHandler(docSvc *service.DocumentService, authSvc *service.AuthService, hub *ws.Hub) *CollabHandler {
+	return &CollabHandler{docSvc: docSvc, authSvc: authSvc, hub: hub, connect: ws.HandleWebSocket(hub)}
+}
+
+// Authorize checks the upgrade request for GET /ws/:documentId — the caller
+// must be a member of the document's workspace or hold a grant on the
+// document. Sets ctx.Locals("role"), which the room re-checks on edits (see
+// ws.recheckRole), and "name" and "avatarUrl" from the user's profile for presence.
+func (h *CollabHandler) Authorize(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	docID, err := uuid.Parse(c.Params("documentId"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	role, appErr := h.docSvc.RequireAccess(c.Context(), userID, docID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	c.Locals("role", role)
+	if profile, appErr := h.authSvc.GetProfile(c.Context(), userID); appErr == nil {
+		if profile.FullName != nil {
+			c.Locals("name", *profile.FullName)
+		}
+		if profile.AvatarURL != nil {
+			c.Locals("avatarUrl", *profile.AvatarURL)
+		}
+	}
+	return c.Next()
+}
+
+// Connect upgrades the request and joins the document's room.
+func (h *CollabHandler) Connect(c *fiber.Ctx) error {
+	return h.connect(c)
+}
+
+// Presence handles GET /api/documents/:id/presence — the users currently
+// connected to the document's room, one entry per user.
+func (h *CollabHandler) Presence(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	if _, appErr := h.docSvc.RequireAccess(c.Context(), userID, docID); appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	entries, err := h.hub.Presence(c.Context(), docID.String())
+	if err != nil {
+		return handleError(c, pkg.ErrInternal.WithMessage("failed to load presence"))
+	}
+
+	users := make([]dto.PresenceUserResp, 0, len(entries))
+	index := make(map[string]int, len(entries))
+	for _, e := range entries {
+		if i, ok := index[e.UserID]; ok {
+			u := &users[i]
+			u.Connections++
+			if e.JoinedAt.Before(u.Since) {
+				u.Since = e.JoinedAt
+			}
+			continue
+		}
+		index[e.UserID] = len(users)
+		users = append(users, dto.PresenceUserResp{
+			ID:          e.UserID,
+			Name:        e.Name,
+			AvatarURL:   e.AvatarURL,
+			Role:        e.Role,
+			Connections: 1,
+			Since:       e.JoinedAt,
+		})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Since.Before(users[j].Since) })
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, dto.DocumentPresenceResp{DocumentID: docID.String(), Users: users})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -14,12 +15,13 @@ import (
 
 // DocumentHandler handles document CRUD and recent endpoints.
 type DocumentHandler struct {
-	docSvc *service.DocumentService
+	docSvc    *service.DocumentService
+	exportSvc *service.ExportService
 }
 
 // NewDocumentHandler creates a new DocumentHandler.
-func NewDocumentHandler(docSvc *service.DocumentService) *DocumentHandler {
-	return &DocumentHandler{docSvc: docSvc}
+func NewDocumentHandler(docSvc *service.DocumentService, exportSvc *service.ExportService) *DocumentHandler {
+	return &DocumentHandler{docSvc: docSvc, exportSvc: exportSvc}
 }
 
 // ListByProject handles GET /api/projects/:id/documents.
@@ -58,6 +60,7 @@ func (h *DocumentHandler) GetByID(c *fiber.Ctx) error {
 		return handleError(c, appErr)
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(resp.Version))
 	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
 }
 
@@ -79,6 +82,7 @@ func (h *DocumentHandler) Create(c *fiber.Ctx) error {
 }
 
 // Update handles PUT /api/documents/:id — update document.
+// An If-Match header carrying the document ETag acts like expected_version in the body.
 func (h *DocumentHandler) Update(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
 
@@ -92,11 +96,23 @@ func (h *DocumentHandler) Update(c *fiber.Ctx) error {
 		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
 	}
 
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" && ifMatch != "*" {
+		version, ok := parseVersionETag(ifMatch)
+		if !ok {
+			return handleError(c, pkg.ErrBadRequest.WithMessage("invalid If-Match header"))
+		}
+		if req.ExpectedVersion != nil && *req.ExpectedVersion != version {
+			return handleError(c, pkg.ErrBadRequest.WithMessage("If-Match does not agree with expected_version"))
+		}
+		req.ExpectedVersion = &version
+	}
+
 	resp, appErr := h.docSvc.Update(c.Context(), userID, docID, req)
 	if appErr != nil {
 		return handleError(c, appErr)
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(resp.Version))
 	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
 }
 
@@ -116,6 +132,170 @@ func (h *DocumentHandler) Delete(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// ListVersions handles GET /api/documents/:id/versions — archived version history.
+func (h *DocumentHandler) ListVersions(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	pq := dto.ParsePagination(c.Query("page"), c.Query("per_page"))
+
+	resp, appErr := h.docSvc.ListVersions(c.Context(), userID, docID, pq)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WritePaginated(c, resp.Data, resp.Meta.Page, resp.Meta.PerPage, resp.Meta.Total)
+}
+
+// GetVersion handles GET /api/documents/:id/versions/:version — content/view at a version.
+func (h *DocumentHandler) GetVersion(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid version"))
+	}
+
+	resp, appErr := h.docSvc.GetVersion(c.Context(), userID, docID, version)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RestoreVersion handles POST /api/documents/:id/versions/:version/restore — restore an old version.
+func (h *DocumentHandler) RestoreVersion(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid version"))
+	}
+
+	resp, appErr := h.docSvc.RestoreVersion(c.Context(), userID, docID, version)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// Diff handles GET /api/documents/:id/diff?from=N&to=M — structural diff between two versions.
+// `to` defaults to the current version.
+func (h *DocumentHandler) Diff(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid or missing from version"))
+	}
+	to := 0
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil || to < 1 {
+			return handleError(c, pkg.ErrBadRequest.WithMessage("invalid to version"))
+		}
+	}
+
+	resp, appErr := h.docSvc.Diff(c.Context(), userID, docID, from, to)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// ExportDSL handles GET /api/documents/:id/dsl — document content as DSL text.
+func (h *DocumentHandler) ExportDSL(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	text, appErr := h.docSvc.ExportDSL(c.Context(), userID, docID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+	return c.Status(fiber.StatusOK).SendString(text)
+}
+
+// ImportDSL handles POST /api/documents/:id/dsl — replace content from DSL text.
+// Accepts either a JSON body { "source": "..." } or the raw DSL as text/plain.
+func (h *DocumentHandler) ImportDSL(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	var req dto.ImportDSLReq
+	if c.Is("json") {
+		if err := c.BodyParser(&req); err != nil {
+			return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+		}
+	} else {
+		req.Source = string(c.Body())
+	}
+
+	resp, appErr := h.docSvc.ImportDSL(c.Context(), userID, docID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// ImportASCII handles POST /api/documents/import/ascii — convert ASCII art
+// into a diagram. Returns the parsed content when preview is set, otherwise
+// creates a document from it.
+func (h *DocumentHandler) ImportASCII(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	var req dto.ImportASCIIReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	if req.Preview {
+		resp, appErr := h.docSvc.PreviewASCII(req)
+		if appErr != nil {
+			return handleError(c, appErr)
+		}
+		return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+	}
+
+	resp, appErr := h.docSvc.ImportASCII(c.Context(), userID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusCreated, resp)
+}
+
 // Recent handles GET /api/documents/recent — recently updated documents dashboard widget.
 func (h *DocumentHandler) Recent(c *fiber.Ctx) error {
 	userID := middleware.GetUserID(c)
@@ -132,3 +312,177 @@ func (h *DocumentHandler) Recent(c *fiber.Ctx) error {
 
 	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
 }
+
+// Export handles POST /api/documents/:id/export — render the document as an image download.
+func (h *DocumentHandler) Export(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	var req dto.ExportDocumentReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	file, appErr := h.exportSvc.Export(c.Context(), userID, docID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	c.Attachment(file.Filename)
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	return c.Status(fiber.StatusOK).Send(file.Data)
+}
+
+// ListGrants handles GET /api/documents/:id/grants — users the document is shared with.
+func (h *DocumentHandler) ListGrants(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	resp, appErr := h.docSvc.ListGrants(c.Context(), userID, docID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// Grant handles POST /api/documents/:id/grants — share the document with a user.
+func (h *DocumentHandler) Grant(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	var req dto.DocumentGrantReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	resp, appErr := h.docSvc.GrantAccess(c.Context(), userID, docID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RevokeGrant handles DELETE /api/documents/:id/grants/:userId.
+func (h *DocumentHandler) RevokeGrant(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	targetID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid user ID"))
+	}
+
+	if appErr := h.docSvc.RevokeAccess(c.Context(), userID, docID, targetID); appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetShare handles GET /api/documents/:id/share — the document's public link.
+func (h *DocumentHandler) GetShare(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	resp, appErr := h.docSvc.GetShare(c.Context(), userID, docID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// CreateShare handles POST /api/documents/:id/share — create or rotate the public link.
+func (h *DocumentHandler) CreateShare(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	resp, appErr := h.docSvc.CreateShare(c.Context(), userID, docID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusCreated, resp)
+}
+
+// DeleteShare handles DELETE /api/documents/:id/share — revoke the public link.
+func (h *DocumentHandler) DeleteShare(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	if appErr := h.docSvc.DeleteShare(c.Context(), userID, docID); appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetShared handles GET /api/public/documents/:token — read-only view of a shared document.
+func (h *DocumentHandler) GetShared(c *fiber.Ctx) error {
+	resp, appErr := h.docSvc.GetShared(c.Context(), c.Params("token"))
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RenderShared handles GET /api/public/documents/:token/svg — shared document as an inline SVG.
+func (h *DocumentHandler) RenderShared(c *fiber.Ctx) error {
+	file, appErr := h.exportSvc.ExportShared(c.Context(), c.Params("token"))
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	return c.Status(fiber.StatusOK).Send(file.Data)
+}
+
+// versionETag derives a document ETag from its version number, e.g. "7".
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseVersionETag extracts the version from an ETag produced by versionETag.
+// Weak validators (W/"7") are accepted as well.
+func parseVersionETag(etag string) (int, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(etag[1 : len(etag)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
@@ -87,9 +87,10 @@ func (h *ProjectHandler) Delete(c *fiber.Ctx) error {
 		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid project ID"))
 	}
 
-	if appErr := h.projSvc.Delete(c.Context(), userID, projID); appErr != nil {
+	resp, appErr := h.projSvc.Delete(c.Context(), userID, projID)
+	if appErr != nil {
 		return handleError(c, appErr)
 	}
 
-	return c.SendStatus(fiber.StatusNoContent)
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
 }
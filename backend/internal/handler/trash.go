@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/middleware"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/service"
+)
+
+// TrashHandler handles trash bin endpoints.
+type TrashHandler struct {
+	trashSvc *service.TrashService
+}
+
+// NewTrashHandler creates a new TrashHandler.
+func NewTrashHandler(trashSvc *service.TrashService) *TrashHandler {
+	return &TrashHandler{trashSvc: trashSvc}
+}
+
+// List handles GET /api/workspaces/:id/trash — trashed projects and documents.
+func (h *TrashHandler) List(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	resp, appErr := h.trashSvc.List(c.Context(), userID, wsID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RestoreDocument handles POST /api/trash/documents/:id/restore.
+func (h *TrashHandler) RestoreDocument(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	resp, appErr := h.trashSvc.RestoreDocument(c.Context(), userID, docID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RestoreProject handles POST /api/trash/projects/:id/restore.
+func (h *TrashHandler) RestoreProject(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	projID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid project ID"))
+	}
+
+	resp, appErr := h.trashSvc.RestoreProject(c.Context(), userID, projID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// PurgeDocument handles DELETE /api/trash/documents/:id — delete permanently.
+func (h *TrashHandler) PurgeDocument(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	docID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid document ID"))
+	}
+
+	if appErr := h.trashSvc.PurgeDocument(c.Context(), userID, docID); appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PurgeProject handles DELETE /api/trash/projects/:id — delete permanently with its documents.
+func (h *TrashHandler) PurgeProject(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	projID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid project ID"))
+	}
+
+	resp, appErr := h.trashSvc.PurgeProject(c.Context(), userID, projID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
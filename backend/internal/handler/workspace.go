@@ -81,9 +81,272 @@ func (h *WorkspaceHandler) Delete(c *fiber.Ctx) error {
 		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
 	}
 
-	if appErr := h.wsSvc.Delete(c.Context(), userID, wsID); appErr != nil {
+	resp, appErr := h.wsSvc.Delete(c.Context(), userID, wsID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// ListMembers handles GET /api/workspaces/:id/members — list workspace members.
+func (h *WorkspaceHandler) ListMembers(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	resp, appErr := h.wsSvc.ListMembers(c.Context(), userID, wsID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// Invite handles POST /api/workspaces/:id/invites — invite a user by email.
+func (h *WorkspaceHandler) Invite(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	var req dto.InviteMemberReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	resp, appErr := h.wsSvc.InviteMember(c.Context(), userID, wsID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusCreated, resp)
+}
+
+// AcceptInvite handles POST /api/invites/:token/accept — join a workspace.
+func (h *WorkspaceHandler) AcceptInvite(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	resp, appErr := h.wsSvc.AcceptInvite(c.Context(), userID, c.Params("token"))
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// UpdateMember handles PUT /api/workspaces/:id/members/:userId — change a member's role.
+func (h *WorkspaceHandler) UpdateMember(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+	memberID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid user ID"))
+	}
+
+	var req dto.UpdateMemberRoleReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	resp, appErr := h.wsSvc.UpdateMemberRole(c.Context(), userID, wsID, memberID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RemoveMember handles DELETE /api/workspaces/:id/members/:userId — remove a member or leave.
+func (h *WorkspaceHandler) RemoveMember(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+	memberID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid user ID"))
+	}
+
+	if appErr := h.wsSvc.RemoveMember(c.Context(), userID, wsID, memberID); appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateInviteLink handles POST /api/workspaces/:id/invite-links — create a shareable invite link.
+func (h *WorkspaceHandler) CreateInviteLink(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	var req dto.CreateInviteLinkReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	resp, appErr := h.wsSvc.CreateInviteLink(c.Context(), userID, wsID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusCreated, resp)
+}
+
+// ListInviteLinks handles GET /api/workspaces/:id/invite-links — list active invite links.
+func (h *WorkspaceHandler) ListInviteLinks(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	resp, appErr := h.wsSvc.ListInviteLinks(c.Context(), userID, wsID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RevokeInviteLink handles DELETE /api/workspaces/:id/invite-links/:linkId — revoke an invite link.
+func (h *WorkspaceHandler) RevokeInviteLink(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+	linkID, err := uuid.Parse(c.Params("linkId"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid invite link ID"))
+	}
+
+	if appErr := h.wsSvc.RevokeInviteLink(c.Context(), userID, wsID, linkID); appErr != nil {
 		return handleError(c, appErr)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// JoinByInviteLink handles POST /api/invite-links/:token/join — join a workspace through a link.
+func (h *WorkspaceHandler) JoinByInviteLink(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	resp, appErr := h.wsSvc.JoinByInviteLink(c.Context(), userID, c.Params("token"))
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// RequestTransfer handles POST /api/workspaces/:id/transfer — propose a new owner.
+func (h *WorkspaceHandler) RequestTransfer(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	var req dto.TransferOwnershipReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	resp, appErr := h.wsSvc.RequestTransfer(c.Context(), userID, wsID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusCreated, resp)
+}
+
+// GetTransfer handles GET /api/workspaces/:id/transfer — the pending ownership transfer.
+func (h *WorkspaceHandler) GetTransfer(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	resp, appErr := h.wsSvc.GetTransfer(c.Context(), userID, wsID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// AcceptTransfer handles POST /api/workspaces/:id/transfer/accept — become the owner.
+func (h *WorkspaceHandler) AcceptTransfer(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	resp, appErr := h.wsSvc.AcceptTransfer(c.Context(), userID, wsID)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
+
+// CancelTransfer handles DELETE /api/workspaces/:id/transfer — withdraw or decline.
+func (h *WorkspaceHandler) CancelTransfer(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	if appErr := h.wsSvc.CancelTransfer(c.Context(), userID, wsID); appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ForceTransfer handles POST /api/admin/workspaces/:id/transfer — admin override.
+func (h *WorkspaceHandler) ForceTransfer(c *fiber.Ctx) error {
+	userID := middleware.GetUserID(c)
+
+	wsID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid workspace ID"))
+	}
+
+	var req dto.TransferOwnershipReq
+	if err := c.BodyParser(&req); err != nil {
+		return handleError(c, pkg.ErrBadRequest.WithMessage("invalid request body"))
+	}
+
+	resp, appErr := h.wsSvc.ForceTransfer(c.Context(), userID, wsID, req)
+	if appErr != nil {
+		return handleError(c, appErr)
+	}
+
+	return pkg.WriteSuccess(c, fiber.StatusOK, resp)
+}
@@ -0,0 +1,121 @@
+package mermaid
+
+import "github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+
+// Layout constants — node sizes match the DSL transformer.
+const (
+	nodeWidth  = 140.0
+	nodeHeight = 60.0
+	origin     = 60.0
+
+	// Spacing between ranks and between nodes of a rank, for top-down and
+	// left-right layouts.
+	rankGapTB    = 100.0
+	siblingGapTB = 180.0
+	rankGapLR    = 220.0
+	siblingGapLR = 100.0
+)
+
+// setSize gives a node the default size, growing entities with their
+// attribute rows like the DSL transformer does.
+func setSize(n *document.Node, attributes int) {
+	width, height := nodeWidth, nodeHeight
+	if attributes > 0 {
+		height = 30 + float64(attributes)*16 + 10
+	}
+	n.Width = &width
+	n.Height = &height
+}
+
+// layout assigns positions by BFS ranks from root nodes (no incoming
+// edges), following a Mermaid direction: TB/TD (default), BT, LR or RL.
+// Nodes within a rank keep declaration order and each rank is centered on
+// the widest one.
+func layout(content *document.DocumentContent, direction string) {
+	if len(content.Nodes) == 0 {
+		return
+	}
+
+	index := make(map[string]int, len(content.Nodes))
+	for i, n := range content.Nodes {
+		index[n.ID] = i
+	}
+	children := make([][]int, len(content.Nodes))
+	indegree := make([]int, len(content.Nodes))
+	for _, e := range content.Edges {
+		s, t := index[e.Source], index[e.Target]
+		if s == t {
+			continue
+		}
+		children[s] = append(children[s], t)
+		indegree[t]++
+	}
+
+	rank := make([]int, len(content.Nodes))
+	visited := make([]bool, len(content.Nodes))
+	var queue []int
+	bfs := func() {
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, child := range children[cur] {
+				if !visited[child] {
+					visited[child] = true
+					rank[child] = rank[cur] + 1
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+	for i := range content.Nodes {
+		if indegree[i] == 0 {
+			visited[i] = true
+			queue = append(queue, i)
+		}
+	}
+	bfs()
+	// Cycles without a root are ranked from their first declared node.
+	for i := range content.Nodes {
+		if !visited[i] {
+			visited[i] = true
+			queue = append(queue, i)
+			bfs()
+		}
+	}
+
+	var ranks [][]int
+	for i := range content.Nodes {
+		for len(ranks) <= rank[i] {
+			ranks = append(ranks, nil)
+		}
+		ranks[rank[i]] = append(ranks[rank[i]], i)
+	}
+	widest := 0
+	for _, r := range ranks {
+		widest = max(widest, len(r))
+	}
+
+	horizontal := direction == "LR" || direction == "RL"
+	reversed := direction == "BT" || direction == "RL"
+	rankGap, siblingGap := rankGapTB, siblingGapTB
+	if horizontal {
+		rankGap, siblingGap = rankGapLR, siblingGapLR
+	}
+	center := float64(widest-1) * siblingGap / 2
+
+	for depth, r := range ranks {
+		if reversed {
+			depth = len(ranks) - 1 - depth
+		}
+		along := origin + float64(depth)*rankGap
+		start := center - float64(len(r)-1)*siblingGap/2
+		for pos, i := range r {
+			across := start + float64(pos)*siblingGap
+			if horizontal {
+				content.Nodes[i].Position = document.Position{X: along, Y: across}
+			} else {
+				content.Nodes[i].Position = document.Position{X: across, Y: along}
+			}
+		}
+	}
+}
@@ -0,0 +1,451 @@
+// Package mermaid converts between DocumentContent and Mermaid diagram text
+// (https://mermaid.js.org), for flowcharts and entity-relationship diagrams.
+//
+// Flowchart (`flowchart`/`graph`) nodes keep their Mermaid IDs; shapes map to
+// node types (`{}` decision, `([])` start-end, `[()]` database, ...) and
+// links become edges with their text as label.
+//
+// An `erDiagram` becomes a Chen-style ERD: every entity is an entity node
+// with its attribute lines in Node.Data, and every relationship is a
+// relationship node between the two entities, whose edges carry the
+// cardinality of each side ("1", "0..1", "0..N" or "1..N").
+//
+// Styling (classDef, style, linkStyle), subgraphs and click handlers are
+// ignored on import; layout is recomputed.
+package mermaid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// Diagram types Mermaid can express.
+const (
+	Flowchart = "flowchart"
+	ERD       = "erd"
+)
+
+// ErrUnsupportedType is returned when serializing a diagram type other than
+// Flowchart or ERD.
+var ErrUnsupportedType = errors.New("mermaid export supports flowchart and erd diagrams only")
+
+// ParseError reports a syntax error with its 1-based line number.
+type ParseError struct {
+	Line int    `json:"line"`
+	Msg  string `json:"message"`
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// line is a source line with its 1-based number.
+type line struct {
+	no   int
+	text string
+}
+
+var headerRe = regexp.MustCompile(`^(flowchart|graph|erDiagram)(?:\s+(TB|TD|BT|LR|RL))?\s*;?$`)
+
+// Parse converts Mermaid text into DocumentContent and returns the diagram
+// type it describes. Markdown code fences and front matter around the
+// diagram are skipped, so a block copied from a README can be used as is.
+func Parse(src string) (string, *document.DocumentContent, error) {
+	lines := sourceLines(src)
+	if len(lines) == 0 {
+		return "", nil, &ParseError{Line: 1, Msg: "empty diagram"}
+	}
+
+	m := headerRe.FindStringSubmatch(lines[0].text)
+	if m == nil {
+		return "", nil, &ParseError{Line: lines[0].no, Msg: fmt.Sprintf("unsupported diagram %q; expected flowchart, graph or erDiagram", lines[0].text)}
+	}
+
+	if m[1] == "erDiagram" {
+		content, err := parseER(lines[1:])
+		return ERD, content, err
+	}
+	content, err := parseFlowchart(lines[1:], m[2])
+	return Flowchart, content, err
+}
+
+// sourceLines returns the non-blank, non-comment lines of src, without code
+// fences and front matter.
+func sourceLines(src string) []line {
+	var lines []line
+	inFrontMatter := false
+	for i, text := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		text = strings.TrimSpace(text)
+		switch {
+		case text == "---" && (inFrontMatter || len(lines) == 0):
+			inFrontMatter = !inFrontMatter
+			continue
+		case inFrontMatter, text == "", strings.HasPrefix(text, "%%"), strings.HasPrefix(text, "```"):
+			continue
+		}
+		lines = append(lines, line{no: i + 1, text: text})
+	}
+	return lines
+}
+
+// ---- flowchart ----
+
+// shape is a Mermaid node shape, e.g. id{label} for a decision.
+type shape struct {
+	open, close string
+	nodeType    string
+}
+
+// shapes lists every shape; parsing picks the longest opening delimiter.
+var shapes = []shape{
+	{"(((", ")))", "ellipse"},
+	{"([", "])", "start-end"},
+	{"[[", "]]", "process"},
+	{"[(", ")]", "database"},
+	{"((", "))", "ellipse"},
+	{"{{", "}}", "preparation"},
+	{"[/", "/]", "input-output"},
+	{"[\\", "\\]", "parallelogram"},
+	{"[/", "\\]", "trapezoid"},
+	{"[\\", "/]", "manual-operation"},
+	{"(", ")", "rounded"},
+	{"[", "]", "process"},
+	{"{", "}", "decision"},
+	{">", "]", "callout"},
+}
+
+var (
+	idRe = regexp.MustCompile(`^[\p{L}\p{N}_]+`)
+	// A -- text --> B, A == text ==> B, A -. text .-> B
+	textLinkRe = regexp.MustCompile(`^\s*(?:--|==|-\.)\s*([^-=.|>\s][^|]*?)\s*(?:-{2,}|={2,}|\.-+)[>ox]?\s*`)
+	// A --> B, A --- B, A -.-> B, A ==> B, A <--> B, each optionally with |text|
+	linkRe    = regexp.MustCompile(`^\s*<?(?:-{2,}|={2,}|-\.+-)[>ox]?\s*(?:\|([^|]*)\|)?\s*`)
+	classRe   = regexp.MustCompile(`^:::[\w-]+`)
+	ignoredRe = regexp.MustCompile(`^(classDef|class|style|linkStyle|click|direction|subgraph)\b`)
+)
+
+type flowBuilder struct {
+	content *document.DocumentContent
+	index   map[string]int
+}
+
+func parseFlowchart(lines []line, direction string) (*document.DocumentContent, error) {
+	b := &flowBuilder{
+		content: &document.DocumentContent{Nodes: []document.Node{}, Edges: []document.Edge{}},
+		index:   make(map[string]int),
+	}
+	for _, l := range lines {
+		if l.text == "end" || ignoredRe.MatchString(l.text) {
+			continue
+		}
+		for _, stmt := range splitStatements(l.text) {
+			if err := b.statement(stmt, l.no); err != nil {
+				return nil, err
+			}
+		}
+	}
+	assignEdgeIDs(b.content)
+	for i := range b.content.Nodes {
+		setSize(&b.content.Nodes[i], 0)
+	}
+	layout(b.content, direction)
+	return b.content, nil
+}
+
+// statement parses `A --> B & C -->|x| D`: groups of nodes joined by links.
+func (b *flowBuilder) statement(stmt string, lineNo int) error {
+	sources, rest, err := b.nodeGroup(stmt, lineNo)
+	if err != nil {
+		return err
+	}
+	for strings.TrimSpace(rest) != "" {
+		label, next, ok := parseLink(rest)
+		if !ok {
+			return &ParseError{Line: lineNo, Msg: fmt.Sprintf("unexpected %q", strings.TrimSpace(rest))}
+		}
+		targets, after, err := b.nodeGroup(next, lineNo)
+		if err != nil {
+			return err
+		}
+		for _, s := range sources {
+			for _, t := range targets {
+				b.content.Edges = append(b.content.Edges, document.Edge{Source: s, Target: t, Type: "step", Label: label})
+			}
+		}
+		sources, rest = targets, after
+	}
+	return nil
+}
+
+// nodeGroup parses one or more nodes joined by &.
+func (b *flowBuilder) nodeGroup(s string, lineNo int) ([]string, string, error) {
+	var ids []string
+	for {
+		id, rest, err := b.node(s, lineNo)
+		if err != nil {
+			return nil, "", err
+		}
+		ids = append(ids, id)
+		trimmed := strings.TrimLeft(rest, " \t")
+		if !strings.HasPrefix(trimmed, "&") {
+			return ids, rest, nil
+		}
+		s = trimmed[1:]
+	}
+}
+
+// node parses `id`, `id[label]`, `id{label}`, ... and records the node.
+// A later definition of the same ID replaces its label and shape.
+func (b *flowBuilder) node(s string, lineNo int) (string, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	id := idRe.FindString(s)
+	if id == "" {
+		return "", "", &ParseError{Line: lineNo, Msg: fmt.Sprintf("expected a node at %q", s)}
+	}
+	rest := s[len(id):]
+
+	label, nodeType, rest, found, err := parseShape(rest)
+	if err != nil {
+		return "", "", &ParseError{Line: lineNo, Msg: fmt.Sprintf("node %s: %v", id, err)}
+	}
+	rest = classRe.ReplaceAllString(rest, "")
+
+	i, seen := b.index[id]
+	if !seen {
+		i = len(b.content.Nodes)
+		b.index[id] = i
+		b.content.Nodes = append(b.content.Nodes, document.Node{ID: id, Type: "process", Label: id})
+	}
+	if found {
+		b.content.Nodes[i].Type = nodeType
+		b.content.Nodes[i].Label = label
+	}
+	return id, rest, nil
+}
+
+// parseShape reads a shape and its label from the start of s, if any.
+func parseShape(s string) (label, nodeType, rest string, found bool, err error) {
+	var best *shape
+	end := -1
+	for i := range shapes {
+		sh := &shapes[i]
+		if !strings.HasPrefix(s, sh.open) || (best != nil && len(sh.open) < len(best.open)) {
+			continue
+		}
+		body := s[len(sh.open):]
+		start := 0
+		if strings.HasPrefix(body, `"`) {
+			q := strings.Index(body[1:], `"`)
+			if q < 0 {
+				return "", "", "", false, errors.New("unterminated quoted label")
+			}
+			start = q + 2
+		}
+		idx := strings.Index(body[start:], sh.close)
+		if idx < 0 {
+			continue
+		}
+		idx += start
+		if best == nil || len(sh.open) > len(best.open) || idx < end {
+			best, end = sh, idx
+		}
+	}
+	if best == nil {
+		if s != "" && strings.ContainsAny(s[:1], "([{>") {
+			return "", "", "", false, errors.New("unterminated shape")
+		}
+		return "", "", s, false, nil
+	}
+	body := s[len(best.open):]
+	return cleanLabel(body[:end]), best.nodeType, body[end+len(best.close):], true, nil
+}
+
+// parseLink reads a link and its optional text from the start of s.
+func parseLink(s string) (label, rest string, ok bool) {
+	if m := textLinkRe.FindStringSubmatch(s); m != nil {
+		return cleanLabel(m[1]), s[len(m[0]):], true
+	}
+	if m := linkRe.FindStringSubmatch(s); m != nil {
+		return cleanLabel(m[1]), s[len(m[0]):], true
+	}
+	return "", "", false
+}
+
+var breakRe = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+// cleanLabel unquotes a label and decodes the entities Mermaid uses for
+// quotes, pipes and line breaks.
+func cleanLabel(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		s = s[1 : len(s)-1]
+	}
+	s = breakRe.ReplaceAllString(s, " ")
+	s = strings.ReplaceAll(s, "#quot;", `"`)
+	s = strings.ReplaceAll(s, "#124;", "|")
+	return strings.TrimSpace(s)
+}
+
+// splitStatements splits a line at semicolons outside labels.
+func splitStatements(text string) []string {
+	var stmts []string
+	depth, quoted, start := 0, false, 0
+	for i, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[' || r == '(' || r == '{':
+			depth++
+		case r == ']' || r == ')' || r == '}':
+			depth--
+		case r == ';' && depth <= 0:
+			stmts = append(stmts, text[start:i])
+			start = i + 1
+		}
+	}
+	stmts = append(stmts, text[start:])
+
+	out := stmts[:0]
+	for _, s := range stmts {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// assignEdgeIDs numbers edges e1, e2, ..., skipping IDs taken by nodes.
+func assignEdgeIDs(content *document.DocumentContent) {
+	taken := make(map[string]bool, len(content.Nodes))
+	for _, n := range content.Nodes {
+		taken[n.ID] = true
+	}
+	next := 1
+	for i := range content.Edges {
+		for taken[fmt.Sprintf("e%d", next)] {
+			next++
+		}
+		content.Edges[i].ID = fmt.Sprintf("e%d", next)
+		next++
+	}
+}
+
+// ---- erDiagram ----
+
+// Cardinality markers of the left and right side of a relationship and the
+// edge labels they become.
+var (
+	leftCardinality  = map[string]string{"|o": "0..1", "||": "1", "}o": "0..N", "}|": "1..N"}
+	rightCardinality = map[string]string{"o|": "0..1", "||": "1", "o{": "0..N", "|{": "1..N"}
+)
+
+// entityName matches a bare or quoted entity name.
+const entityName = `("[^"]+"|[\p{L}\p{N}_-]+)`
+
+var (
+	relationRe = regexp.MustCompile(`^` + entityName + `\s*(\|o|\|\||\}o|\}\|)(?:--|\.\.)(o\||\|\||o\{|\|\{)\s*` + entityName + `\s*:\s*(.+)$`)
+	blockRe    = regexp.MustCompile(`^` + entityName + `\s*\{\s*(\})?$`)
+	entityRe   = regexp.MustCompile(`^` + entityName + `$`)
+)
+
+// nodeData is the shape of Node.Data for entities, shared with the DSL.
+type nodeData struct {
+	Attributes []string `json:"attributes,omitempty"`
+}
+
+type erBuilder struct {
+	content    *document.DocumentContent
+	entities   map[string]int
+	attributes map[int][]string
+}
+
+func parseER(lines []line) (*document.DocumentContent, error) {
+	b := &erBuilder{
+		content:    &document.DocumentContent{Nodes: []document.Node{}, Edges: []document.Edge{}},
+		entities:   make(map[string]int),
+		attributes: make(map[int][]string),
+	}
+
+	var block *int
+	for _, l := range lines {
+		if block != nil {
+			if l.text == "}" {
+				block = nil
+				continue
+			}
+			b.attributes[*block] = append(b.attributes[*block], l.text)
+			continue
+		}
+
+		if m := relationRe.FindStringSubmatch(l.text); m != nil {
+			b.relationship(m[1], leftCardinality[m[2]], rightCardinality[m[3]], m[4], cleanLabel(m[5]))
+			continue
+		}
+		if m := blockRe.FindStringSubmatch(l.text); m != nil {
+			i := b.entity(m[1])
+			if m[2] == "" {
+				block = &i
+			}
+			continue
+		}
+		if m := entityRe.FindStringSubmatch(l.text); m != nil {
+			b.entity(m[1])
+			continue
+		}
+		return nil, &ParseError{Line: l.no, Msg: fmt.Sprintf("unexpected %q", l.text)}
+	}
+	if block != nil {
+		return nil, &ParseError{Line: lines[len(lines)-1].no, Msg: "unterminated entity block"}
+	}
+
+	for i, attrs := range b.attributes {
+		data, err := json.Marshal(nodeData{Attributes: attrs})
+		if err != nil {
+			return nil, err
+		}
+		b.content.Nodes[i].Data = data
+	}
+	for i := range b.content.Nodes {
+		setSize(&b.content.Nodes[i], len(b.attributes[i]))
+	}
+	layout(b.content, "LR")
+	return b.content, nil
+}
+
+// entity returns the node index of an entity, adding it on first use.
+func (b *erBuilder) entity(name string) int {
+	name = strings.Trim(name, `"`)
+	if i, ok := b.entities[name]; ok {
+		return i
+	}
+	i := len(b.content.Nodes)
+	b.entities[name] = i
+	b.content.Nodes = append(b.content.Nodes, document.Node{
+		ID:    fmt.Sprintf("n%d", i+1),
+		Type:  "entity",
+		Label: name,
+	})
+	return i
+}
+
+// relationship adds a relationship node joining two entities.
+func (b *erBuilder) relationship(left, leftCard, rightCard, right, label string) {
+	from := b.content.Nodes[b.entity(left)].ID
+	to := b.content.Nodes[b.entity(right)].ID
+	rel := document.Node{
+		ID:    fmt.Sprintf("n%d", len(b.content.Nodes)+1),
+		Type:  "relationship",
+		Label: label,
+	}
+	b.content.Nodes = append(b.content.Nodes, rel)
+	b.content.Edges = append(b.content.Edges,
+		document.Edge{ID: fmt.Sprintf("e%d", len(b.content.Edges)+1), Source: from, Target: rel.ID, Type: "step", Label: leftCard},
+		document.Edge{ID: fmt.Sprintf("e%d", len(b.content.Edges)+2), Source: rel.ID, Target: to, Type: "step", Label: rightCard},
+	)
+}
@@ -0,0 +1,336 @@
+package mermaid
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// typeToShape maps node types to the Mermaid shape written on export.
+// Types without a Mermaid counterpart are written as rectangles.
+var typeToShape = map[string][2]string{
+	"start-end":        {"([", "])"},
+	"terminator":       {"([", "])"},
+	"rounded":          {"(", ")"},
+	"ellipse":          {"((", "))"},
+	"decision":         {"{", "}"},
+	"diamond":          {"{", "}"},
+	"preparation":      {"{{", "}}"},
+	"hexagon":          {"{{", "}}"},
+	"database":         {"[(", ")]"},
+	"cylinder":         {"[(", ")]"},
+	"input-output":     {"[/", "/]"},
+	"parallelogram":    {"[\\", "\\]"},
+	"trapezoid":        {"[/", "\\]"},
+	"manual-operation": {"[\\", "/]"},
+	"callout":          {">", "]"},
+}
+
+// reservedIDs cannot be used as flowchart node IDs.
+var reservedIDs = map[string]bool{
+	"end": true, "graph": true, "flowchart": true, "subgraph": true, "direction": true,
+	"style": true, "class": true, "classDef": true, "linkStyle": true, "click": true,
+}
+
+// Serialize converts DocumentContent to Mermaid text for a flowchart or erd
+// document. Output is deterministic: nodes and then edges in content order.
+// Positions and styles are not part of Mermaid and are dropped; positions
+// only choose the flowchart direction.
+func Serialize(content *document.DocumentContent, diagramType string) (string, error) {
+	switch diagramType {
+	case Flowchart:
+		return serializeFlowchart(content), nil
+	case ERD:
+		return serializeER(content), nil
+	default:
+		return "", ErrUnsupportedType
+	}
+}
+
+// ---- flowchart ----
+
+func serializeFlowchart(content *document.DocumentContent) string {
+	var b strings.Builder
+	b.WriteString("flowchart " + direction(content) + "\n")
+
+	ids := flowchartIDs(content)
+	for _, n := range content.Nodes {
+		delims, ok := typeToShape[n.Type]
+		if !ok {
+			delims = [2]string{"[", "]"}
+		}
+		b.WriteString("    " + ids[n.ID] + delims[0] + quote(n.Label) + delims[1] + "\n")
+	}
+	for _, e := range content.Edges {
+		source, target := ids[e.Source], ids[e.Target]
+		if source == "" || target == "" {
+			continue
+		}
+		if e.Label == "" {
+			b.WriteString("    " + source + " --> " + target + "\n")
+		} else {
+			b.WriteString("    " + source + " -->|" + quote(e.Label) + "| " + target + "\n")
+		}
+	}
+	return b.String()
+}
+
+// flowchartIDs maps node IDs to Mermaid IDs. IDs that Mermaid cannot parse
+// or reserves are replaced with n1, n2, ...
+func flowchartIDs(content *document.DocumentContent) map[string]string {
+	ids := make(map[string]string, len(content.Nodes))
+	taken := make(map[string]bool, len(content.Nodes))
+	for _, n := range content.Nodes {
+		if idRe.FindString(n.ID) == n.ID && n.ID != "" && !reservedIDs[n.ID] {
+			ids[n.ID] = n.ID
+			taken[n.ID] = true
+		}
+	}
+	next := 1
+	for _, n := range content.Nodes {
+		if _, ok := ids[n.ID]; ok {
+			continue
+		}
+		for taken[fmt.Sprintf("n%d", next)] {
+			next++
+		}
+		ids[n.ID] = fmt.Sprintf("n%d", next)
+		taken[ids[n.ID]] = true
+	}
+	return ids
+}
+
+// direction returns the Mermaid direction the edges mostly run in, so that a
+// left-to-right drawing (such as an imported LR flowchart) exports as LR.
+// Without edges to go by it is TD.
+func direction(content *document.DocumentContent) string {
+	positions := make(map[string]document.Position, len(content.Nodes))
+	for _, n := range content.Nodes {
+		positions[n.ID] = n.Position
+	}
+	var dx, dy float64
+	for _, e := range content.Edges {
+		source, ok := positions[e.Source]
+		target, ok2 := positions[e.Target]
+		if ok && ok2 {
+			dx += target.X - source.X
+			dy += target.Y - source.Y
+		}
+	}
+	switch {
+	case math.Abs(dx) > math.Abs(dy) && dx > 0:
+		return "LR"
+	case math.Abs(dx) > math.Abs(dy):
+		return "RL"
+	case dy < 0:
+		return "BT"
+	}
+	return "TD"
+}
+
+// quote writes a label as a Mermaid string, escaping quotes, pipes (which
+// end an edge label even inside quotes) and line breaks.
+func quote(label string) string {
+	label = strings.ReplaceAll(label, `"`, "#quot;")
+	label = strings.ReplaceAll(label, "|", "#124;")
+	label = strings.ReplaceAll(label, "\r\n", "<br>")
+	label = strings.ReplaceAll(label, "\n", "<br>")
+	return `"` + label + `"`
+}
+
+// ---- erDiagram ----
+
+// cardinalityMarkers maps edge labels back to the left and right Mermaid
+// cardinality markers. Unknown labels are written as exactly one.
+var cardinalityMarkers = map[string][2]string{
+	"0..1": {"|o", "o|"},
+	"1":    {"||", "||"},
+	"0..N": {"}o", "o{"},
+	"1..N": {"}|", "|{"},
+}
+
+var attributeNameRe = regexp.MustCompile(`[^\p{L}\p{N}_\-\[\]()]+`)
+
+func serializeER(content *document.DocumentContent) string {
+	nodes := make(map[string]*document.Node, len(content.Nodes))
+	for i := range content.Nodes {
+		nodes[content.Nodes[i].ID] = &content.Nodes[i]
+	}
+	isEntity := func(id string) bool {
+		n, ok := nodes[id]
+		return ok && (n.Type == "entity" || n.Type == "weak-entity")
+	}
+
+	// Attribute nodes and relationships are collected from the edges.
+	attributes := make(map[string][]string)
+	incident := make(map[string][]document.Edge)
+	var direct []document.Edge
+	for _, e := range content.Edges {
+		source, target := nodes[e.Source], nodes[e.Target]
+		if source == nil || target == nil {
+			continue
+		}
+		switch {
+		case source.Type == "attribute" && isEntity(target.ID):
+			attributes[target.ID] = append(attributes[target.ID], "string "+attributeName(source.Label))
+		case target.Type == "attribute" && isEntity(source.ID):
+			attributes[source.ID] = append(attributes[source.ID], "string "+attributeName(target.Label))
+		case source.Type == "relationship":
+			incident[source.ID] = append(incident[source.ID], e)
+		case target.Type == "relationship":
+			incident[target.ID] = append(incident[target.ID], e)
+		case isEntity(source.ID) && isEntity(target.ID):
+			direct = append(direct, e)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+
+	related := make(map[string]bool)
+	var relations []string
+	for _, n := range content.Nodes {
+		if n.Type != "relationship" {
+			continue
+		}
+		edges := incident[n.ID]
+		if len(edges) != 2 {
+			relations = append(relations, fmt.Sprintf("    %%%% relationship %s does not join two entities; skipped", quote(n.Label)))
+			continue
+		}
+		left, right := edges[0], edges[1]
+		// The side whose edge points at the relationship is written first.
+		if right.Target == n.ID && left.Target != n.ID {
+			left, right = right, left
+		}
+		leftEntity, rightEntity := otherEnd(left, n.ID), otherEnd(right, n.ID)
+		if !isEntity(leftEntity) || !isEntity(rightEntity) {
+			relations = append(relations, fmt.Sprintf("    %%%% relationship %s does not join two entities; skipped", quote(n.Label)))
+			continue
+		}
+		related[leftEntity], related[rightEntity] = true, true
+		relations = append(relations, relation(nodes[leftEntity], left.Label, nodes[rightEntity], right.Label, n.Label))
+	}
+	for _, e := range direct {
+		related[e.Source], related[e.Target] = true, true
+		relations = append(relations, relation(nodes[e.Source], "", nodes[e.Target], "", e.Label))
+	}
+
+	for _, n := range content.Nodes {
+		if !isEntity(n.ID) {
+			continue
+		}
+		attrs := append(entityAttributes(n.Data), attributes[n.ID]...)
+		if len(attrs) == 0 && related[n.ID] {
+			continue
+		}
+		b.WriteString("    " + entityRef(n.Label) + " {\n")
+		for _, a := range attrs {
+			b.WriteString("        " + a + "\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, r := range relations {
+		b.WriteString(r + "\n")
+	}
+	return b.String()
+}
+
+func otherEnd(e document.Edge, id string) string {
+	if e.Source == id {
+		return e.Target
+	}
+	return e.Source
+}
+
+// relation writes `A ||--o{ B : "label"`.
+func relation(left *document.Node, leftCard string, right *document.Node, rightCard, label string) string {
+	l, ok := cardinalityMarkers[leftCard]
+	if !ok {
+		l = cardinalityMarkers["1"]
+	}
+	r, ok := cardinalityMarkers[rightCard]
+	if !ok {
+		r = cardinalityMarkers["1"]
+	}
+	// Mermaid relationship labels cannot escape quotes.
+	label = strings.ReplaceAll(strings.ReplaceAll(label, `"`, "'"), "\n", " ")
+	return fmt.Sprintf(`    %s %s--%s %s : "%s"`, entityRef(left.Label), l[0], r[1], entityRef(right.Label), label)
+}
+
+// entityRef writes an entity name, quoting names Mermaid cannot parse bare.
+func entityRef(name string) string {
+	if entityRe.MatchString(name) && !strings.HasPrefix(name, `"`) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, "'") + `"`
+}
+
+// entityAttributes returns the attribute lines stored on an entity, rewritten
+// as `type name [PK|FK|UK] ["comment"]`. A bare name gets the type string.
+func entityAttributes(data json.RawMessage) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	var d nodeData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil
+	}
+	var attrs []string
+	for _, a := range d.Attributes {
+		if line := attributeLine(a); line != "" {
+			attrs = append(attrs, line)
+		}
+	}
+	return attrs
+}
+
+func attributeLine(a string) string {
+	comment := ""
+	if i := strings.Index(a, `"`); i >= 0 {
+		comment = strings.Trim(a[i:], `" `)
+		a = a[:i]
+	}
+	fields := strings.Fields(a)
+	switch len(fields) {
+	case 0:
+		return ""
+	case 1:
+		fields = []string{"string", fields[0]}
+	}
+
+	parts := []string{attributeName(fields[0]), attributeName(fields[1])}
+	var keys, rest []string
+	for _, f := range fields[2:] {
+		switch strings.ToUpper(strings.TrimSuffix(f, ",")) {
+		case "PK", "FK", "UK":
+			keys = append(keys, strings.ToUpper(strings.TrimSuffix(f, ",")))
+		default:
+			rest = append(rest, f)
+		}
+	}
+	if len(keys) > 0 {
+		parts = append(parts, strings.Join(keys, ", "))
+	}
+	if comment == "" {
+		comment = strings.Join(rest, " ")
+	}
+	if comment != "" {
+		parts = append(parts, `"`+comment+`"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// attributeName replaces characters Mermaid does not allow in attribute
+// types and names with underscores.
+func attributeName(s string) string {
+	s = attributeNameRe.ReplaceAllString(strings.TrimSpace(s), "_")
+	if s == "" {
+		return "_"
+	}
+	return s
+}
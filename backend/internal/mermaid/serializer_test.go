@@ -0,0 +1,81 @@
+package mermaid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlowchartRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		header    string
+		edgeLabel string
+	}{
+		{
+			name:   "top down",
+			src:    "flowchart TD\n    a[Start] --> b{Ok?}\n",
+			header: "flowchart TD",
+		},
+		{
+			name:   "left to right",
+			src:    "flowchart LR\n    a[Start] --> b[Next] --> c[End]\n",
+			header: "flowchart LR",
+		},
+		{
+			name:   "bottom to top",
+			src:    "graph BT\n    a --> b\n",
+			header: "flowchart BT",
+		},
+		{
+			name:      "pipe in edge label",
+			src:       "flowchart TD\n    a -->|\"yes #124; no\"| b\n",
+			header:    "flowchart TD",
+			edgeLabel: "yes | no",
+		},
+		{
+			name:      "quote in edge label",
+			src:       "flowchart TD\n    a -->|\"say #quot;hi#quot;\"| b\n",
+			header:    "flowchart TD",
+			edgeLabel: `say "hi"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, content, err := Parse(tt.src)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			text, err := Serialize(content, Flowchart)
+			if err != nil {
+				t.Fatalf("Serialize: %v", err)
+			}
+			if header, _, _ := strings.Cut(text, "\n"); header != tt.header {
+				t.Errorf("header = %q, want %q", header, tt.header)
+			}
+
+			_, again, err := Parse(text)
+			if err != nil {
+				t.Fatalf("Parse(Serialize): %v\n%s", err, text)
+			}
+			if len(again.Nodes) != len(content.Nodes) || len(again.Edges) != len(content.Edges) {
+				t.Fatalf("round trip has %d nodes and %d edges, want %d and %d\n%s",
+					len(again.Nodes), len(again.Edges), len(content.Nodes), len(content.Edges), text)
+			}
+			for i, n := range content.Nodes {
+				if got := again.Nodes[i]; got.ID != n.ID || got.Type != n.Type || got.Label != n.Label {
+					t.Errorf("node %d = %s %s %q, want %s %s %q", i, got.ID, got.Type, got.Label, n.ID, n.Type, n.Label)
+				}
+			}
+			for i, e := range content.Edges {
+				if got := again.Edges[i]; got.Source != e.Source || got.Target != e.Target || got.Label != e.Label {
+					t.Errorf("edge %d = %s->%s %q, want %s->%s %q", i, got.Source, got.Target, got.Label, e.Source, e.Target, e.Label)
+				}
+			}
+			if tt.edgeLabel != "" && again.Edges[0].Label != tt.edgeLabel {
+				t.Errorf("edge label = %q, want %q", again.Edges[0].Label, tt.edgeLabel)
+			}
+		})
+	}
+}
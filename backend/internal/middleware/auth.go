@@ -27,48 +27,109 @@ func Auth(jwtSecret string) fiber.Handler {
 		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
 			return pkg.WriteError(c, pkg.ErrUnauthorized.WithMessage("invalid Authorization header format"))
 		}
-		tokenStr := parts[1]
 
-		// Parse and validate the JWT — HS256 only
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
-		if err != nil || !token.Valid {
-			log.Printf("[Auth] JWT validation failed: %v", err)
-			return pkg.WriteError(c, pkg.ErrUnauthorized.WithMessage("invalid or expired token"))
+		userID, email, appErr := parseToken(jwtSecret, parts[1])
+		if appErr != nil {
+			return pkg.WriteError(c, appErr)
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return pkg.WriteError(c, pkg.ErrUnauthorized.WithMessage("invalid token claims"))
+		// Set user context for downstream handlers
+		c.Locals("userId", userID)
+
+		// Optionally set email if present
+		if email != "" {
+			c.Locals("email", email)
 		}
 
-		// Extract user ID from the `sub` claim
-		sub, ok := claims["sub"].(string)
-		if !ok || sub == "" {
-			return pkg.WriteError(c, pkg.ErrUnauthorized.WithMessage("missing sub claim in token"))
+		return c.Next()
+	}
+}
+
+// WSAuth authenticates WebSocket upgrade requests with the same JWT as Auth.
+// Browsers cannot set headers on a WebSocket handshake, so the token is read
+// from the `token` query parameter or from the subprotocol list
+// (`new WebSocket(url, ["bearer", token])`); an Authorization header is also
+// accepted. Sets the same locals as Auth.
+func WSAuth(jwtSecret string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenStr := c.Query("token")
+		if tokenStr == "" {
+			tokenStr = subprotocolToken(c.Get(fiber.HeaderSecWebSocketProtocol))
+		}
+		if tokenStr == "" {
+			parts := strings.SplitN(c.Get("Authorization"), " ", 2)
+			if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+				tokenStr = parts[1]
+			}
+		}
+		if tokenStr == "" {
+			return pkg.WriteError(c, pkg.ErrUnauthorized.WithMessage("missing token"))
 		}
 
-		userID, err := uuid.Parse(sub)
-		if err != nil {
-			return pkg.WriteError(c, pkg.ErrUnauthorized.WithMessage("invalid user ID in token"))
+		userID, email, appErr := parseToken(jwtSecret, tokenStr)
+		if appErr != nil {
+			return pkg.WriteError(c, appErr)
 		}
 
-		// Set user context for downstream handlers
 		c.Locals("userId", userID)
-
-		// Optionally extract email if present
-		if email, ok := claims["email"].(string); ok {
+		if email != "" {
 			c.Locals("email", email)
 		}
-
 		return c.Next()
 	}
 }
 
+// BearerSubprotocol is the WebSocket subprotocol that marks the next entry of
+// Sec-WebSocket-Protocol as an access token. The server echoes it back.
+const BearerSubprotocol = "bearer"
+
+// subprotocolToken returns the entry following "bearer" in a
+// Sec-WebSocket-Protocol header, or "".
+func subprotocolToken(header string) string {
+	protocols := strings.Split(header, ",")
+	for i := 0; i < len(protocols)-1; i++ {
+		if strings.TrimSpace(protocols[i]) == BearerSubprotocol {
+			return strings.TrimSpace(protocols[i+1])
+		}
+	}
+	return ""
+}
+
+// parseToken validates an HS256 JWT and returns the user ID from the `sub`
+// claim and the optional email claim.
+func parseToken(jwtSecret, tokenStr string) (uuid.UUID, string, *pkg.AppError) {
+	// Parse and validate the JWT — HS256 only
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		log.Printf("[Auth] JWT validation failed: %v", err)
+		return uuid.Nil, "", pkg.ErrUnauthorized.WithMessage("invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return uuid.Nil, "", pkg.ErrUnauthorized.WithMessage("invalid token claims")
+	}
+
+	// Extract user ID from the `sub` claim
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return uuid.Nil, "", pkg.ErrUnauthorized.WithMessage("missing sub claim in token")
+	}
+
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, "", pkg.ErrUnauthorized.WithMessage("invalid user ID in token")
+	}
+
+	email, _ := claims["email"].(string)
+	return userID, email, nil
+}
+
 // GetUserID extracts the authenticated user's UUID from ctx.Locals.
 // Returns uuid.Nil if not set (should not happen behind Auth middleware).
 func GetUserID(c *fiber.Ctx) uuid.UUID {
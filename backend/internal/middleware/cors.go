@@ -10,7 +10,8 @@ func CORS(frontendURL string) fiber.Handler {
 	return cors.New(cors.Config{
 		AllowOrigins:     frontendURL,
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Authorization,Content-Type,X-Request-ID",
+		AllowHeaders:     "Authorization,Content-Type,X-Request-ID,If-Match",
+		ExposeHeaders:    "ETag,X-RateLimit-Limit,X-RateLimit-Remaining,X-RateLimit-Reset,Retry-After",
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 hours preflight cache
 	})
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
+)
+
+// fakeLimiter allows limit requests per key and records the keys it saw.
+type fakeLimiter struct {
+	counts map[string]int
+	keys   []string
+	err    error
+}
+
+func (f *fakeLimiter) Allow(_ context.Context, key string, limit int, window time.Duration) (*redis.RateLimitResult, error) {
+	f.keys = append(f.keys, key)
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.counts[key]++
+	res := &redis.RateLimitResult{
+		Allowed:   f.counts[key] <= limit,
+		Limit:     limit,
+		Remaining: max(limit-f.counts[key], 0),
+		Reset:     time.Unix(1700000000, 0),
+	}
+	if !res.Allowed {
+		res.RetryAfter = 1500 * time.Millisecond
+	}
+	return res, nil
+}
+
+func newRateLimitApp(limiter Limiter, userID uuid.UUID, scope string, limit int, methods ...string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if userID != uuid.Nil {
+			c.Locals("userId", userID)
+		}
+		return c.Next()
+	})
+	app.Use(RateLimit(limiter, scope, limit, methods...))
+	app.All("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	return app
+}
+
+func TestRateLimit(t *testing.T) {
+	user := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		scope    string
+		limit    int
+		methods  []string
+		requests []string // HTTP methods, in order
+		statuses []int
+		keys     []string
+	}{
+		{
+			name:     "over the limit",
+			userID:   user,
+			scope:    "global",
+			limit:    2,
+			requests: []string{"GET", "GET", "GET"},
+			statuses: []int{204, 204, 429},
+			keys:     []string{"global:user:" + user.String(), "global:user:" + user.String(), "global:user:" + user.String()},
+		},
+		{
+			name:     "anonymous clients are keyed by IP",
+			scope:    "auth",
+			limit:    1,
+			requests: []string{"GET", "GET"},
+			statuses: []int{204, 429},
+			keys:     []string{"auth:ip:0.0.0.0", "auth:ip:0.0.0.0"},
+		},
+		{
+			name:     "write scope only counts write methods",
+			userID:   user,
+			scope:    "write",
+			limit:    1,
+			methods:  WriteMethods,
+			requests: []string{"GET", "POST", "GET", "DELETE", "PUT"},
+			statuses: []int{204, 204, 204, 429, 429},
+			keys:     []string{"write:user:" + user.String(), "write:user:" + user.String(), "write:user:" + user.String()},
+		},
+		{
+			name:     "non-positive limit disables the check",
+			userID:   user,
+			scope:    "export",
+			limit:    0,
+			requests: []string{"GET", "GET"},
+			statuses: []int{204, 204},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{counts: map[string]int{}}
+			app := newRateLimitApp(limiter, tt.userID, tt.scope, tt.limit, tt.methods...)
+			for i, method := range tt.requests {
+				resp, err := app.Test(httptest.NewRequest(method, "/", nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != tt.statuses[i] {
+					t.Errorf("%s request %d: status = %d, want %d", method, i, resp.StatusCode, tt.statuses[i])
+				}
+				if resp.StatusCode == fiber.StatusTooManyRequests {
+					if got := resp.Header.Get(fiber.HeaderRetryAfter); got != "2" {
+						t.Errorf("Retry-After = %q, want 2", got)
+					}
+					if got := resp.Header.Get("X-RateLimit-Remaining"); got != "0" {
+						t.Errorf("X-RateLimit-Remaining = %q, want 0", got)
+					}
+				}
+			}
+			if len(limiter.keys) != len(tt.keys) {
+				t.Fatalf("keys = %v, want %v", limiter.keys, tt.keys)
+			}
+			for i := range tt.keys {
+				if limiter.keys[i] != tt.keys[i] {
+					t.Errorf("key %d = %q, want %q", i, limiter.keys[i], tt.keys[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	limiter := &fakeLimiter{counts: map[string]int{}, err: errors.New("connection refused")}
+	app := newRateLimitApp(limiter, uuid.Nil, "global", 1)
+	for i := 0; i < 3; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusNoContent {
+			t.Errorf("request %d: status = %d, want 204", i, resp.StatusCode)
+		}
+	}
+}
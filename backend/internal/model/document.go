@@ -19,6 +19,33 @@ type Document struct {
 	View        json.RawMessage `bson:"view"         json:"view"`
 	Version     int             `bson:"version"      json:"version"`
 	CreatedBy   *uuid.UUID      `bson:"created_by"   json:"created_by"`
+	UpdatedBy   *uuid.UUID      `bson:"updated_by"   json:"updated_by"` // author of the current version
 	CreatedAt   time.Time       `bson:"created_at"   json:"created_at"`
 	UpdatedAt   time.Time       `bson:"updated_at"   json:"updated_at"`
+
+	// Trash tombstone. Trashed documents are hidden everywhere but the trash
+	// bin; TrashedWith is set when the document went to the trash with its project.
+	DeletedAt   *time.Time `bson:"deleted_at,omitempty"   json:"deleted_at,omitempty"`
+	DeletedBy   *uuid.UUID `bson:"deleted_by,omitempty"   json:"deleted_by,omitempty"`
+	TrashedWith *uuid.UUID `bson:"trashed_with,omitempty" json:"trashed_with,omitempty"`
+}
+
+// DocumentGrant mirrors the document_grants collection: access to a single
+// document for a user, independent of workspace membership.
+// Composite key: (document_id, user_id).
+type DocumentGrant struct {
+	DocumentID uuid.UUID `bson:"document_id" json:"document_id"`
+	UserID     uuid.UUID `bson:"user_id"     json:"user_id"`
+	Role       string    `bson:"role"        json:"role"` // editor | viewer
+	GrantedBy  uuid.UUID `bson:"granted_by"  json:"granted_by"`
+	CreatedAt  time.Time `bson:"created_at"  json:"created_at"`
+}
+
+// DocumentShare mirrors the document_shares collection: the public read-only
+// link of a document, at most one per document.
+type DocumentShare struct {
+	DocumentID uuid.UUID `bson:"_id"        json:"document_id"`
+	Token      string    `bson:"token"      json:"-"`
+	CreatedBy  uuid.UUID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
 }
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DocumentVersion mirrors the document_versions collection.
+// Each row is an immutable snapshot of a document's content/view at a given version,
+// written when an update supersedes that version.
+type DocumentVersion struct {
+	ID         uuid.UUID       `bson:"_id"         json:"id"`
+	DocumentID uuid.UUID       `bson:"document_id" json:"document_id"`
+	Version    int             `bson:"version"     json:"version"`
+	Title      string          `bson:"title"       json:"title"`
+	Content    json.RawMessage `bson:"content"     json:"content"`
+	View       json.RawMessage `bson:"view"        json:"view"`
+	CreatedBy  *uuid.UUID      `bson:"created_by"  json:"created_by"` // author of this version
+	CreatedAt  time.Time       `bson:"created_at"  json:"created_at"` // when this version was saved
+	ArchivedAt time.Time       `bson:"archived_at" json:"archived_at"`
+}
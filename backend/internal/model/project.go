@@ -15,4 +15,8 @@ type Project struct {
 	CreatedBy   *uuid.UUID `bson:"created_by"   json:"created_by"`
 	CreatedAt   time.Time  `bson:"created_at"   json:"created_at"`
 	UpdatedAt   time.Time  `bson:"updated_at"   json:"updated_at"`
+
+	// Trash tombstone. Trashing a project trashes its documents with it.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy *uuid.UUID `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
 }
@@ -25,3 +25,46 @@ type WorkspaceMember struct {
 	Role        string    `bson:"role"          json:"role"` // owner | editor | viewer
 	JoinedAt    time.Time `bson:"joined_at"     json:"joined_at"`
 }
+
+// WorkspaceInvite mirrors the workspace_invites collection.
+// An invite is pending until the invited email accepts it with Token.
+type WorkspaceInvite struct {
+	ID          uuid.UUID  `bson:"_id"          json:"id"`
+	WorkspaceID uuid.UUID  `bson:"workspace_id" json:"workspace_id"`
+	Email       string     `bson:"email"        json:"email"` // lower-cased
+	Role        string     `bson:"role"         json:"role"`  // editor | viewer
+	Token       string     `bson:"token"        json:"-"`
+	InvitedBy   uuid.UUID  `bson:"invited_by"   json:"invited_by"`
+	CreatedAt   time.Time  `bson:"created_at"   json:"created_at"`
+	ExpiresAt   time.Time  `bson:"expires_at"   json:"expires_at"`
+	AcceptedAt  *time.Time `bson:"accepted_at"  json:"accepted_at"`
+	AcceptedBy  *uuid.UUID `bson:"accepted_by"  json:"accepted_by"`
+}
+
+// WorkspaceInviteLink mirrors the workspace_invite_links collection.
+// Anyone holding Token can join the workspace with Role until the link is
+// revoked, expires or has been used MaxUses times (nil: unlimited).
+type WorkspaceInviteLink struct {
+	ID          uuid.UUID  `bson:"_id"          json:"id"`
+	WorkspaceID uuid.UUID  `bson:"workspace_id" json:"workspace_id"`
+	Token       string     `bson:"token"        json:"-"`
+	Role        string     `bson:"role"         json:"role"` // editor | viewer
+	MaxUses     *int       `bson:"max_uses"     json:"max_uses"`
+	Uses        int        `bson:"uses"         json:"uses"`
+	CreatedBy   uuid.UUID  `bson:"created_by"   json:"created_by"`
+	CreatedAt   time.Time  `bson:"created_at"   json:"created_at"`
+	ExpiresAt   time.Time  `bson:"expires_at"   json:"expires_at"`
+	RevokedAt   *time.Time `bson:"revoked_at"   json:"revoked_at"`
+}
+
+// WorkspaceTransfer mirrors the workspace_transfers collection: an ownership
+// transfer proposed by the owner and waiting for the new owner to accept it.
+// At most one per workspace.
+type WorkspaceTransfer struct {
+	WorkspaceID       uuid.UUID `bson:"_id"                 json:"workspace_id"`
+	FromUserID        uuid.UUID `bson:"from_user_id"        json:"from_user_id"`
+	ToUserID          uuid.UUID `bson:"to_user_id"          json:"to_user_id"`
+	PreviousOwnerRole string    `bson:"previous_owner_role" json:"previous_owner_role"` // editor | viewer
+	CreatedAt         time.Time `bson:"created_at"          json:"created_at"`
+	ExpiresAt         time.Time `bson:"expires_at"          json:"expires_at"`
+}
@@ -46,6 +46,10 @@ func formatFieldError(fe validator.FieldError) string {
 		return field + " must be a valid UUID"
 	case "oneof":
 		return field + " must be one of: " + fe.Param()
+	case "required_without":
+		return field + " is required when " + fe.Param() + " is not set"
+	case "excluded_with":
+		return field + " cannot be combined with " + fe.Param()
 	default:
 		return field + " is invalid (" + fe.Tag() + ")"
 	}
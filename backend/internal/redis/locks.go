@@ -8,6 +8,25 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// LockTTL is how long a node lock lives without being renewed.
+const LockTTL = 60 * time.Second
+
+// unlockScript deletes a lock only if it is still held by the given owner.
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// renewScript extends a lock only if it is still held by the given owner.
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
 type LockService struct {
 	client *redis.Client
 }
@@ -16,30 +35,36 @@ func NewLockService(client *redis.Client) *LockService {
 	return &LockService{client: client}
 }
 
-// LockNode acquires a lock on a node for a specific user. Returns true if lock acquired.
+// LockNode acquires a lock on a node for a specific user. Returns true if lock acquired,
+// or if the user already held it (the TTL is then refreshed).
 func (s *LockService) LockNode(ctx context.Context, roomID, nodeID, userID string) (bool, error) {
 	key := fmt.Sprintf("room:%s:lock:%s", roomID, nodeID)
-	ok, err := s.client.SetNX(ctx, key, userID, 60*time.Second).Result()
+	ok, err := s.client.SetNX(ctx, key, userID, LockTTL).Result()
 	if err != nil {
 		return false, err
 	}
-	return ok, nil
+	if !ok {
+		return s.RenewLock(ctx, roomID, nodeID, userID)
+	}
+	return true, nil
 }
 
-// UnlockNode releases a lock only if held by the specified user.
-func (s *LockService) UnlockNode(ctx context.Context, roomID, nodeID, userID string) error {
+// RenewLock resets the TTL of a lock held by the specified user.
+// Returns false if the lock expired or belongs to someone else.
+func (s *LockService) RenewLock(ctx context.Context, roomID, nodeID, userID string) (bool, error) {
 	key := fmt.Sprintf("room:%s:lock:%s", roomID, nodeID)
-	current, err := s.client.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return nil
-	}
+	n, err := renewScript.Run(ctx, s.client, []string{key}, userID, LockTTL.Milliseconds()).Int()
 	if err != nil {
-		return err
-	}
-	if current == userID {
-		return s.client.Del(ctx, key).Err()
+		return false, err
 	}
-	return nil
+	return n == 1, nil
+}
+
+// UnlockNode releases a lock only if held by the specified user.
+// The check and delete run atomically in a Lua script.
+func (s *LockService) UnlockNode(ctx context.Context, roomID, nodeID, userID string) error {
+	key := fmt.Sprintf("room:%s:lock:%s", roomID, nodeID)
+	return unlockScript.Run(ctx, s.client, []string{key}, userID).Err()
 }
 
 // IsNodeLocked checks if a node is locked and by whom.
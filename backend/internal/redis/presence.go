@@ -2,12 +2,27 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// HeartbeatTTL is how long a connection counts as present without a heartbeat.
+const HeartbeatTTL = 30 * time.Second
+
+// PresenceEntry is one connection in a room. A user with several tabs open
+// has one entry per tab.
+type PresenceEntry struct {
+	ClientID  string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
 type PresenceService struct {
 	client *redis.Client
 }
@@ -16,22 +31,110 @@ func NewPresenceService(client *redis.Client) *PresenceService {
 	return &PresenceService{client: client}
 }
 
-func (s *PresenceService) SetPresence(ctx context.Context, roomID, userID, userName string) error {
-	key := fmt.Sprintf("room:%s:users", roomID)
-	return s.client.HSet(ctx, key, userID, userName).Err()
+func usersKey(roomID string) string {
+	return fmt.Sprintf("room:%s:users", roomID)
+}
+
+func heartbeatKey(roomID, clientID string) string {
+	return fmt.Sprintf("room:%s:heartbeat:%s", roomID, clientID)
+}
+
+// SetPresence records a connection in the room and starts its heartbeat.
+func (s *PresenceService) SetPresence(ctx context.Context, roomID string, entry PresenceEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, usersKey(roomID), entry.ClientID, data)
+	pipe.Set(ctx, heartbeatKey(roomID, entry.ClientID), "1", HeartbeatTTL)
+	pipe.Expire(ctx, usersKey(roomID), 2*HeartbeatTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *PresenceService) RemovePresence(ctx context.Context, roomID, clientID string) error {
+	pipe := s.client.TxPipeline()
+	pipe.HDel(ctx, usersKey(roomID), clientID)
+	pipe.Del(ctx, heartbeatKey(roomID, clientID))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// SetHeartbeat keeps a connection present for another HeartbeatTTL.
+// The room's user hash lives as long as any of its connections heartbeats,
+// so rooms abandoned by crashed instances disappear on their own.
+func (s *PresenceService) SetHeartbeat(ctx context.Context, roomID, clientID string) error {
+	pipe := s.client.Pipeline()
+	pipe.Set(ctx, heartbeatKey(roomID, clientID), "1", HeartbeatTTL)
+	pipe.Expire(ctx, usersKey(roomID), 2*HeartbeatTTL)
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-func (s *PresenceService) RemovePresence(ctx context.Context, roomID, userID string) error {
-	key := fmt.Sprintf("room:%s:users", roomID)
-	return s.client.HDel(ctx, key, userID).Err()
+// GetRoomUsers returns the connections in a room whose heartbeat is alive.
+func (s *PresenceService) GetRoomUsers(ctx context.Context, roomID string) ([]PresenceEntry, error) {
+	live, _, err := s.partition(ctx, roomID)
+	return live, err
 }
 
-func (s *PresenceService) GetRoomUsers(ctx context.Context, roomID string) (map[string]string, error) {
-	key := fmt.Sprintf("room:%s:users", roomID)
-	return s.client.HGetAll(ctx, key).Result()
+// SweepRoom removes connections whose heartbeat expired and returns the
+// entries removed by this call (another instance may sweep concurrently).
+func (s *PresenceService) SweepRoom(ctx context.Context, roomID string) ([]PresenceEntry, error) {
+	_, expired, err := s.partition(ctx, roomID)
+	if err != nil || len(expired) == 0 {
+		return nil, err
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(expired))
+	for i, e := range expired {
+		cmds[i] = pipe.HDel(ctx, usersKey(roomID), e.ClientID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	var removed []PresenceEntry
+	for i, cmd := range cmds {
+		if cmd.Val() == 1 {
+			removed = append(removed, expired[i])
+		}
+	}
+	return removed, nil
 }
 
-func (s *PresenceService) SetHeartbeat(ctx context.Context, roomID, userID string) error {
-	key := fmt.Sprintf("room:%s:heartbeat:%s", roomID, userID)
-	return s.client.Set(ctx, key, "1", 30*time.Second).Err()
+// partition splits a room's entries by whether their heartbeat key still exists.
+func (s *PresenceService) partition(ctx context.Context, roomID string) (live, expired []PresenceEntry, err error) {
+	all, err := s.client.HGetAll(ctx, usersKey(roomID)).Result()
+	if err != nil || len(all) == 0 {
+		return nil, nil, err
+	}
+
+	entries := make([]PresenceEntry, 0, len(all))
+	for clientID, data := range all {
+		var e PresenceEntry
+		if json.Unmarshal([]byte(data), &e) != nil {
+			e = PresenceEntry{ClientID: clientID} // unreadable entries are swept
+		}
+		entries = append(entries, e)
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(entries))
+	for i, e := range entries {
+		cmds[i] = pipe.Exists(ctx, heartbeatKey(roomID, e.ClientID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	for i, e := range entries {
+		if cmds[i].Val() == 1 {
+			live = append(live, e)
+		} else {
+			expired = append(expired, e)
+		}
+	}
+	return live, expired, nil
 }
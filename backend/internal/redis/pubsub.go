@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RoomMessage is a payload published to a collaboration room by any instance.
+type RoomMessage struct {
+	RoomID  string
+	Payload []byte
+}
+
+// RoomBroker fans out collaboration room messages between API instances.
+// A single subscription connection is shared by all rooms hosted locally;
+// rooms are added and removed as they open and close.
+type RoomBroker struct {
+	client   *redis.Client
+	pubsub   *redis.PubSub
+	messages chan RoomMessage
+}
+
+func NewRoomBroker(client *redis.Client) *RoomBroker {
+	b := &RoomBroker{
+		client:   client,
+		pubsub:   client.Subscribe(context.Background()),
+		messages: make(chan RoomMessage, 256),
+	}
+	go b.receive()
+	return b
+}
+
+func roomChannel(roomID string) string {
+	return fmt.Sprintf("room:%s:events", roomID)
+}
+
+// Publish sends payload to every instance subscribed to the room.
+func (b *RoomBroker) Publish(ctx context.Context, roomID string, payload []byte) error {
+	return b.client.Publish(ctx, roomChannel(roomID), payload).Err()
+}
+
+// Join subscribes this instance to a room's messages.
+func (b *RoomBroker) Join(ctx context.Context, roomID string) error {
+	return b.pubsub.Subscribe(ctx, roomChannel(roomID))
+}
+
+// Leave unsubscribes this instance from a room's messages.
+func (b *RoomBroker) Leave(ctx context.Context, roomID string) error {
+	return b.pubsub.Unsubscribe(ctx, roomChannel(roomID))
+}
+
+// Messages returns the messages of all joined rooms. The channel is closed by Close.
+func (b *RoomBroker) Messages() <-chan RoomMessage {
+	return b.messages
+}
+
+func seqKey(roomID string) string {
+	return fmt.Sprintf("room:%s:seq", roomID)
+}
+
+// NextSeq returns the next cluster-wide operation sequence number of a room.
+func (b *RoomBroker) NextSeq(ctx context.Context, roomID string) (int64, error) {
+	return b.client.Incr(ctx, seqKey(roomID)).Result()
+}
+
+// CurrentSeq returns the last sequence number handed out by NextSeq, or 0.
+func (b *RoomBroker) CurrentSeq(ctx context.Context, roomID string) (int64, error) {
+	seq, err := b.client.Get(ctx, seqKey(roomID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// Close stops the subscription.
+func (b *RoomBroker) Close() error {
+	return b.pubsub.Close()
+}
+
+func (b *RoomBroker) receive() {
+	defer close(b.messages)
+	for msg := range b.pubsub.Channel() {
+		roomID, ok := strings.CutPrefix(msg.Channel, "room:")
+		if !ok {
+			continue
+		}
+		roomID, ok = strings.CutSuffix(roomID, ":events")
+		if !ok {
+			continue
+		}
+		b.messages <- RoomMessage{RoomID: roomID, Payload: []byte(msg.Payload)}
+	}
+}
@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript keeps a sorted set of request timestamps (ms) per key.
+// Entries older than the window are dropped; the request is recorded only if
+// the remaining count is under the limit. Time comes from the Redis server so
+// all API instances share one clock.
+//
+// Returns {allowed (0|1), count, now_ms, reset_ms}, where reset_ms is when the
+// oldest request in the window expires and a slot frees up.
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+return {allowed, count, now, reset}
+`)
+
+// RateLimitResult is the outcome of a single rate limit check.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time     // when the next slot in the window frees up
+	RetryAfter time.Duration // zero when allowed
+}
+
+type RateLimiter struct {
+	client *redis.Client
+}
+
+func NewRateLimiter(client *redis.Client) *RateLimiter {
+	return &RateLimiter{client: client}
+}
+
+// Allow records a request against key and reports whether it fits within
+// limit requests per sliding window.
+func (s *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	res, err := slidingWindowScript.Run(ctx, s.client, []string{"ratelimit:" + key},
+		window.Milliseconds(), limit, uuid.NewString()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, count, now, reset := res[0] == 1, int(res[1]), res[2], res[3]
+	result := &RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: max(limit-count, 0),
+		Reset:     time.UnixMilli(reset),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration(reset-now) * time.Millisecond
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRateLimiter(client), srv
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter, srv := newTestLimiter(t)
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	srv.SetTime(start)
+
+	for i := 1; i <= 3; i++ {
+		res, err := limiter.Allow(ctx, "global:user:a", 3, time.Minute)
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if !res.Allowed || res.Remaining != 3-i || res.RetryAfter != 0 {
+			t.Fatalf("request %d = %+v, want allowed with %d remaining", i, res, 3-i)
+		}
+	}
+
+	res, err := limiter.Allow(ctx, "global:user:a", 3, time.Minute)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if res.Allowed || res.Remaining != 0 {
+		t.Errorf("over-limit request = %+v, want rejected", res)
+	}
+	if res.RetryAfter != time.Minute || !res.Reset.Equal(start.Add(time.Minute)) {
+		t.Errorf("retry after = %v, reset = %v, want the oldest request to expire", res.RetryAfter, res.Reset)
+	}
+
+	res, err = limiter.Allow(ctx, "global:user:b", 3, time.Minute)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !res.Allowed {
+		t.Errorf("another key shares the limit: %+v", res)
+	}
+
+	srv.SetTime(start.Add(time.Minute + time.Second))
+	res, err = limiter.Allow(ctx, "global:user:a", 3, time.Minute)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !res.Allowed || res.Remaining != 2 {
+		t.Errorf("request after the window = %+v, want allowed with 2 remaining", res)
+	}
+}
+
+func TestRateLimiterRejectedRequestsAreNotCounted(t *testing.T) {
+	limiter, srv := newTestLimiter(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if _, err := limiter.Allow(ctx, "write:ip:1.2.3.4", 2, time.Minute); err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+	}
+	members, err := srv.ZMembers("ratelimit:write:ip:1.2.3.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 {
+		t.Errorf("window holds %d requests, want 2", len(members))
+	}
+}
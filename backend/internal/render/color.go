@@ -0,0 +1,142 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// None is the fully transparent color used for "no fill" / "no stroke".
+var None = color.NRGBA{}
+
+// theme is the fill/stroke pair behind a node's `color` name.
+type theme struct {
+	Fill   color.NRGBA
+	Stroke color.NRGBA
+	Text   color.NRGBA
+}
+
+// themes mirrors the styleMap of frontend/src/lib/components/nodes/ShapeNode.svelte.
+var themes = map[string]theme{
+	"slate":  {Fill: rgb(0x1e, 0x29, 0x3b), Stroke: rgb(0x47, 0x55, 0x69), Text: rgb(0xe2, 0xe8, 0xf0)},
+	"red":    {Fill: rgba(127, 29, 29, 0.4), Stroke: rgb(0xef, 0x44, 0x44), Text: rgb(0xe2, 0xe8, 0xf0)},
+	"green":  {Fill: rgba(20, 83, 45, 0.4), Stroke: rgb(0x22, 0xc5, 0x5e), Text: rgb(0xe2, 0xe8, 0xf0)},
+	"amber":  {Fill: rgba(120, 53, 15, 0.4), Stroke: rgb(0xf5, 0x9e, 0x0b), Text: rgb(0xe2, 0xe8, 0xf0)},
+	"indigo": {Fill: rgba(49, 46, 129, 0.4), Stroke: rgb(0x63, 0x66, 0xf1), Text: rgb(0xe2, 0xe8, 0xf0)},
+	"cyan":   {Fill: rgba(22, 78, 99, 0.4), Stroke: rgb(0x06, 0xb6, 0xd4), Text: rgb(0xe2, 0xe8, 0xf0)},
+	"white":  {Fill: rgb(0xff, 0xff, 0xff), Stroke: rgb(0x94, 0xa3, 0xb8), Text: rgb(0x1e, 0x29, 0x3b)},
+}
+
+// Fixed colors used by the editor canvas.
+var (
+	edgeStroke     = rgb(0x64, 0x74, 0x8b) // slate-500
+	edgeLabelFill  = rgb(0x0f, 0x17, 0x2a) // slate-900
+	edgeLabelLine  = rgb(0x33, 0x41, 0x55) // slate-700
+	edgeLabelText  = rgb(0xcb, 0xd5, 0xe1) // slate-300
+	placeholderTxt = rgb(0x64, 0x74, 0x8b)
+)
+
+var namedColors = map[string]color.NRGBA{
+	"black":  rgb(0, 0, 0),
+	"white":  rgb(0xff, 0xff, 0xff),
+	"red":    rgb(0xff, 0, 0),
+	"green":  rgb(0, 0x80, 0),
+	"blue":   rgb(0, 0, 0xff),
+	"yellow": rgb(0xff, 0xff, 0),
+	"orange": rgb(0xff, 0xa5, 0),
+	"purple": rgb(0x80, 0, 0x80),
+	"gray":   rgb(0x80, 0x80, 0x80),
+	"grey":   rgb(0x80, 0x80, 0x80),
+}
+
+func rgb(r, g, b uint8) color.NRGBA {
+	return color.NRGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+func rgba(r, g, b uint8, a float64) color.NRGBA {
+	return color.NRGBA{R: r, G: g, B: b, A: uint8(math.Round(a * 0xff))}
+}
+
+// withOpacity scales the alpha channel of c by opacity (0..1).
+func withOpacity(c color.NRGBA, opacity float64) color.NRGBA {
+	c.A = uint8(math.Round(float64(c.A) * math.Max(0, math.Min(1, opacity))))
+	return c
+}
+
+// ParseColor parses a CSS color: #rgb, #rgba, #rrggbb, #rrggbbaa, rgb(), rgba(),
+// a basic color name, or "none"/"transparent".
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "none", "transparent":
+		return None, nil
+	}
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		if len(hex) == 3 || len(hex) == 4 {
+			var b strings.Builder
+			for _, ch := range hex {
+				b.WriteRune(ch)
+				b.WriteRune(ch)
+			}
+			hex = b.String()
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 8 || err != nil {
+			return None, fmt.Errorf("invalid hex color %q", s)
+		}
+		return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+	}
+
+	if args, ok := cutFunc(s, "rgba", "rgb"); ok {
+		parts := strings.Split(args, ",")
+		if len(parts) != 3 && len(parts) != 4 {
+			return None, fmt.Errorf("invalid rgb color %q", s)
+		}
+		var ch [3]uint8
+		for i := range 3 {
+			v, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+			if err != nil || v < 0 || v > 255 {
+				return None, fmt.Errorf("invalid rgb color %q", s)
+			}
+			ch[i] = uint8(math.Round(v))
+		}
+		alpha := 1.0
+		if len(parts) == 4 {
+			v, err := strconv.ParseFloat(strings.TrimSpace(parts[3]), 64)
+			if err != nil || v < 0 || v > 1 {
+				return None, fmt.Errorf("invalid rgb color %q", s)
+			}
+			alpha = v
+		}
+		return rgba(ch[0], ch[1], ch[2], alpha), nil
+	}
+
+	return None, fmt.Errorf("unsupported color %q", s)
+}
+
+// cutFunc strips a CSS functional notation such as `rgba(...)` and returns its arguments.
+func cutFunc(s string, names ...string) (string, bool) {
+	for _, name := range names {
+		if rest, ok := strings.CutPrefix(s, name+"("); ok {
+			return strings.CutSuffix(rest, ")")
+		}
+	}
+	return "", false
+}
+
+// cssColor formats c as an SVG color and separate opacity (1 when opaque).
+func cssColor(c color.NRGBA) (string, float64) {
+	if c.A == 0 {
+		return "none", 1
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B), float64(c.A) / 0xff
+}
@@ -0,0 +1,251 @@
+package render
+
+import (
+	"math"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// drawEdge returns the elements for an edge, following BaseEdge.svelte:
+// the path is routed center-to-center by edge type (or through the routing
+// waypoints) and then clipped to the outlines of both nodes so that markers
+// touch the shapes instead of hiding beneath them.
+func drawEdge(e *document.Edge, src, tgt *nodeBox, r edgeRouting) []Element {
+	sc, tc := src.rect.Center(), tgt.rect.Center()
+
+	var path Path
+	switch {
+	case len(r.Waypoints) > 0 && e.Type == "straight":
+		path = polyline(append(append([]Point{sc}, r.Waypoints...), tc)...)
+	case len(r.Waypoints) > 0:
+		path = smoothPolyline(append(append([]Point{sc}, r.Waypoints...), tc))
+	case e.Type == "step":
+		midY := (sc.Y + tc.Y) / 2
+		path = polyline(sc, Point{sc.X, midY}, Point{tc.X, midY}, tc)
+	case e.Type == "straight":
+		path = polyline(sc, tc)
+	default:
+		d := math.Min(math.Abs(tc.X-sc.X)*0.5, 150) + math.Min(math.Abs(tc.Y-sc.Y)*0.5, 150)
+		path.MoveTo(sc.X, sc.Y)
+		path.CubicTo(sc.X, sc.Y+d, tc.X, tc.Y-d, tc.X, tc.Y)
+	}
+
+	if src != tgt {
+		path = trimStart(path, src.contains)
+		path = trimEnd(path, tgt.contains)
+	}
+
+	stroke := edgeStroke
+	if r.Stroke != nil {
+		stroke = *r.Stroke
+	}
+	lineStyle := Style{Stroke: stroke, StrokeWidth: r.StrokeWidth, Dash: r.Dash}
+
+	// Markers scale with the stroke width like SVG markerUnits="strokeWidth".
+	scale := math.Max(r.StrokeWidth, 1) / 2
+	var markers []Element
+	if m, ok := marker(r.MarkerEnd, path, true, scale); ok {
+		markers = append(markers, Element{Path: m.shape, Style: Style{Fill: stroke}})
+		path = trimEnd(path, m.covers)
+	}
+	if m, ok := marker(r.MarkerStart, path, false, scale); ok {
+		markers = append(markers, Element{Path: m.shape, Style: Style{Fill: stroke}})
+		path = trimStart(path, m.covers)
+	}
+
+	els := append([]Element{{Path: path, Style: lineStyle}}, markers...)
+	if e.Label != "" {
+		mid := Point{(sc.X + tc.X) / 2, (sc.Y + tc.Y) / 2}
+		w := TextWidth(e.Label, 10) + 8
+		els = append(els,
+			Element{
+				Path:  rectPath(Rect{X: mid.X - w/2, Y: mid.Y - 10, W: w, H: 20}, 4),
+				Style: Style{Fill: edgeLabelFill, Stroke: edgeLabelLine, StrokeWidth: 1},
+			},
+			Element{Text: &Text{
+				Lines: []string{e.Label}, X: mid.X, Y: mid.Y + 4,
+				Size: 10, LineHeight: 12, Family: "sans-serif", Color: edgeLabelText,
+			}},
+		)
+	}
+	return els
+}
+
+// smoothPolyline passes a Catmull-Rom spline through pts (getSmoothPolyline in geometry.ts).
+func smoothPolyline(pts []Point) Path {
+	if len(pts) == 2 {
+		return polyline(pts...)
+	}
+	full := append(append([]Point{pts[0]}, pts...), pts[len(pts)-1])
+
+	var p Path
+	p.MoveTo(pts[0].X, pts[0].Y)
+	for i := 1; i < len(full)-2; i++ {
+		p0, p1, p2, p3 := full[i-1], full[i], full[i+1], full[i+2]
+		p.CubicTo(
+			p1.X+(p2.X-p0.X)/6, p1.Y+(p2.Y-p0.Y)/6,
+			p2.X-(p3.X-p1.X)/6, p2.Y-(p3.Y-p1.Y)/6,
+			p2.X, p2.Y,
+		)
+	}
+	return p
+}
+
+// edgeMarker is a marker shape and the region of the line it covers.
+type edgeMarker struct {
+	shape  Path
+	covers func(Point) bool
+}
+
+// marker builds the arrow or circle marker at the end (or start) of path.
+// It reports false for "none" and for degenerate paths.
+func marker(kind string, path Path, atEnd bool, scale float64) (edgeMarker, bool) {
+	if kind == "none" || len(path) < 2 {
+		return edgeMarker{}, false
+	}
+	tip, dir, ok := endpoint(path, atEnd)
+	if !ok {
+		return edgeMarker{}, false
+	}
+
+	if kind == "circle" {
+		radius := 4 * scale
+		c := Point{tip.X - dir.X*radius, tip.Y - dir.Y*radius}
+		return edgeMarker{
+			shape:  ellipsePath(c, radius, radius),
+			covers: func(p Point) bool { return math.Hypot(p.X-c.X, p.Y-c.Y) < radius },
+		}, true
+	}
+
+	// Arrowhead: the 10×7 triangle of the editor's #arrowhead marker.
+	length, half := 10*scale, 3.5*scale
+	base := Point{tip.X - dir.X*length, tip.Y - dir.Y*length}
+	normal := Point{-dir.Y, dir.X}
+	return edgeMarker{
+		shape: polygon(
+			tip,
+			Point{base.X + normal.X*half, base.Y + normal.Y*half},
+			Point{base.X - normal.X*half, base.Y - normal.Y*half},
+		),
+		covers: func(p Point) bool { return math.Hypot(p.X-tip.X, p.Y-tip.Y) < length*0.6 },
+	}, true
+}
+
+// endpoint returns the first or last point of path and the unit direction
+// pointing out of the path at that end.
+func endpoint(path Path, atEnd bool) (Point, Point, bool) {
+	polys := path.flatten()
+	if len(polys) == 0 {
+		return Point{}, Point{}, false
+	}
+	pts := polys[0]
+	if atEnd {
+		pts = polys[len(polys)-1]
+		pts = reversed(pts)
+	}
+	tip := pts[0]
+	for _, p := range pts[1:] {
+		dx, dy := tip.X-p.X, tip.Y-p.Y
+		if d := math.Hypot(dx, dy); d > 1e-6 {
+			return tip, Point{dx / d, dy / d}, true
+		}
+	}
+	return Point{}, Point{}, false
+}
+
+func reversed(pts []Point) []Point {
+	out := make([]Point, len(pts))
+	for i, p := range pts {
+		out[len(pts)-1-i] = p
+	}
+	return out
+}
+
+// trimStart removes the leading part of an open path for which inside is true,
+// cutting the segment that leaves the region at the exact crossing point.
+func trimStart(path Path, inside func(Point) bool) Path {
+	if len(path) < 2 || path[0].Op != OpMoveTo {
+		return path
+	}
+	cur := path[0].Pts[0]
+	if !inside(cur) {
+		return path
+	}
+	for i := 1; i < len(path); i++ {
+		s := path[i]
+		end := segmentEnd(s)
+		if inside(end) {
+			cur = end
+			continue
+		}
+		// Bisect for the exit parameter of this segment
+		lo, hi := 0.0, 1.0
+		for range 24 {
+			mid := (lo + hi) / 2
+			if inside(segmentAt(cur, s, mid)) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		_, tail := splitSegment(cur, s, hi)
+		out := Path{{Op: OpMoveTo, Pts: [3]Point{segmentAt(cur, s, hi)}}, tail}
+		return append(out, path[i+1:]...)
+	}
+	return path // entirely inside: keep as is rather than dropping the edge
+}
+
+// trimEnd is trimStart applied to the reversed path.
+func trimEnd(path Path, inside func(Point) bool) Path {
+	return reversePath(trimStart(reversePath(path), inside))
+}
+
+func segmentEnd(s Segment) Point {
+	if s.Op == OpCubicTo {
+		return s.Pts[2]
+	}
+	return s.Pts[0]
+}
+
+func segmentAt(from Point, s Segment, t float64) Point {
+	if s.Op == OpCubicTo {
+		return cubicAt(from, s.Pts[0], s.Pts[1], s.Pts[2], t)
+	}
+	to := s.Pts[0]
+	return Point{from.X + (to.X-from.X)*t, from.Y + (to.Y-from.Y)*t}
+}
+
+// splitSegment splits a line or cubic starting at from at parameter t (de Casteljau).
+func splitSegment(from Point, s Segment, t float64) (Segment, Segment) {
+	if s.Op != OpCubicTo {
+		at := segmentAt(from, s, t)
+		return Segment{Op: OpLineTo, Pts: [3]Point{at}}, s
+	}
+	lerp := func(a, b Point) Point { return Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t} }
+	p01, p12, p23 := lerp(from, s.Pts[0]), lerp(s.Pts[0], s.Pts[1]), lerp(s.Pts[1], s.Pts[2])
+	p012, p123 := lerp(p01, p12), lerp(p12, p23)
+	at := lerp(p012, p123)
+	return Segment{Op: OpCubicTo, Pts: [3]Point{p01, p012, at}},
+		Segment{Op: OpCubicTo, Pts: [3]Point{p123, p23, s.Pts[2]}}
+}
+
+// reversePath reverses a single open subpath of lines and cubics.
+func reversePath(path Path) Path {
+	if len(path) < 2 || path[0].Op != OpMoveTo {
+		return path
+	}
+	out := Path{{Op: OpMoveTo, Pts: [3]Point{segmentEnd(path[len(path)-1])}}}
+	for i := len(path) - 1; i >= 1; i-- {
+		start := segmentEnd(path[i-1])
+		s := path[i]
+		switch s.Op {
+		case OpCubicTo:
+			out = append(out, Segment{Op: OpCubicTo, Pts: [3]Point{s.Pts[1], s.Pts[0], start}})
+		case OpLineTo:
+			out = append(out, Segment{Op: OpLineTo, Pts: [3]Point{start}})
+		default:
+			return path
+		}
+	}
+	return out
+}
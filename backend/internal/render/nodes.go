@@ -0,0 +1,210 @@
+package render
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// defaultSizes are the width/height fallbacks of the frontend node components.
+var defaultSizes = map[string][2]float64{
+	"decision":  {100, 100},
+	"start-end": {100, 50},
+	"entity":    {120, 80},
+	"actor":     {60, 90},
+	"lifeline":  {120, 300},
+}
+
+// nodeBox is a node with its resolved geometry and style.
+type nodeBox struct {
+	node  *document.Node
+	rect  Rect
+	style nodeStyle
+	hit   [][]Point // flattened outline used to clip edge endpoints
+}
+
+func newNodeBox(n *document.Node, view *document.DocumentView) *nodeBox {
+	pos := n.Position
+	if p, ok := view.Positions[n.ID]; ok {
+		pos = p
+	}
+
+	size, ok := defaultSizes[n.Type]
+	if !ok {
+		size = [2]float64{120, 60}
+	}
+	if n.Width != nil && *n.Width > 0 {
+		size[0] = *n.Width
+	}
+	if n.Height != nil && *n.Height > 0 {
+		size[1] = *n.Height
+	}
+
+	nb := &nodeBox{
+		node:  n,
+		rect:  Rect{X: pos.X, Y: pos.Y, W: size[0], H: size[1]},
+		style: resolveNodeStyle(n, view),
+	}
+	if outline, _ := shapeOutline(n.Type, nb.rect); outline != nil {
+		nb.hit = outline.flatten()
+	}
+	return nb
+}
+
+// contains reports whether p lies inside the node's visible shape
+// (its bounding box for shapes without a closed outline).
+func (nb *nodeBox) contains(p Point) bool {
+	r := nb.rect
+	if p.X < r.X || p.X > r.X+r.W || p.Y < r.Y || p.Y > r.Y+r.H {
+		return false
+	}
+	if len(nb.hit) == 0 {
+		return true
+	}
+	inside := false
+	for _, poly := range nb.hit {
+		if pointInPolygon(p, poly) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// drawNode returns the elements for a node, following the shape of its
+// frontend component (NodeRenderer falls back to ShapeNode for unknown types).
+func drawNode(nb *nodeBox) []Element {
+	switch nb.node.Type {
+	case "entity":
+		return drawEntity(nb)
+	case "actor":
+		return drawActor(nb)
+	case "lifeline":
+		return drawLifeline(nb)
+	case "text":
+		return []Element{{Text: nb.label(nb.rect, nb.style.text(themes["slate"]))}}
+	}
+
+	t := nb.style.palette("slate")
+	paint := nb.style.paint(t, 2)
+	outline, details := shapeOutline(nb.node.Type, nb.rect)
+
+	els := []Element{{Path: outline, Style: paint}}
+	if details != nil {
+		els = append(els, Element{Path: details, Style: Style{Stroke: paint.Stroke, StrokeWidth: paint.StrokeWidth}})
+	}
+	return append(els, Element{Text: nb.label(nb.rect, nb.style.text(t))})
+}
+
+// label is the node label centered in area and wrapped to its width.
+func (nb *nodeBox) label(area Rect, c color.NRGBA) *Text {
+	st := nb.style
+	lines := wrapText(nb.node.Label, st.fontSize, area.W-16)
+	lineHeight := st.fontSize * 1.2
+	return &Text{
+		Lines:      lines,
+		X:          area.X + area.W/2,
+		Y:          firstBaseline(area.Y+area.H/2, len(lines), st.fontSize, lineHeight),
+		Size:       st.fontSize,
+		LineHeight: lineHeight,
+		Family:     st.fontFamily,
+		Bold:       st.bold,
+		Color:      c,
+	}
+}
+
+// drawEntity mirrors EntityNode.svelte: a table with a header bar and attribute rows.
+func drawEntity(nb *nodeBox) []Element {
+	r := nb.rect
+	t := nb.style.palette("green")
+	body := nb.style.paint(theme{Fill: themes["slate"].Fill, Stroke: withOpacity(t.Stroke, 0.6)}, 1.5)
+	header := withOpacity(t.Stroke, 0.2*nb.style.opacity)
+	headerText := withOpacity(t.Stroke, nb.style.opacity)
+	if nb.style.textColor != nil {
+		headerText = nb.style.text(t)
+	}
+
+	var headerPath Path = rectPath(Rect{X: r.X, Y: r.Y, W: r.W, H: 24}, 4)
+	headerPath = append(headerPath, rectPath(Rect{X: r.X, Y: r.Y + 20, W: r.W, H: 4}, 0)...)
+
+	els := []Element{
+		{Path: rectPath(r, 4), Style: body},
+		{Path: headerPath, Style: Style{Fill: header}},
+		{Path: polyline(Point{r.X, r.Y + 24}, Point{r.X + r.W, r.Y + 24}), Style: Style{Stroke: withOpacity(t.Stroke, 0.3*nb.style.opacity), StrokeWidth: 1}},
+		{Text: &Text{
+			Lines: []string{nb.node.Label}, X: r.X + r.W/2, Y: r.Y + 16,
+			Size: 11, LineHeight: 13, Family: "sans-serif", Bold: true, Color: headerText,
+		}},
+	}
+
+	attrs := nodeAttributes(nb.node.Data)
+	if len(attrs) == 0 {
+		return append(els, Element{Text: &Text{
+			Lines: []string{"(attributes)"}, X: r.X + r.W/2, Y: r.Y + 24 + (r.H-24)/2 + 4,
+			Size: 10, LineHeight: 12, Family: "sans-serif", Italic: true, Color: placeholderTxt,
+		}})
+	}
+	return append(els, Element{Text: &Text{
+		Lines: attrs, X: r.X + 10, Y: r.Y + 36,
+		Size: 10, LineHeight: 14, Family: "monospace", Anchor: AnchorStart, Color: body.Stroke,
+	}})
+}
+
+// drawActor mirrors ActorNode.svelte: a stick figure with the label underneath.
+func drawActor(nb *nodeBox) []Element {
+	r := nb.rect
+	t := nb.style.palette("slate")
+	if nb.style.theme == "" || nb.style.theme == "slate" {
+		t.Stroke = rgba(251, 191, 36, 0.7)
+	}
+	paint := nb.style.paint(t, 1.5)
+	cx := r.X + r.W/2
+
+	var limbs Path
+	limbs = append(limbs, polyline(Point{cx, r.Y + 20}, Point{cx, r.Y + 46})...)
+	limbs = append(limbs, polyline(Point{cx - 16, r.Y + 30}, Point{cx + 16, r.Y + 30})...)
+	limbs = append(limbs, polyline(Point{cx - 12, r.Y + 62}, Point{cx, r.Y + 46}, Point{cx + 12, r.Y + 62})...)
+
+	textColor := rgb(0xcb, 0xd5, 0xe1)
+	if nb.style.textColor != nil {
+		textColor = *nb.style.textColor
+	}
+	return []Element{
+		{Path: ellipsePath(Point{cx, r.Y + 12}, 8, 8), Style: paint},
+		{Path: limbs, Style: Style{Stroke: paint.Stroke, StrokeWidth: paint.StrokeWidth}},
+		{Text: &Text{
+			Lines: []string{nb.node.Label}, X: cx, Y: r.Y + r.H - 2,
+			Size: 10, LineHeight: 12, Family: "sans-serif", Color: withOpacity(textColor, nb.style.opacity),
+		}},
+	}
+}
+
+// drawLifeline mirrors LifelineNode.svelte: a head box and a dashed line down to the node height.
+func drawLifeline(nb *nodeBox) []Element {
+	r := nb.rect
+	t := nb.style.palette("slate")
+	paint := nb.style.paint(t, 2)
+	head := Rect{X: r.X, Y: r.Y, W: r.W, H: math.Min(50, r.H)}
+	cx := r.X + r.W/2
+
+	return []Element{
+		{Path: rectPath(head, 4), Style: paint},
+		{Text: nb.label(head, nb.style.text(t))},
+		{Path: polyline(Point{cx, head.Y + head.H}, Point{cx, r.Y + r.H}), Style: Style{Stroke: edgeStroke, StrokeWidth: 2, Dash: []float64{8, 8}}},
+	}
+}
+
+// nodeAttributes returns Node.Data.attributes (entity/class rows), if any.
+func nodeAttributes(data json.RawMessage) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	var d struct {
+		Attributes []string `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil
+	}
+	return d.Attributes
+}
@@ -0,0 +1,246 @@
+package render
+
+import "math"
+
+// Point is a coordinate in diagram space.
+type Point struct {
+	X, Y float64
+}
+
+// Rect is an axis-aligned rectangle in diagram space.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// Center returns the middle of the rectangle.
+func (r Rect) Center() Point {
+	return Point{X: r.X + r.W/2, Y: r.Y + r.H/2}
+}
+
+// union returns the smallest rectangle containing both r and o.
+func (r Rect) union(o Rect) Rect {
+	minX, minY := math.Min(r.X, o.X), math.Min(r.Y, o.Y)
+	maxX, maxY := math.Max(r.X+r.W, o.X+o.W), math.Max(r.Y+r.H, o.Y+o.H)
+	return Rect{X: minX, Y: minY, W: maxX - minX, H: maxY - minY}
+}
+
+// SegmentOp is the kind of a path segment.
+type SegmentOp int
+
+const (
+	OpMoveTo  SegmentOp = iota // Pts[0]
+	OpLineTo                   // Pts[0]
+	OpCubicTo                  // Pts[0], Pts[1] control points, Pts[2] end point
+	OpClose
+)
+
+// Segment is one command of a Path.
+type Segment struct {
+	Op  SegmentOp
+	Pts [3]Point
+}
+
+// Path is a sequence of move/line/cubic/close commands, the common
+// denominator of every output format. Arcs are approximated with cubics.
+type Path []Segment
+
+// MoveTo starts a new subpath at p.
+func (p *Path) MoveTo(x, y float64) {
+	*p = append(*p, Segment{Op: OpMoveTo, Pts: [3]Point{{x, y}}})
+}
+
+// LineTo draws a straight line to (x, y).
+func (p *Path) LineTo(x, y float64) {
+	*p = append(*p, Segment{Op: OpLineTo, Pts: [3]Point{{x, y}}})
+}
+
+// CubicTo draws a cubic Bézier curve to (x, y).
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float64) {
+	*p = append(*p, Segment{Op: OpCubicTo, Pts: [3]Point{{c1x, c1y}, {c2x, c2y}, {x, y}}})
+}
+
+// Close closes the current subpath.
+func (p *Path) Close() {
+	*p = append(*p, Segment{Op: OpClose})
+}
+
+// kappa is the cubic control point distance approximating a quarter circle.
+const kappa = 0.5522847498
+
+// polygon builds a closed path through pts.
+func polygon(pts ...Point) Path {
+	var p Path
+	for i, pt := range pts {
+		if i == 0 {
+			p.MoveTo(pt.X, pt.Y)
+		} else {
+			p.LineTo(pt.X, pt.Y)
+		}
+	}
+	p.Close()
+	return p
+}
+
+// polyline builds an open path through pts, skipping repeated points.
+func polyline(pts ...Point) Path {
+	var p Path
+	for i, pt := range pts {
+		switch {
+		case i == 0:
+			p.MoveTo(pt.X, pt.Y)
+		case pt != pts[i-1]:
+			p.LineTo(pt.X, pt.Y)
+		}
+	}
+	return p
+}
+
+// rectPath builds a rectangle with optional corner radius.
+func rectPath(r Rect, radius float64) Path {
+	radius = math.Min(radius, math.Min(r.W, r.H)/2)
+	if radius <= 0 {
+		return polygon(Point{r.X, r.Y}, Point{r.X + r.W, r.Y}, Point{r.X + r.W, r.Y + r.H}, Point{r.X, r.Y + r.H})
+	}
+	k := radius * (1 - kappa)
+	x0, y0, x1, y1 := r.X, r.Y, r.X+r.W, r.Y+r.H
+
+	var p Path
+	p.MoveTo(x0+radius, y0)
+	p.LineTo(x1-radius, y0)
+	p.CubicTo(x1-k, y0, x1, y0+k, x1, y0+radius)
+	p.LineTo(x1, y1-radius)
+	p.CubicTo(x1, y1-k, x1-k, y1, x1-radius, y1)
+	p.LineTo(x0+radius, y1)
+	p.CubicTo(x0+k, y1, x0, y1-k, x0, y1-radius)
+	p.LineTo(x0, y0+radius)
+	p.CubicTo(x0, y0+k, x0+k, y0, x0+radius, y0)
+	p.Close()
+	return p
+}
+
+// ellipsePath builds a closed ellipse centered at c.
+func ellipsePath(c Point, rx, ry float64) Path {
+	ox, oy := rx*kappa, ry*kappa
+
+	var p Path
+	p.MoveTo(c.X+rx, c.Y)
+	p.CubicTo(c.X+rx, c.Y+oy, c.X+ox, c.Y+ry, c.X, c.Y+ry)
+	p.CubicTo(c.X-ox, c.Y+ry, c.X-rx, c.Y+oy, c.X-rx, c.Y)
+	p.CubicTo(c.X-rx, c.Y-oy, c.X-ox, c.Y-ry, c.X, c.Y-ry)
+	p.CubicTo(c.X+ox, c.Y-ry, c.X+rx, c.Y-oy, c.X+rx, c.Y)
+	p.Close()
+	return p
+}
+
+// halfEllipse appends the lower (down) or upper half of an ellipse between its
+// horizontal extremes, from left to right or right to left, without moving the pen.
+func (p *Path) halfEllipse(c Point, rx, ry float64, down, leftToRight bool) {
+	ox, oy := rx*kappa, ry*kappa
+	if !down {
+		oy, ry = -oy, -ry
+	}
+	sx := 1.0
+	if !leftToRight {
+		sx = -1
+	}
+	p.CubicTo(c.X-sx*rx, c.Y+oy, c.X-sx*ox, c.Y+ry, c.X, c.Y+ry)
+	p.CubicTo(c.X+sx*ox, c.Y+ry, c.X+sx*rx, c.Y+oy, c.X+sx*rx, c.Y)
+}
+
+// quadTo appends the quadratic Bézier from → ctrl → to as an equivalent cubic.
+// from must be the current pen position.
+func (p *Path) quadTo(from, ctrl, to Point) {
+	p.CubicTo(
+		from.X+2.0/3*(ctrl.X-from.X), from.Y+2.0/3*(ctrl.Y-from.Y),
+		to.X+2.0/3*(ctrl.X-to.X), to.Y+2.0/3*(ctrl.Y-to.Y),
+		to.X, to.Y,
+	)
+}
+
+// Bounds returns the bounding box of the path's points, including control points.
+func (p Path) Bounds() Rect {
+	first := true
+	var minX, minY, maxX, maxY float64
+	for _, s := range p {
+		n := 1
+		switch s.Op {
+		case OpClose:
+			continue
+		case OpCubicTo:
+			n = 3
+		}
+		for _, pt := range s.Pts[:n] {
+			if first {
+				minX, minY, maxX, maxY = pt.X, pt.Y, pt.X, pt.Y
+				first = false
+				continue
+			}
+			minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+			maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+		}
+	}
+	return Rect{X: minX, Y: minY, W: maxX - minX, H: maxY - minY}
+}
+
+// flattenSteps is the number of line segments a cubic is split into when flattened.
+const flattenSteps = 16
+
+// flatten converts the path into polylines, one per subpath. Closed subpaths
+// end with their starting point repeated.
+func (p Path) flatten() [][]Point {
+	var polys [][]Point
+	var cur []Point
+	var start Point
+	for _, s := range p {
+		switch s.Op {
+		case OpMoveTo:
+			if len(cur) > 1 {
+				polys = append(polys, cur)
+			}
+			start = s.Pts[0]
+			cur = []Point{start}
+		case OpLineTo:
+			cur = append(cur, s.Pts[0])
+		case OpCubicTo:
+			if len(cur) == 0 {
+				cur = []Point{start}
+			}
+			p0 := cur[len(cur)-1]
+			for i := 1; i <= flattenSteps; i++ {
+				cur = append(cur, cubicAt(p0, s.Pts[0], s.Pts[1], s.Pts[2], float64(i)/flattenSteps))
+			}
+		case OpClose:
+			if len(cur) > 0 {
+				cur = append(cur, start)
+				polys = append(polys, cur)
+			}
+			cur = nil
+		}
+	}
+	if len(cur) > 1 {
+		polys = append(polys, cur)
+	}
+	return polys
+}
+
+// cubicAt evaluates a cubic Bézier at t.
+func cubicAt(p0, p1, p2, p3 Point, t float64) Point {
+	mt := 1 - t
+	a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+	return Point{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+// pointInPolygon is the even-odd ray casting test.
+func pointInPolygon(p Point, poly []Point) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
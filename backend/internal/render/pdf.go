@@ -0,0 +1,441 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Page sizes in PDF points (1/72 inch), portrait.
+var pageSizes = map[string][2]float64{
+	"a4":     {595.28, 841.89},
+	"a3":     {841.89, 1190.55},
+	"letter": {612, 792},
+	"legal":  {612, 1008},
+}
+
+// pxToPt converts diagram units (CSS pixels at 96 dpi) to points.
+const pxToPt = 0.75
+
+// minFitScale is the smallest zoom used when shrinking a diagram onto one page;
+// anything larger is tiled at this zoom rather than printed unreadably small.
+const minFitScale = 0.5
+
+// MaxPDFPages caps the number of tiles a PDF export may produce.
+const MaxPDFPages = 100
+
+// ErrTooManyPages is returned when tiling the scene would exceed MaxPDFPages.
+var ErrTooManyPages = errors.New("too many pages")
+
+const (
+	pdfMargin      = 36 // half an inch
+	pdfTitleHeight = 40
+)
+
+// PDFOptions control the page layout of a PDF export.
+type PDFOptions struct {
+	PageSize    string  // a4 (default), a3, letter, legal
+	Orientation string  // portrait, landscape, or auto (default): follows the diagram's aspect ratio
+	Scale       float64 // diagram zoom, 1 prints one diagram pixel as 0.75pt; 0 fits the diagram to the page
+	Title       string
+	Subtitle    string // e.g. version and last update, printed under the title
+}
+
+// PDF encodes the scene as a vector PDF. Every page carries a title block;
+// when the scaled diagram does not fit the printable area it is tiled
+// across several pages, row by row. Without an explicit scale the diagram is
+// printed at most 1:1 and shrunk to fit a single page, down to minFitScale.
+func PDF(scene *Scene, opts PDFOptions) ([]byte, error) {
+	size, ok := pageSizes[opts.PageSize]
+	if !ok {
+		size = pageSizes["a4"]
+	}
+	pageW, pageH := size[0], size[1]
+	landscape := opts.Orientation == "landscape" ||
+		(opts.Orientation != "portrait" && scene.Bounds.W > scene.Bounds.H)
+	if landscape {
+		pageW, pageH = pageH, pageW
+	}
+	areaW := pageW - 2*pdfMargin
+	areaH := pageH - 2*pdfMargin - pdfTitleHeight
+
+	scale := opts.Scale
+	if scale <= 0 {
+		fit := math.Min(areaW/(scene.Bounds.W*pxToPt), areaH/(scene.Bounds.H*pxToPt))
+		scale = math.Max(math.Min(fit, 1), minFitScale)
+	}
+	k := pxToPt * scale
+	cols := max(1, int(math.Ceil(scene.Bounds.W*k/areaW-1e-9)))
+	rows := max(1, int(math.Ceil(scene.Bounds.H*k/areaH-1e-9)))
+	if cols*rows > MaxPDFPages {
+		return nil, ErrTooManyPages
+	}
+
+	w := &pdfWriter{}
+	res := newPDFResources(scene)
+
+	// Object layout: 1 catalog, 2 page tree, 3 resources, then page/content pairs.
+	pageIDs := make([]int, 0, cols*rows)
+	for i := range cols * rows {
+		pageIDs = append(pageIDs, 4+2*i)
+	}
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+
+	w.header()
+	w.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.object(3, res.dict())
+
+	for row := range rows {
+		for col := range cols {
+			n := row*cols + col
+			tile := Point{scene.Bounds.X + float64(col)*areaW/k, scene.Bounds.Y + float64(row)*areaH/k}
+
+			var c pdfContent
+			c.res = res
+			c.titleBlock(opts, pageW, pageH, n+1, len(pageIDs), row, col, rows, cols)
+
+			// Clip to the printable area, then map diagram space onto it (y axis flipped).
+			top := pageH - pdfMargin - pdfTitleHeight
+			fmt.Fprintf(&c.buf, "q %s %s %s %s re W n\n", pdfNum(pdfMargin), pdfNum(top-areaH), pdfNum(areaW), pdfNum(areaH))
+			fmt.Fprintf(&c.buf, "%s 0 0 %s %s %s cm\n", pdfNum(k), pdfNum(-k), pdfNum(pdfMargin-tile.X*k), pdfNum(top+tile.Y*k))
+			c.scene(scene)
+			c.buf.WriteString("Q\n")
+
+			stream, err := deflate(c.buf.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			w.object(pageIDs[n], fmt.Sprintf(
+				"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources 3 0 R /Contents %d 0 R >>",
+				pdfNum(pageW), pdfNum(pageH), pageIDs[n]+1))
+			w.stream(pageIDs[n]+1, stream)
+		}
+	}
+
+	w.trailer(3 + 2*len(pageIDs))
+	return w.buf.Bytes(), nil
+}
+
+// ─── Resources ──────────────────────────────────────────
+
+// Standard 14 fonts; no embedding needed, text is WinAnsi encoded.
+var pdfFonts = []struct{ name, base string }{
+	{"F1", "Helvetica"},
+	{"F2", "Helvetica-Bold"},
+	{"F3", "Helvetica-Oblique"},
+	{"F4", "Courier"},
+}
+
+// pdfResources is the resource dictionary shared by every page: the fonts and
+// one ExtGState per distinct fill/stroke alpha pair used by the scene.
+type pdfResources struct {
+	alphas map[[2]uint8]string
+	order  [][2]uint8
+}
+
+func newPDFResources(scene *Scene) *pdfResources {
+	r := &pdfResources{alphas: map[[2]uint8]string{}}
+	add := func(fill, stroke uint8) {
+		key := [2]uint8{fill, stroke}
+		if _, ok := r.alphas[key]; ok || key == [2]uint8{0xff, 0xff} {
+			return
+		}
+		r.alphas[key] = fmt.Sprintf("GS%d", len(r.order)+1)
+		r.order = append(r.order, key)
+	}
+	add(scene.Background.A, 0xff)
+	for _, el := range scene.Elements {
+		if el.Text != nil {
+			add(el.Text.Color.A, 0xff)
+			continue
+		}
+		add(el.Style.Fill.A, el.Style.Stroke.A)
+	}
+	return r
+}
+
+func (r *pdfResources) dict() string {
+	var b strings.Builder
+	b.WriteString("<< /Font <<")
+	for _, f := range pdfFonts {
+		fmt.Fprintf(&b, " /%s << /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.name, f.base)
+	}
+	b.WriteString(" >>")
+	if len(r.order) > 0 {
+		b.WriteString(" /ExtGState <<")
+		for _, key := range r.order {
+			fmt.Fprintf(&b, " /%s << /ca %s /CA %s >>", r.alphas[key], pdfNum(float64(key[0])/0xff), pdfNum(float64(key[1])/0xff))
+		}
+		b.WriteString(" >>")
+	}
+	b.WriteString(" >>")
+	return b.String()
+}
+
+// ─── Content stream ─────────────────────────────────────
+
+type pdfContent struct {
+	buf bytes.Buffer
+	res *pdfResources
+}
+
+// titleBlock prints the title, subtitle and page position above the printable area.
+func (c *pdfContent) titleBlock(opts PDFOptions, pageW, pageH float64, page, pages, row, col, rows, cols int) {
+	top := pageH - pdfMargin
+	c.text("F2", 14, pdfMargin, top-14, opts.Title, rgb(0x0f, 0x17, 0x2a))
+
+	sub := opts.Subtitle
+	if pages > 1 {
+		pos := fmt.Sprintf("Page %d of %d (row %d/%d, column %d/%d)", page, pages, row+1, rows, col+1, cols)
+		if sub != "" {
+			sub += "  ·  "
+		}
+		sub += pos
+	}
+	c.text("F1", 9, pdfMargin, top-28, sub, rgb(0x64, 0x74, 0x8b))
+
+	fmt.Fprintf(&c.buf, "%s RG 0.5 w %s %s m %s %s l S\n",
+		pdfColor(rgb(0xcb, 0xd5, 0xe1)), pdfNum(pdfMargin), pdfNum(top-34), pdfNum(pageW-pdfMargin), pdfNum(top-34))
+}
+
+// text writes a single unflipped line of text in page coordinates.
+func (c *pdfContent) text(font string, size, x, y float64, s string, col color.NRGBA) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&c.buf, "%s rg BT /%s %s Tf %s %s Td %s Tj ET\n",
+		pdfColor(col), font, pdfNum(size), pdfNum(x), pdfNum(y), pdfString(s))
+}
+
+func (c *pdfContent) scene(scene *Scene) {
+	b := scene.Bounds
+	if scene.Background.A > 0 {
+		c.gs(scene.Background.A, 0xff)
+		fmt.Fprintf(&c.buf, "%s rg %s %s %s %s re f\n", pdfColor(scene.Background), pdfNum(b.X), pdfNum(b.Y), pdfNum(b.W), pdfNum(b.H))
+	}
+	c.buf.WriteString("1 j\n")
+
+	for _, el := range scene.Elements {
+		switch {
+		case el.Text != nil:
+			c.sceneText(el.Text)
+		case len(el.Path) > 0:
+			c.path(el.Path, el.Style)
+		}
+	}
+}
+
+func (c *pdfContent) path(p Path, st Style) {
+	fill := st.Fill.A > 0
+	stroke := st.Stroke.A > 0 && st.StrokeWidth > 0
+	if !fill && !stroke {
+		return
+	}
+
+	c.buf.WriteString("q ")
+	c.gs(st.Fill.A, st.Stroke.A)
+	if fill {
+		c.buf.WriteString(pdfColor(st.Fill) + " rg ")
+	}
+	if stroke {
+		fmt.Fprintf(&c.buf, "%s RG %s w ", pdfColor(st.Stroke), pdfNum(st.StrokeWidth))
+		if len(st.Dash) > 0 {
+			dash := make([]string, len(st.Dash))
+			for i, d := range st.Dash {
+				dash[i] = pdfNum(d)
+			}
+			fmt.Fprintf(&c.buf, "[%s] 0 d ", strings.Join(dash, " "))
+		}
+	}
+	c.buf.WriteString("\n")
+
+	for _, s := range p {
+		switch s.Op {
+		case OpMoveTo:
+			fmt.Fprintf(&c.buf, "%s %s m\n", pdfNum(s.Pts[0].X), pdfNum(s.Pts[0].Y))
+		case OpLineTo:
+			fmt.Fprintf(&c.buf, "%s %s l\n", pdfNum(s.Pts[0].X), pdfNum(s.Pts[0].Y))
+		case OpCubicTo:
+			fmt.Fprintf(&c.buf, "%s %s %s %s %s %s c\n",
+				pdfNum(s.Pts[0].X), pdfNum(s.Pts[0].Y), pdfNum(s.Pts[1].X), pdfNum(s.Pts[1].Y), pdfNum(s.Pts[2].X), pdfNum(s.Pts[2].Y))
+		case OpClose:
+			c.buf.WriteString("h\n")
+		}
+	}
+
+	switch {
+	case fill && stroke:
+		c.buf.WriteString("B Q\n")
+	case fill:
+		c.buf.WriteString("f Q\n")
+	default:
+		c.buf.WriteString("S Q\n")
+	}
+}
+
+// sceneText writes a text block inside the flipped diagram space;
+// the text matrix flips glyphs back upright.
+func (c *pdfContent) sceneText(t *Text) {
+	if t.Color.A == 0 {
+		return
+	}
+	font, widths := "F1", &helveticaWidths
+	switch {
+	case t.Mono():
+		font, widths = "F4", nil
+	case t.Bold:
+		font, widths = "F2", &helveticaBoldWidths
+	case t.Italic:
+		font = "F3"
+	}
+
+	c.buf.WriteString("q ")
+	c.gs(t.Color.A, 0xff)
+	c.buf.WriteString(pdfColor(t.Color) + " rg BT\n")
+	fmt.Fprintf(&c.buf, "/%s %s Tf\n", font, pdfNum(t.Size))
+	for i, line := range t.Lines {
+		x := t.X
+		if t.Anchor == AnchorMiddle {
+			x -= pdfTextWidth(line, t.Size, widths) / 2
+		}
+		fmt.Fprintf(&c.buf, "1 0 0 -1 %s %s Tm %s Tj\n", pdfNum(x), pdfNum(t.Y+float64(i)*t.LineHeight), pdfString(line))
+	}
+	c.buf.WriteString("ET Q\n")
+}
+
+// gs selects the ExtGState for a fill/stroke alpha pair (nothing when opaque).
+func (c *pdfContent) gs(fill, stroke uint8) {
+	if name, ok := c.res.alphas[[2]uint8{fill, stroke}]; ok {
+		c.buf.WriteString("/" + name + " gs ")
+	}
+}
+
+// ─── Text metrics ───────────────────────────────────────
+
+// Glyph widths (1/1000 em) of printable ASCII, from the standard 14 font metrics.
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// pdfTextWidth measures s in points; nil widths means Courier (fixed 600).
+func pdfTextWidth(s string, size float64, widths *[95]int) float64 {
+	total := 0
+	for _, r := range s {
+		switch {
+		case widths == nil:
+			total += 600
+		case r >= 32 && r <= 126:
+			total += widths[r-32]
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// ─── Low-level writer ───────────────────────────────────
+
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *pdfWriter) header() {
+	w.offsets = map[int]int{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+}
+
+func (w *pdfWriter) object(id int, body string) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *pdfWriter) stream(id int, data []byte) {
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", id, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *pdfWriter) trailer(maxID int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", maxID+1)
+	for id := 1; id <= maxID; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", maxID+1, xref)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfString encodes s as a WinAnsi literal string. Latin-1 characters map
+// directly; anything else the standard fonts cannot show becomes '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '·':
+			b.WriteString("\\267")
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func pdfColor(c color.NRGBA) string {
+	return pdfNum(float64(c.R)/0xff) + " " + pdfNum(float64(c.G)/0xff) + " " + pdfNum(float64(c.B)/0xff)
+}
+
+// pdfNum formats a number with at most three decimals.
+func pdfNum(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
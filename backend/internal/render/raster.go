@@ -0,0 +1,342 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// MaxRasterPixels caps the size of a rasterized image (about 8000×5000).
+const MaxRasterPixels = 40_000_000
+
+// ErrImageTooLarge is returned when the scaled scene exceeds MaxRasterPixels.
+var ErrImageTooLarge = errors.New("image too large")
+
+// PNG rasterizes the scene and encodes it as PNG.
+func PNG(scene *Scene, scale float64) ([]byte, error) {
+	img, err := Rasterize(scene, scale)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Rasterize draws the scene into an anti-aliased RGBA image, scale pixels per
+// diagram unit. Transparent backgrounds are kept as alpha.
+func Rasterize(scene *Scene, scale float64) (*image.RGBA, error) {
+	if scale <= 0 {
+		scale = 1
+	}
+	w := int(math.Ceil(scene.Bounds.W * scale))
+	h := int(math.Ceil(scene.Bounds.H * scale))
+	if w <= 0 || h <= 0 || w*h > MaxRasterPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	r := &rasterizer{
+		dst:   image.NewRGBA(image.Rect(0, 0, w, h)),
+		scale: scale,
+		orig:  Point{scene.Bounds.X, scene.Bounds.Y},
+		faces: make(map[faceKey]font.Face),
+	}
+	defer r.closeFaces()
+
+	if scene.Background.A > 0 {
+		draw.Draw(r.dst, r.dst.Bounds(), image.NewUniform(scene.Background), image.Point{}, draw.Src)
+	}
+
+	for _, el := range scene.Elements {
+		switch {
+		case el.Text != nil:
+			if err := r.text(el.Text); err != nil {
+				return nil, err
+			}
+		case len(el.Path) > 0:
+			r.path(el.Path, el.Style)
+		}
+	}
+	return r.dst, nil
+}
+
+type faceKey struct {
+	family string
+	bold   bool
+	italic bool
+	size   float64
+}
+
+// rasterizer holds the per-image drawing state. Font faces are not safe for
+// concurrent use, so each image gets its own.
+type rasterizer struct {
+	dst   *image.RGBA
+	scale float64
+	orig  Point
+	vec   vector.Rasterizer
+	faces map[faceKey]font.Face
+}
+
+// px maps a diagram point to image pixels.
+func (r *rasterizer) px(p Point) Point {
+	return Point{(p.X - r.orig.X) * r.scale, (p.Y - r.orig.Y) * r.scale}
+}
+
+func (r *rasterizer) path(p Path, st Style) {
+	polys := p.flatten()
+	for i, poly := range polys {
+		for j, pt := range poly {
+			polys[i][j] = r.px(pt)
+		}
+	}
+
+	if st.Fill.A > 0 {
+		r.fill(polys, st.Fill)
+	}
+	if st.Stroke.A > 0 && st.StrokeWidth > 0 {
+		var dash []float64
+		for _, d := range st.Dash {
+			dash = append(dash, d*r.scale)
+		}
+		var outline [][]Point
+		for _, poly := range polys {
+			closed := len(poly) > 2 && poly[0] == poly[len(poly)-1]
+			for _, run := range dashRuns(poly, dash) {
+				outline = append(outline, strokePolygons(run, closed && len(dash) == 0, st.StrokeWidth*r.scale/2)...)
+			}
+		}
+		r.fill(outline, st.Stroke)
+	}
+}
+
+// fill paints the union of polys (all wound the same way) with c.
+// Only the bounding box of the shape is rasterized.
+func (r *rasterizer) fill(polys [][]Point, c color.NRGBA) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, poly := range polys {
+		for _, p := range poly {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	box := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).
+		Intersect(r.dst.Bounds())
+	if box.Empty() {
+		return
+	}
+
+	r.vec.Reset(box.Dx(), box.Dy())
+	r.vec.DrawOp = draw.Over
+	ox, oy := float64(box.Min.X), float64(box.Min.Y)
+	for _, poly := range polys {
+		if len(poly) < 3 {
+			continue
+		}
+		r.vec.MoveTo(float32(poly[0].X-ox), float32(poly[0].Y-oy))
+		for _, p := range poly[1:] {
+			r.vec.LineTo(float32(p.X-ox), float32(p.Y-oy))
+		}
+		r.vec.ClosePath()
+	}
+	r.vec.Draw(r.dst, box, image.NewUniform(c), image.Point{})
+}
+
+func (r *rasterizer) text(t *Text) error {
+	if t.Color.A == 0 {
+		return nil
+	}
+	face, err := r.face(t)
+	if err != nil {
+		return err
+	}
+	d := font.Drawer{Dst: r.dst, Src: image.NewUniform(t.Color), Face: face}
+	for i, line := range t.Lines {
+		origin := r.px(Point{t.X, t.Y + float64(i)*t.LineHeight})
+		if t.Anchor == AnchorMiddle {
+			origin.X -= float64(d.MeasureString(line)) / 64 / 2
+		}
+		d.Dot = fixed.Point26_6{X: fixed.Int26_6(origin.X * 64), Y: fixed.Int26_6(origin.Y * 64)}
+		d.DrawString(line)
+	}
+	return nil
+}
+
+func (r *rasterizer) face(t *Text) (font.Face, error) {
+	key := faceKey{family: "sans-serif", bold: t.Bold, italic: t.Italic, size: t.Size * r.scale}
+	if t.Mono() {
+		key = faceKey{family: "monospace", size: t.Size * r.scale}
+	}
+	if f, ok := r.faces[key]; ok {
+		return f, nil
+	}
+
+	fonts, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+	otf := fonts.regular
+	switch {
+	case key.family == "monospace":
+		otf = fonts.mono
+	case key.bold:
+		otf = fonts.bold
+	case key.italic:
+		otf = fonts.italic
+	}
+	f, err := opentype.NewFace(otf, &opentype.FaceOptions{Size: key.size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, err
+	}
+	r.faces[key] = f
+	return f, nil
+}
+
+func (r *rasterizer) closeFaces() {
+	for _, f := range r.faces {
+		f.Close()
+	}
+}
+
+// goFonts are the embedded Go fonts used for text in raster output.
+type goFonts struct {
+	regular, bold, italic, mono *opentype.Font
+}
+
+var loadFonts = sync.OnceValues(func() (*goFonts, error) {
+	var fonts goFonts
+	for _, f := range []struct {
+		dst  **opentype.Font
+		data []byte
+	}{
+		{&fonts.regular, goregular.TTF},
+		{&fonts.bold, gobold.TTF},
+		{&fonts.italic, goitalic.TTF},
+		{&fonts.mono, gomono.TTF},
+	} {
+		parsed, err := opentype.Parse(f.data)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = parsed
+	}
+	return &fonts, nil
+})
+
+// strokePolygons outlines a polyline of half-width hw as a set of
+// counter-clockwise polygons: one quad per segment plus round joins
+// (SVG stroke-linejoin="round"); open ends get butt caps.
+func strokePolygons(pts []Point, closed bool, hw float64) [][]Point {
+	var polys [][]Point
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			continue
+		}
+		nx, ny := -dy/l*hw, dx/l*hw
+		polys = append(polys, ccw([]Point{
+			{a.X + nx, a.Y + ny}, {b.X + nx, b.Y + ny}, {b.X - nx, b.Y - ny}, {a.X - nx, a.Y - ny},
+		}))
+	}
+
+	joins := pts
+	if !closed && len(pts) > 2 {
+		joins = pts[1 : len(pts)-1]
+	} else if !closed {
+		joins = nil
+	}
+	for _, p := range joins {
+		polys = append(polys, ccw(circlePolygon(p, hw)))
+	}
+	return polys
+}
+
+// circlePolygon approximates a circle with a polygon.
+func circlePolygon(c Point, radius float64) []Point {
+	const n = 12
+	pts := make([]Point, n)
+	for i := range n {
+		angle := -2 * math.Pi * float64(i) / n
+		pts[i] = Point{c.X + radius*math.Cos(angle), c.Y + radius*math.Sin(angle)}
+	}
+	return pts
+}
+
+// ccw returns poly wound counter-clockwise (in image coordinates, y down),
+// so that overlapping stroke pieces add up instead of cancelling out.
+func ccw(poly []Point) []Point {
+	area := 0.0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%len(poly)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	if area > 0 {
+		return reversed(poly)
+	}
+	return poly
+}
+
+// dashRuns splits a polyline into the "on" runs of an SVG dash pattern.
+// An empty pattern returns the polyline unchanged.
+func dashRuns(pts []Point, dash []float64) [][]Point {
+	total := 0.0
+	for _, d := range dash {
+		total += d
+	}
+	if len(dash) == 0 || total <= 0 {
+		return [][]Point{pts}
+	}
+	if len(dash)%2 == 1 {
+		dash = append(dash, dash...) // SVG repeats odd-length patterns
+	}
+
+	var runs [][]Point
+	cur := []Point{pts[0]}
+	idx, left := 0, dash[0]
+	on := true
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		segLen := math.Hypot(b.X-a.X, b.Y-a.Y)
+		pos := 0.0
+		for segLen-pos > left {
+			pos += left
+			t := pos / segLen
+			p := Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+			if on {
+				runs = append(runs, append(cur, p))
+				cur = nil
+			} else {
+				cur = []Point{p}
+			}
+			on = !on
+			idx = (idx + 1) % len(dash)
+			left = dash[idx]
+		}
+		left -= segLen - pos
+		if on {
+			cur = append(cur, b)
+		}
+	}
+	if on && len(cur) > 1 {
+		runs = append(runs, cur)
+	}
+	return runs
+}
@@ -0,0 +1,128 @@
+// Package render draws diagram content server-side, without a browser.
+//
+// Build lays a DocumentContent and its DocumentView out into a Scene of
+// filled/stroked paths and text runs in diagram coordinates, mirroring the
+// node and edge components of the frontend canvas. Output backends
+// (SVG, ...) only have to know how to draw those primitives.
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// Style is how a path is painted. A zero Fill or Stroke (alpha 0) is not painted.
+type Style struct {
+	Fill        color.NRGBA
+	Stroke      color.NRGBA
+	StrokeWidth float64
+	Dash        []float64
+}
+
+// Anchor is the horizontal alignment of a text run relative to its X.
+type Anchor int
+
+const (
+	AnchorMiddle Anchor = iota
+	AnchorStart
+)
+
+// Text is a block of one or more lines. Y is the baseline of the first line.
+type Text struct {
+	Lines      []string
+	X, Y       float64
+	Size       float64
+	LineHeight float64
+	Family     string
+	Bold       bool
+	Italic     bool
+	Anchor     Anchor
+	Color      color.NRGBA
+}
+
+// Mono reports whether the text uses a monospace font.
+func (t *Text) Mono() bool {
+	return t.Family == "monospace"
+}
+
+// Element is a single drawing primitive: either a painted path or a text block.
+type Element struct {
+	Path  Path
+	Style Style
+	Text  *Text
+}
+
+// Scene is a rendered diagram. Bounds is the visible area in diagram
+// coordinates, padding included; elements are in paint order.
+type Scene struct {
+	Bounds     Rect
+	Background color.NRGBA
+	Elements   []Element
+}
+
+// Options control the layout of a Scene.
+type Options struct {
+	Padding    float64
+	Background color.NRGBA
+}
+
+// Build lays out content as a Scene. Positions and styles from view,
+// when present, take precedence over the values stored on the nodes.
+// Edges are painted first so that nodes sit on top of them, as in the editor.
+func Build(content *document.DocumentContent, view *document.DocumentView, opts Options) *Scene {
+	if view == nil {
+		view = &document.DocumentView{}
+	}
+
+	nodes := make([]*nodeBox, 0, len(content.Nodes))
+	byID := make(map[string]*nodeBox, len(content.Nodes))
+	for i := range content.Nodes {
+		nb := newNodeBox(&content.Nodes[i], view)
+		nodes = append(nodes, nb)
+		byID[nb.node.ID] = nb
+	}
+
+	scene := &Scene{Background: opts.Background}
+	var bounds *Rect
+	extend := func(r Rect) {
+		if bounds == nil {
+			bounds = &r
+			return
+		}
+		u := bounds.union(r)
+		bounds = &u
+	}
+
+	for i := range content.Edges {
+		e := &content.Edges[i]
+		src, tgt := byID[e.Source], byID[e.Target]
+		if src == nil || tgt == nil {
+			continue // dangling edge, the editor skips these too
+		}
+		for _, el := range drawEdge(e, src, tgt, edgeRoutingFor(view, e.ID)) {
+			scene.Elements = append(scene.Elements, el)
+			if el.Path != nil {
+				extend(el.Path.Bounds())
+			}
+		}
+	}
+
+	for _, nb := range nodes {
+		scene.Elements = append(scene.Elements, drawNode(nb)...)
+		extend(nb.rect)
+	}
+
+	if bounds == nil {
+		bounds = &Rect{}
+	}
+	pad := math.Max(0, opts.Padding)
+	scene.Bounds = Rect{
+		X: bounds.X - pad,
+		Y: bounds.Y - pad,
+		W: math.Max(1, bounds.W+2*pad),
+		H: math.Max(1, bounds.H+2*pad),
+	}
+	return scene
+}
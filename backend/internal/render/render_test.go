@@ -0,0 +1,108 @@
+package render
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestBuildBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []document.Node
+		view    *document.DocumentView
+		padding float64
+		want    Rect
+	}{
+		{
+			name:  "empty",
+			nodes: nil,
+			want:  Rect{W: 1, H: 1},
+		},
+		{
+			name:  "stored position and size",
+			nodes: []document.Node{{ID: "a", Type: "process", Position: document.Position{X: 10, Y: 20}, Width: ptr(100), Height: ptr(50)}},
+			want:  Rect{X: 10, Y: 20, W: 100, H: 50},
+		},
+		{
+			name:    "padding",
+			nodes:   []document.Node{{ID: "a", Type: "process", Position: document.Position{X: 10, Y: 20}, Width: ptr(100), Height: ptr(50)}},
+			padding: 5,
+			want:    Rect{X: 5, Y: 15, W: 110, H: 60},
+		},
+		{
+			name:  "view position wins",
+			nodes: []document.Node{{ID: "a", Type: "process", Position: document.Position{X: 10, Y: 20}, Width: ptr(100), Height: ptr(50)}},
+			view:  &document.DocumentView{Positions: map[string]document.Position{"a": {X: 300, Y: 400}}},
+			want:  Rect{X: 300, Y: 400, W: 100, H: 50},
+		},
+		{
+			name: "several nodes",
+			nodes: []document.Node{
+				{ID: "a", Type: "process", Position: document.Position{X: 0, Y: 0}, Width: ptr(100), Height: ptr(50)},
+				{ID: "b", Type: "process", Position: document.Position{X: 200, Y: 100}, Width: ptr(100), Height: ptr(50)},
+			},
+			want: Rect{X: 0, Y: 0, W: 300, H: 150},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := &document.DocumentContent{Nodes: tt.nodes}
+			scene := Build(content, tt.view, Options{Padding: tt.padding})
+			if scene.Bounds != tt.want {
+				t.Errorf("bounds = %+v, want %+v", scene.Bounds, tt.want)
+			}
+		})
+	}
+}
+
+func TestSVGIncludesLabels(t *testing.T) {
+	content := &document.DocumentContent{
+		Nodes: []document.Node{
+			{ID: "a", Type: "process", Label: "Input <data>", Position: document.Position{X: 0, Y: 0}},
+			{ID: "b", Type: "decision", Label: "Valid?", Position: document.Position{X: 0, Y: 200}},
+		},
+		Edges: []document.Edge{{ID: "e1", Source: "a", Target: "b", Label: "next"}},
+	}
+	svg := SVG(Build(content, nil, Options{Padding: 20, Background: color.NRGBA{R: 255, G: 255, B: 255, A: 255}}))
+
+	for _, want := range []string{"<svg", "Input &lt;data&gt;", "Valid?", "next"} {
+		if !bytes.Contains(svg, []byte(want)) {
+			t.Errorf("SVG does not contain %q", want)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    color.NRGBA
+		wantErr bool
+	}{
+		{in: "#fff", want: color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+		{in: "#11223344", want: color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x44}},
+		{in: "#102030", want: color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 255}},
+		{in: " RGB(1, 2, 3) ", want: color.NRGBA{R: 1, G: 2, B: 3, A: 255}},
+		{in: "transparent", want: None},
+		{in: "#12345", wantErr: true},
+		{in: "rgb(1, 2)", wantErr: true},
+		{in: "rgb(300, 0, 0)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseColor(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseColor(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package render
+
+import "math"
+
+// shapeOutline returns the closed outline of a node shape and, for shapes
+// with inner strokes (cylinder rim, note fold, class divider, ...), an
+// unfilled detail path. It ports getShapePath from frontend/src/lib/utils/shapes.ts;
+// unknown types are drawn as rectangles, like the frontend default.
+func shapeOutline(kind string, r Rect) (outline, details Path) {
+	x, y, w, h := r.X, r.Y, r.W, r.H
+	cx, cy := x+w/2, y+h/2
+	pt := func(fx, fy float64) Point { return Point{x + fx*w, y + fy*h} }
+
+	switch kind {
+	case "process", "rectangle", "entity":
+		return rectPath(r, 6), nil
+
+	case "rounded", "start-end", "terminator":
+		return rectPath(r, math.Min(w, h)/2), nil
+
+	case "decision", "diamond", "relationship", "gateway":
+		return polygon(pt(0.5, 0), pt(1, 0.5), pt(0.5, 1), pt(0, 0.5)), nil
+
+	case "triangle":
+		return polygon(pt(0.5, 0), pt(1, 1), pt(0, 1)), nil
+
+	case "circle", "ellipse", "start-event", "intermediate-event", "end-event",
+		"attribute", "connector", "interface", "usecase":
+		return ellipsePath(Point{cx, cy}, w/2, h/2), nil
+
+	case "star":
+		pts := make([]Point, 0, 10)
+		for i := range 10 {
+			angle := float64(i)*math.Pi/5 - math.Pi/2
+			radius := w / 4
+			if i%2 == 0 {
+				radius = w / 2
+			}
+			pts = append(pts, Point{cx + radius*math.Cos(angle), cy + radius*math.Sin(angle)})
+		}
+		return polygon(pts...), nil
+
+	case "hexagon", "preparation":
+		return polygon(pt(0.25, 0), pt(0.75, 0), pt(1, 0.5), pt(0.75, 1), pt(0.25, 1), pt(0, 0.5)), nil
+
+	case "octagon":
+		o := math.Min(w, h) * 0.3
+		return polygon(
+			Point{x + o, y}, Point{x + w - o, y}, Point{x + w, y + o}, Point{x + w, y + h - o},
+			Point{x + w - o, y + h}, Point{x + o, y + h}, Point{x, y + h - o}, Point{x, y + o},
+		), nil
+
+	case "parallelogram", "input-output":
+		return polygon(pt(0.2, 0), pt(1, 0), pt(0.8, 1), pt(0, 1)), nil
+
+	case "trapezoid", "manual-operation":
+		return polygon(pt(0, 0), pt(1, 0), pt(0.8, 1), pt(0.2, 1)), nil
+
+	case "cloud":
+		var p Path
+		p.MoveTo(x+w*0.25, y+h*0.5)
+		p.quadTo(pt(0.25, 0.5), pt(0.1, 0.2), pt(0.4, 0.3))
+		p.quadTo(pt(0.4, 0.3), pt(0.5, 0.05), pt(0.7, 0.3))
+		p.quadTo(pt(0.7, 0.3), pt(0.9, 0.2), pt(0.95, 0.5))
+		p.quadTo(pt(0.95, 0.5), pt(1, 0.8), pt(0.8, 0.9))
+		p.quadTo(pt(0.8, 0.9), pt(0.6, 1), pt(0.4, 0.9))
+		p.quadTo(pt(0.4, 0.9), pt(0.1, 0.9), pt(0.05, 0.6))
+		p.quadTo(pt(0.05, 0.6), pt(0, 0.5), pt(0.25, 0.5))
+		p.Close()
+		return p, nil
+
+	case "note":
+		fold := math.Min(w, h) * 0.2
+		return polygon(Point{x, y}, Point{x + w - fold, y}, Point{x + w, y + fold}, Point{x + w, y + h}, Point{x, y + h}),
+			polyline(Point{x + w - fold, y}, Point{x + w - fold, y + fold}, Point{x + w, y + fold})
+
+	case "callout":
+		return polygon(pt(0, 0), pt(1, 0), pt(1, 0.7), pt(0.4, 0.7), pt(0.2, 1), pt(0.3, 0.7), pt(0, 0.7)), nil
+
+	case "cross":
+		c := math.Min(w, h) * 0.25
+		return polygon(
+			Point{x + c, y}, Point{x + w - c, y}, Point{x + w - c, y + c}, Point{x + w, y + c},
+			Point{x + w, y + h - c}, Point{x + w - c, y + h - c}, Point{x + w - c, y + h}, Point{x + c, y + h},
+			Point{x + c, y + h - c}, Point{x, y + h - c}, Point{x, y + c}, Point{x + c, y + c},
+		), nil
+
+	case "cylinder", "database":
+		dy := h * 0.15
+		var p Path
+		p.MoveTo(x, y+dy)
+		p.halfEllipse(Point{cx, y + dy}, w/2, dy, false, true)
+		p.LineTo(x+w, y+h-dy)
+		p.halfEllipse(Point{cx, y + h - dy}, w/2, dy, true, false)
+		p.Close()
+
+		var rim Path
+		rim.MoveTo(x, y+dy)
+		rim.halfEllipse(Point{cx, y + dy}, w/2, dy, true, true)
+		return p, rim
+
+	case "manual-input":
+		return polygon(pt(0, 0.2), pt(1, 0), pt(1, 1), pt(0, 1)), nil
+
+	case "delay":
+		ex, ey, rx, ry := x+w*0.8, cy, w*0.2, h/2
+		var p Path
+		p.MoveTo(x, y)
+		p.LineTo(ex, y)
+		p.CubicTo(ex+rx*kappa, y, ex+rx, ey-ry*kappa, ex+rx, ey)
+		p.CubicTo(ex+rx, ey+ry*kappa, ex+rx*kappa, y+h, ex, y+h)
+		p.LineTo(x, y+h)
+		p.Close()
+		return p, nil
+
+	case "display":
+		return polygon(pt(0, 0.5), pt(0.2, 0), pt(0.8, 0), pt(1, 0.5), pt(0.8, 1), pt(0.2, 1)), nil
+
+	case "internal-storage":
+		return polygon(pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 1)),
+			append(polyline(pt(0.15, 0), pt(0.15, 1)), polyline(pt(0.15, 0.15), pt(1, 0.15))...)
+
+	case "card":
+		return polygon(pt(0, 0.2), pt(0.2, 0), pt(1, 0), pt(1, 1), pt(0, 1)), nil
+
+	case "collate":
+		return polygon(pt(0, 0), pt(1, 1), pt(0, 1), pt(1, 0)), nil
+
+	case "off-page":
+		return polygon(pt(0, 0), pt(1, 0), pt(1, 0.8), pt(0.5, 1), pt(0, 0.8)), nil
+
+	case "document", "multi-document":
+		var p Path
+		p.MoveTo(x, y)
+		p.LineTo(x+w, y)
+		p.LineTo(x+w, y+h*0.85)
+		p.quadTo(pt(1, 0.85), pt(0.75, 1), pt(0.5, 0.85))
+		p.quadTo(pt(0.5, 0.85), pt(0.25, 0.7), pt(0, 0.85))
+		p.Close()
+		return p, nil
+
+	case "class":
+		return polygon(pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 1)), polyline(pt(0, 0.25), pt(1, 0.25))
+
+	case "package":
+		return polygon(pt(0, 0), pt(0.4, 0), pt(0.4, 0.15), pt(1, 0.15), pt(1, 1), pt(0, 1)),
+			polyline(pt(0, 0.15), pt(0.4, 0.15))
+
+	case "weak-entity":
+		const gap = 4
+		return polygon(pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 1)),
+			polygon(Point{x + gap, y + gap}, Point{x + w - gap, y + gap}, Point{x + w - gap, y + h - gap}, Point{x + gap, y + h - gap})
+
+	case "server":
+		return polygon(pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 1)),
+			append(append(polyline(pt(0.1, 0.2), pt(0.9, 0.2)), polyline(pt(0.1, 0.5), pt(0.9, 0.5))...), polyline(pt(0.1, 0.8), pt(0.9, 0.8))...)
+
+	case "cube":
+		d := w * 0.25
+		return polygon(Point{x, y + d}, Point{x + d, y}, Point{x + w, y}, Point{x + w, y + h - d}, Point{x + w - d, y + h}, Point{x, y + h}),
+			append(polyline(Point{x, y + d}, Point{x + w - d, y + d}, Point{x + w - d, y + h}), polyline(Point{x + w - d, y + d}, Point{x + w, y})...)
+	}
+
+	return polygon(pt(0, 0), pt(1, 0), pt(1, 1), pt(0, 1)), nil
+}
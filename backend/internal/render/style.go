@@ -0,0 +1,209 @@
+package render
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// nodeStyle is the resolved look of a node: its color theme plus the
+// per-node overrides from view.styles (frontend Node.style).
+type nodeStyle struct {
+	theme       string
+	fill        *color.NRGBA
+	stroke      *color.NRGBA
+	strokeWidth *float64
+	dash        []float64
+	opacity     float64
+	textColor   *color.NRGBA
+	fontSize    float64
+	fontFamily  string
+	bold        bool
+}
+
+// resolveNodeStyle merges node.color with view.styles[node.ID].
+// The frontend stores node.color under styles.color, so a theme name there
+// selects the theme; any other color value is the label color.
+func resolveNodeStyle(n *document.Node, view *document.DocumentView) nodeStyle {
+	st := nodeStyle{theme: n.Color, opacity: 1, fontSize: 14, fontFamily: "sans-serif"}
+
+	overrides := view.Styles[n.ID]
+	if c, ok := styleString(overrides, "color"); ok {
+		if _, isTheme := themes[c]; isTheme {
+			st.theme = c
+		} else if parsed, err := ParseColor(c); err == nil {
+			st.textColor = &parsed
+		}
+	}
+	if c, ok := styleColor(overrides, "fill"); ok {
+		st.fill = &c
+	}
+	if c, ok := styleColor(overrides, "stroke"); ok {
+		st.stroke = &c
+	}
+	if v, ok := styleNumber(overrides, "strokeWidth"); ok && v >= 0 {
+		st.strokeWidth = &v
+	}
+	if s, ok := styleString(overrides, "strokeDasharray"); ok {
+		st.dash = parseDash(s)
+	}
+	if v, ok := styleNumber(overrides, "opacity"); ok && v >= 0 && v <= 1 {
+		st.opacity = v
+	}
+	if v, ok := styleNumber(overrides, "fontSize"); ok && v > 0 {
+		st.fontSize = v
+	}
+	if s, ok := styleString(overrides, "fontFamily"); ok && s != "" {
+		st.fontFamily = s
+	}
+	if v, ok := styleNumber(overrides, "fontWeight"); ok {
+		st.bold = v >= 600
+	} else if s, ok := styleString(overrides, "fontWeight"); ok {
+		st.bold = s == "bold" || s == "bolder"
+	}
+	return st
+}
+
+// palette returns the theme colors, falling back to def for unknown names.
+func (st nodeStyle) palette(def string) theme {
+	if t, ok := themes[st.theme]; ok {
+		return t
+	}
+	return themes[def]
+}
+
+// paint returns the shape style: theme colors and the component's stroke width,
+// with the fill/stroke/strokeWidth overrides applied.
+func (st nodeStyle) paint(t theme, strokeWidth float64) Style {
+	fill, stroke := t.Fill, t.Stroke
+	if st.fill != nil {
+		fill = *st.fill
+	}
+	if st.stroke != nil {
+		stroke = *st.stroke
+	}
+	if st.strokeWidth != nil {
+		strokeWidth = *st.strokeWidth
+	}
+	return Style{
+		Fill:        withOpacity(fill, st.opacity),
+		Stroke:      withOpacity(stroke, st.opacity),
+		StrokeWidth: strokeWidth,
+		Dash:        st.dash,
+	}
+}
+
+// text returns the label color: the override, or the theme default.
+func (st nodeStyle) text(t theme) color.NRGBA {
+	c := t.Text
+	if st.textColor != nil {
+		c = *st.textColor
+	}
+	return withOpacity(c, st.opacity)
+}
+
+// edgeRouting is view.routing[edgeID] (frontend Edge waypoints/style/markers).
+type edgeRouting struct {
+	Waypoints   []Point
+	Stroke      *color.NRGBA
+	StrokeWidth float64
+	Dash        []float64
+	Animated    bool
+	MarkerStart string
+	MarkerEnd   string
+}
+
+// edgeRoutingFor decodes the loosely typed routing entry of an edge.
+func edgeRoutingFor(view *document.DocumentView, edgeID string) edgeRouting {
+	r := edgeRouting{StrokeWidth: 2, MarkerStart: "none", MarkerEnd: "arrow"}
+
+	raw, ok := view.Routing[edgeID].(map[string]interface{})
+	if !ok {
+		return r
+	}
+	if wps, ok := raw["waypoints"].([]interface{}); ok {
+		for _, wp := range wps {
+			m, ok := wp.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			x, okX := styleNumber(m, "x")
+			y, okY := styleNumber(m, "y")
+			if okX && okY {
+				r.Waypoints = append(r.Waypoints, Point{X: x, Y: y})
+			}
+		}
+	}
+	if animated, ok := raw["animated"].(bool); ok {
+		r.Animated = animated
+	}
+	if s, ok := styleString(raw, "markerStart"); ok && s != "" {
+		r.MarkerStart = s
+	}
+	if s, ok := styleString(raw, "markerEnd"); ok && s != "" {
+		r.MarkerEnd = s
+	}
+	if style, ok := raw["style"].(map[string]interface{}); ok {
+		if c, ok := styleColor(style, "stroke"); ok {
+			r.Stroke = &c
+		}
+		if v, ok := styleNumber(style, "strokeWidth"); ok && v >= 0 {
+			r.StrokeWidth = v
+		}
+		if s, ok := styleString(style, "strokeDasharray"); ok {
+			r.Dash = parseDash(s)
+		}
+	}
+	if r.Dash == nil && r.Animated {
+		r.Dash = []float64{5, 5}
+	}
+	return r
+}
+
+func styleString(m map[string]interface{}, key string) (string, bool) {
+	s, ok := m[key].(string)
+	return strings.TrimSpace(s), ok
+}
+
+// styleNumber accepts JSON numbers and numeric strings such as "2" or "14px".
+func styleNumber(m map[string]interface{}, key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "px"), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func styleColor(m map[string]interface{}, key string) (color.NRGBA, bool) {
+	s, ok := styleString(m, key)
+	if !ok || s == "" {
+		return None, false
+	}
+	c, err := ParseColor(s)
+	return c, err == nil
+}
+
+// parseDash parses an SVG stroke-dasharray ("5,5", "8 8", "none").
+func parseDash(s string) []float64 {
+	var dash []float64
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || v < 0 {
+			return nil
+		}
+		dash = append(dash, v)
+	}
+	for _, v := range dash {
+		if v > 0 {
+			return dash
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// SVG encodes the scene as a standalone SVG document.
+// The viewBox is the scene bounds, so the image is sized 1:1 in diagram units.
+func SVG(scene *Scene) []byte {
+	var b bytes.Buffer
+	bounds := scene.Bounds
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg"`)
+	attr(&b, "width", num(bounds.W))
+	attr(&b, "height", num(bounds.H))
+	attr(&b, "viewBox", strings.Join([]string{num(bounds.X), num(bounds.Y), num(bounds.W), num(bounds.H)}, " "))
+	b.WriteString(">\n")
+
+	if scene.Background.A > 0 {
+		b.WriteString("<rect")
+		attr(&b, "x", num(bounds.X))
+		attr(&b, "y", num(bounds.Y))
+		attr(&b, "width", num(bounds.W))
+		attr(&b, "height", num(bounds.H))
+		paintAttrs(&b, "fill", scene.Background)
+		b.WriteString("/>\n")
+	}
+
+	for _, el := range scene.Elements {
+		switch {
+		case el.Text != nil:
+			writeSVGText(&b, el.Text)
+		case len(el.Path) > 0:
+			writeSVGPath(&b, el.Path, el.Style)
+		}
+	}
+
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+func writeSVGPath(b *bytes.Buffer, p Path, st Style) {
+	b.WriteString("<path")
+	attr(b, "d", pathData(p))
+	paintAttrs(b, "fill", st.Fill)
+	if st.Stroke.A > 0 && st.StrokeWidth > 0 {
+		paintAttrs(b, "stroke", st.Stroke)
+		attr(b, "stroke-width", num(st.StrokeWidth))
+		attr(b, "stroke-linejoin", "round")
+		if len(st.Dash) > 0 {
+			dash := make([]string, len(st.Dash))
+			for i, d := range st.Dash {
+				dash[i] = num(d)
+			}
+			attr(b, "stroke-dasharray", strings.Join(dash, ","))
+		}
+	}
+	b.WriteString("/>\n")
+}
+
+func writeSVGText(b *bytes.Buffer, t *Text) {
+	if t.Color.A == 0 || len(t.Lines) == 0 {
+		return
+	}
+	b.WriteString("<text")
+	attr(b, "x", num(t.X))
+	attr(b, "y", num(t.Y))
+	if t.Anchor == AnchorMiddle {
+		attr(b, "text-anchor", "middle")
+	}
+	attr(b, "font-family", t.Family)
+	attr(b, "font-size", num(t.Size))
+	if t.Bold {
+		attr(b, "font-weight", "bold")
+	}
+	if t.Italic {
+		attr(b, "font-style", "italic")
+	}
+	paintAttrs(b, "fill", t.Color)
+	b.WriteString(">")
+
+	if len(t.Lines) == 1 {
+		xml.EscapeText(b, []byte(t.Lines[0]))
+	} else {
+		for i, line := range t.Lines {
+			b.WriteString("<tspan")
+			attr(b, "x", num(t.X))
+			if i > 0 {
+				attr(b, "dy", num(t.LineHeight))
+			}
+			b.WriteString(">")
+			xml.EscapeText(b, []byte(line))
+			b.WriteString("</tspan>")
+		}
+	}
+	b.WriteString("</text>\n")
+}
+
+// pathData formats a path as SVG path data.
+func pathData(p Path) string {
+	var d strings.Builder
+	for _, s := range p {
+		if d.Len() > 0 {
+			d.WriteByte(' ')
+		}
+		switch s.Op {
+		case OpMoveTo:
+			d.WriteString("M" + num(s.Pts[0].X) + " " + num(s.Pts[0].Y))
+		case OpLineTo:
+			d.WriteString("L" + num(s.Pts[0].X) + " " + num(s.Pts[0].Y))
+		case OpCubicTo:
+			d.WriteString("C" + num(s.Pts[0].X) + " " + num(s.Pts[0].Y) + " " +
+				num(s.Pts[1].X) + " " + num(s.Pts[1].Y) + " " +
+				num(s.Pts[2].X) + " " + num(s.Pts[2].Y))
+		case OpClose:
+			d.WriteString("Z")
+		}
+	}
+	return d.String()
+}
+
+// paintAttrs writes a fill or stroke color with its opacity when translucent.
+func paintAttrs(b *bytes.Buffer, name string, c color.NRGBA) {
+	value, opacity := cssColor(c)
+	attr(b, name, value)
+	if opacity < 1 {
+		attr(b, name+"-opacity", num(opacity))
+	}
+}
+
+func attr(b *bytes.Buffer, name, value string) {
+	b.WriteString(" " + name + `="`)
+	xml.EscapeText(b, []byte(value))
+	b.WriteString(`"`)
+}
+
+// num formats a coordinate with at most two decimals.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// avgCharWidth is the average advance of a sans-serif glyph relative to the font size.
+// Layout only needs an estimate; each backend draws the text with its own font.
+const avgCharWidth = 0.55
+
+// TextWidth estimates the rendered width of s at the given font size.
+func TextWidth(s string, size float64) float64 {
+	return float64(utf8.RuneCountInString(s)) * size * avgCharWidth
+}
+
+// wrapText breaks s into lines no wider than maxWidth, splitting on spaces
+// (explicit newlines are kept). Words longer than a line are not broken.
+func wrapText(s string, size, maxWidth float64) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, w := range words[1:] {
+			if TextWidth(line+" "+w, size) > maxWidth {
+				lines = append(lines, line)
+				line = w
+				continue
+			}
+			line += " " + w
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// firstBaseline returns the baseline of the first of n lines vertically centered on cy.
+func firstBaseline(cy float64, n int, size, lineHeight float64) float64 {
+	// 0.35em puts the middle of lowercase/cap glyphs on the center line.
+	return cy - float64(n-1)*lineHeight/2 + size*0.35
+}
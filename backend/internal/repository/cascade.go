@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// CascadeCounts is the number of child records removed along with a
+// workspace, project or document.
+type CascadeCounts struct {
+	Members     int
+	Invites     int
+	InviteLinks int
+	Projects    int
+	Documents   int
+	Versions    int
+	Grants      int
+	Shares      int
+}
+
+// deleteDocuments removes the documents matching filter together with their
+// version history, grants and share links. Must run inside runInTx.
+func deleteDocuments(ctx context.Context, db *mongo.Database, filter bson.M, counts *CascadeCounts) error {
+	cursor, err := db.Collection("documents").Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		ID uuid.UUID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	byDocument := bson.M{"document_id": bson.M{"$in": ids}}
+
+	if counts.Versions, err = deleteMany(ctx, db.Collection("document_versions"), byDocument); err != nil {
+		return err
+	}
+	if counts.Grants, err = deleteMany(ctx, db.Collection("document_grants"), byDocument); err != nil {
+		return err
+	}
+	if counts.Shares, err = deleteMany(ctx, db.Collection("document_shares"), bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return err
+	}
+	counts.Documents, err = deleteMany(ctx, db.Collection("documents"), bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+func deleteMany(ctx context.Context, col *mongo.Collection, filter bson.M) (int, error) {
+	res, err := col.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
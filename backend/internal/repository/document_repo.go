@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,27 +15,36 @@ import (
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
 )
 
-// DocumentRepo handles documents collection operations.
+// DocumentRepo handles documents, document_versions, document_grants and
+// document_shares collection operations.
 type DocumentRepo struct {
-	col       *mongo.Collection
-	wsCol     *mongo.Collection
-	projCol   *mongo.Collection
-	memberCol *mongo.Collection
+	db         *mongo.Database
+	col        *mongo.Collection
+	versionCol *mongo.Collection
+	grantCol   *mongo.Collection
+	shareCol   *mongo.Collection
+	wsCol      *mongo.Collection
+	projCol    *mongo.Collection
+	memberCol  *mongo.Collection
 }
 
 // NewDocumentRepo creates a new DocumentRepo.
 func NewDocumentRepo(db *mongo.Database) *DocumentRepo {
 	return &DocumentRepo{
-		col:       db.Collection("documents"),
-		wsCol:     db.Collection("workspaces"),
-		projCol:   db.Collection("projects"),
-		memberCol: db.Collection("workspace_members"),
+		db:         db,
+		col:        db.Collection("documents"),
+		versionCol: db.Collection("document_versions"),
+		grantCol:   db.Collection("document_grants"),
+		shareCol:   db.Collection("document_shares"),
+		wsCol:      db.Collection("workspaces"),
+		projCol:    db.Collection("projects"),
+		memberCol:  db.Collection("workspace_members"),
 	}
 }
 
-// FindByProject returns paginated documents in a project.
+// FindByProject returns paginated documents in a project, excluding trashed ones.
 func (r *DocumentRepo) FindByProject(ctx context.Context, projectID uuid.UUID, limit, offset int, diagramType, sortBy, sortOrder string) ([]model.Document, int, *pkg.AppError) {
-	filter := bson.M{"project_id": projectID}
+	filter := bson.M{"project_id": projectID, "deleted_at": nil}
 
 	if diagramType != "" {
 		filter["diagram_type"] = diagramType
@@ -79,10 +90,10 @@ func (r *DocumentRepo) FindByProject(ctx context.Context, projectID uuid.UUID, l
 	return docs, int(total), nil
 }
 
-// FindByID returns a document by ID (full content/view).
+// FindByID returns a document by ID (full content/view). Trashed documents are not found.
 func (r *DocumentRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Document, *pkg.AppError) {
 	doc := new(model.Document)
-	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(doc)
+	err := r.col.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(doc)
 	if appErr := handleMongoError(err, "document"); appErr != nil {
 		return nil, appErr
 	}
@@ -98,27 +109,322 @@ func (r *DocumentRepo) Insert(ctx context.Context, doc *model.Document) *pkg.App
 	return nil
 }
 
-// Update updates document fields.
-func (r *DocumentRepo) Update(ctx context.Context, doc *model.Document) *pkg.AppError {
-	filter := bson.M{"_id": doc.ID}
+// errVersionConflict aborts a compare-and-swap transaction when the stored version moved on.
+var errVersionConflict = errors.New("document version conflict")
+
+// Update writes document fields as a compare-and-swap: the write only applies if the
+// stored version still equals expectedVersion. Returns ErrConflict otherwise, or
+// ErrNotFound if the document was moved to the trash meanwhile.
+func (r *DocumentRepo) Update(ctx context.Context, doc *model.Document, expectedVersion int) *pkg.AppError {
+	filter := bson.M{"_id": doc.ID, "version": expectedVersion, "deleted_at": nil}
 	update := bson.M{"$set": doc}
-	_, err := r.col.UpdateOne(ctx, filter, update)
+	res, err := r.col.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return pkg.ErrInternal.WithMessage("failed to update document").WithDetails(err.Error())
 	}
+	if res.MatchedCount == 0 {
+		return r.versionConflict(ctx, doc.ID)
+	}
 	return nil
 }
 
-// Delete removes a document by ID.
+// UpdateWithSnapshot archives the superseded version and updates the document in a single transaction.
+// The document write is a compare-and-swap on the snapshot's version, like Update.
+func (r *DocumentRepo) UpdateWithSnapshot(ctx context.Context, doc *model.Document, snapshot *model.DocumentVersion) *pkg.AppError {
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		res, err := r.col.UpdateOne(sessCtx, bson.M{"_id": doc.ID, "version": snapshot.Version, "deleted_at": nil}, bson.M{"$set": doc})
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errVersionConflict
+		}
+		if _, err := r.versionCol.InsertOne(sessCtx, snapshot); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errVersionConflict) || mongo.IsDuplicateKeyError(err) {
+		return r.versionConflict(ctx, doc.ID)
+	}
+	if err != nil {
+		log.Printf("[DocumentRepo.UpdateWithSnapshot] TX error: %v", err)
+		return pkg.ErrInternal.WithMessage("failed to update document").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// versionConflict builds the ErrConflict returned by a failed compare-and-swap,
+// carrying the version currently stored on the server. A document in the trash
+// is reported as not found.
+func (r *DocumentRepo) versionConflict(ctx context.Context, id uuid.UUID) *pkg.AppError {
+	var current struct {
+		Version int `bson:"version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"version": 1})
+	err := r.col.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}, opts).Decode(&current)
+	if appErr := handleMongoError(err, "document"); appErr != nil {
+		return appErr
+	}
+	return pkg.ErrConflict.
+		WithMessage("document was modified by someone else").
+		WithDetails(map[string]int{"current_version": current.Version})
+}
+
+// errDocumentNotTrashed aborts a purge when the document left the trash.
+var errDocumentNotTrashed = errors.New("document not in trash")
+
+// Delete permanently removes a trashed document with its version history,
+// grants and share link in a single transaction. Returns ErrNotFound if the
+// document is not in the trash, e.g. because it was restored meanwhile.
 func (r *DocumentRepo) Delete(ctx context.Context, id uuid.UUID) *pkg.AppError {
-	_, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		counts := new(CascadeCounts)
+		if err := deleteDocuments(sessCtx, r.db, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}, counts); err != nil {
+			return nil, err
+		}
+		if counts.Documents == 0 {
+			return nil, errDocumentNotTrashed
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errDocumentNotTrashed) {
+		return pkg.ErrNotFound.WithMessage("document not found in trash")
+	}
+	if err != nil {
+		log.Printf("[DocumentRepo.Delete] TX error: %v", err)
+		return pkg.ErrInternal.WithMessage("failed to delete document").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// --- DocumentVersion operations ---
+
+// FindVersions returns paginated version snapshots of a document, newest first.
+// Content and view are omitted from the result.
+func (r *DocumentRepo) FindVersions(ctx context.Context, documentID uuid.UUID, limit, offset int) ([]model.DocumentVersion, int, *pkg.AppError) {
+	filter := bson.M{"document_id": documentID}
+
+	total, err := r.versionCol.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, pkg.ErrInternal.WithMessage("failed to list versions").WithDetails(err.Error())
+	}
+
+	opts := paginationOpts(limit, offset).
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"content": 0, "view": 0})
+
+	cursor, err := r.versionCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, pkg.ErrInternal.WithMessage("failed to list versions").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var versions []model.DocumentVersion
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, 0, pkg.ErrInternal.WithMessage("failed to decode versions").WithDetails(err.Error())
+	}
+
+	return versions, int(total), nil
+}
+
+// FindVersion returns a single version snapshot (full content/view).
+func (r *DocumentRepo) FindVersion(ctx context.Context, documentID uuid.UUID, version int) (*model.DocumentVersion, *pkg.AppError) {
+	v := new(model.DocumentVersion)
+	err := r.versionCol.FindOne(ctx, bson.M{"document_id": documentID, "version": version}).Decode(v)
+	if appErr := handleMongoError(err, "document version"); appErr != nil {
+		return nil, appErr
+	}
+	return v, nil
+}
+
+// --- DocumentGrant operations ---
+
+// FindGrants returns the per-document grants of a document, oldest first.
+func (r *DocumentRepo) FindGrants(ctx context.Context, documentID uuid.UUID) ([]model.DocumentGrant, *pkg.AppError) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.grantCol.Find(ctx, bson.M{"document_id": documentID}, opts)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to list grants").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var grants []model.DocumentGrant
+	if err := cursor.All(ctx, &grants); err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to decode grants").WithDetails(err.Error())
+	}
+	return grants, nil
+}
+
+// GetGrantRole returns the role granted to a user on a document, or empty string if none.
+func (r *DocumentRepo) GetGrantRole(ctx context.Context, documentID, userID uuid.UUID) (string, *pkg.AppError) {
+	grant := new(model.DocumentGrant)
+	filter := bson.M{"document_id": documentID, "user_id": userID}
+	err := r.grantCol.FindOne(ctx, filter).Decode(grant)
+	if appErr := handleMongoError(err, "grant"); appErr != nil {
+		if appErr.Code == "NOT_FOUND" {
+			return "", nil
+		}
+		return "", appErr
+	}
+	return grant.Role, nil
+}
+
+// UpsertGrant creates a grant or changes the role of an existing one.
+func (r *DocumentRepo) UpsertGrant(ctx context.Context, grant *model.DocumentGrant) *pkg.AppError {
+	filter := bson.M{"document_id": grant.DocumentID, "user_id": grant.UserID}
+	update := bson.M{
+		"$set": bson.M{"role": grant.Role, "granted_by": grant.GrantedBy},
+		"$setOnInsert": bson.M{
+			"document_id": grant.DocumentID,
+			"user_id":     grant.UserID,
+			"created_at":  grant.CreatedAt,
+		},
+	}
+	_, err := r.grantCol.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to save grant").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// DeleteGrant removes a user's grant on a document.
+func (r *DocumentRepo) DeleteGrant(ctx context.Context, documentID, userID uuid.UUID) *pkg.AppError {
+	res, err := r.grantCol.DeleteOne(ctx, bson.M{"document_id": documentID, "user_id": userID})
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to delete grant").WithDetails(err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("grant not found")
+	}
+	return nil
+}
+
+// --- DocumentShare operations ---
+
+// SetShare creates or replaces the public link of a document.
+func (r *DocumentRepo) SetShare(ctx context.Context, share *model.DocumentShare) *pkg.AppError {
+	_, err := r.shareCol.ReplaceOne(ctx, bson.M{"_id": share.DocumentID}, share, options.Replace().SetUpsert(true))
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to save share link").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// FindShare returns the public link of a document.
+func (r *DocumentRepo) FindShare(ctx context.Context, documentID uuid.UUID) (*model.DocumentShare, *pkg.AppError) {
+	share := new(model.DocumentShare)
+	err := r.shareCol.FindOne(ctx, bson.M{"_id": documentID}).Decode(share)
+	if appErr := handleMongoError(err, "share link"); appErr != nil {
+		return nil, appErr
+	}
+	return share, nil
+}
+
+// DeleteShare removes the public link of a document.
+func (r *DocumentRepo) DeleteShare(ctx context.Context, documentID uuid.UUID) *pkg.AppError {
+	res, err := r.shareCol.DeleteOne(ctx, bson.M{"_id": documentID})
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to delete share link").WithDetails(err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("document is not shared")
+	}
+	return nil
+}
+
+// FindByShareToken returns the document a public link points to.
+func (r *DocumentRepo) FindByShareToken(ctx context.Context, token string) (*model.Document, *pkg.AppError) {
+	share := new(model.DocumentShare)
+	err := r.shareCol.FindOne(ctx, bson.M{"token": token}).Decode(share)
+	if appErr := handleMongoError(err, "shared document"); appErr != nil {
+		return nil, appErr
+	}
+	return r.FindByID(ctx, share.DocumentID)
+}
+
+// --- Trash operations ---
+
+// Trash moves a document to the trash.
+func (r *DocumentRepo) Trash(ctx context.Context, id, deletedBy uuid.UUID, at time.Time) *pkg.AppError {
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{"deleted_at": at, "deleted_by": deletedBy}},
+	)
 	if err != nil {
 		return pkg.ErrInternal.WithMessage("failed to delete document").WithDetails(err.Error())
 	}
+	if res.MatchedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("document not found")
+	}
+	return nil
+}
+
+// FindTrashed returns a document in the trash by ID.
+func (r *DocumentRepo) FindTrashed(ctx context.Context, id uuid.UUID) (*model.Document, *pkg.AppError) {
+	doc := new(model.Document)
+	err := r.col.FindOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}).Decode(doc)
+	if appErr := handleMongoError(err, "trashed document"); appErr != nil {
+		return nil, appErr
+	}
+	return doc, nil
+}
+
+// FindTrashedByWorkspace returns the documents trashed on their own in a
+// workspace, most recently trashed first. Documents trashed with their
+// project are left out; they come back with it.
+func (r *DocumentRepo) FindTrashedByWorkspace(ctx context.Context, workspaceID uuid.UUID) ([]model.Document, *pkg.AppError) {
+	filter := bson.M{"workspace_id": workspaceID, "deleted_at": bson.M{"$ne": nil}, "trashed_with": nil}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}}).
+		SetProjection(bson.M{"content": 0, "view": 0})
+
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to list trash").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var docs []model.Document
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to decode documents").WithDetails(err.Error())
+	}
+	return docs, nil
+}
+
+// Restore takes a document out of the trash.
+func (r *DocumentRepo) Restore(ctx context.Context, id uuid.UUID) *pkg.AppError {
+	res, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": "", "trashed_with": ""}},
+	)
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to restore document").WithDetails(err.Error())
+	}
+	if res.MatchedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("trashed document not found")
+	}
 	return nil
 }
 
-// FindRecent returns the N most recently updated documents across workspaces the user belongs to.
+// DeleteExpired permanently removes the documents trashed on their own
+// before the cutoff, in a single transaction.
+func (r *DocumentRepo) DeleteExpired(ctx context.Context, before time.Time) (*CascadeCounts, *pkg.AppError) {
+	counts := new(CascadeCounts)
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		*counts = CascadeCounts{}
+		filter := bson.M{"deleted_at": bson.M{"$lt": before}, "trashed_with": nil}
+		return nil, deleteDocuments(sessCtx, r.db, filter, counts)
+	})
+	if err != nil {
+		log.Printf("[DocumentRepo.DeleteExpired] TX error: %v", err)
+		return nil, pkg.ErrInternal.WithMessage("failed to purge documents").WithDetails(err.Error())
+	}
+	return counts, nil
+}
+
+// FindRecent returns the N most recently updated documents across workspaces the user belongs to,
+// excluding trashed ones.
 // Replaces the SQL JOIN query with a multi-step approach.
 func (r *DocumentRepo) FindRecent(ctx context.Context, userID uuid.UUID, limit int) ([]RecentDocumentRow, *pkg.AppError) {
 	// Step 1: Get workspace IDs for this user
@@ -142,7 +448,7 @@ func (r *DocumentRepo) FindRecent(ctx context.Context, userID uuid.UUID, limit i
 	}
 
 	// Step 2: Fetch recent documents from those workspaces
-	docFilter := bson.M{"workspace_id": bson.M{"$in": wsIDs}}
+	docFilter := bson.M{"workspace_id": bson.M{"$in": wsIDs}, "deleted_at": nil}
 	docOpts := options.Find().
 		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
 		SetLimit(int64(limit))
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -14,6 +17,7 @@ import (
 
 // ProjectRepo handles projects collection operations.
 type ProjectRepo struct {
+	db     *mongo.Database
 	col    *mongo.Collection
 	docCol *mongo.Collection
 }
@@ -21,14 +25,15 @@ type ProjectRepo struct {
 // NewProjectRepo creates a new ProjectRepo.
 func NewProjectRepo(db *mongo.Database) *ProjectRepo {
 	return &ProjectRepo{
+		db:     db,
 		col:    db.Collection("projects"),
 		docCol: db.Collection("documents"),
 	}
 }
 
-// FindByWorkspace returns paginated projects in a workspace.
+// FindByWorkspace returns paginated projects in a workspace, excluding trashed ones.
 func (r *ProjectRepo) FindByWorkspace(ctx context.Context, workspaceID uuid.UUID, limit, offset int) ([]model.Project, int, *pkg.AppError) {
-	filter := bson.M{"workspace_id": workspaceID}
+	filter := bson.M{"workspace_id": workspaceID, "deleted_at": nil}
 
 	// Count total
 	total, err := r.col.CountDocuments(ctx, filter)
@@ -56,10 +61,10 @@ func (r *ProjectRepo) FindByWorkspace(ctx context.Context, workspaceID uuid.UUID
 	return projects, int(total), nil
 }
 
-// FindByID returns a project by ID.
+// FindByID returns a project by ID. Trashed projects are not found.
 func (r *ProjectRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Project, *pkg.AppError) {
 	proj := new(model.Project)
-	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(proj)
+	err := r.col.FindOne(ctx, bson.M{"_id": id, "deleted_at": nil}).Decode(proj)
 	if appErr := handleMongoError(err, "project"); appErr != nil {
 		return nil, appErr
 	}
@@ -86,20 +91,202 @@ func (r *ProjectRepo) Update(ctx context.Context, proj *model.Project) *pkg.AppE
 	return nil
 }
 
-// Delete removes a project by ID.
-func (r *ProjectRepo) Delete(ctx context.Context, id uuid.UUID) *pkg.AppError {
-	_, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+// Delete permanently removes a trashed project with the documents trashed
+// with it in a single transaction. Returns ErrNotFound if the project is not in the
+// trash, e.g. because it was restored meanwhile.
+func (r *ProjectRepo) Delete(ctx context.Context, id uuid.UUID) (*CascadeCounts, *pkg.AppError) {
+	counts := new(CascadeCounts)
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		*counts = CascadeCounts{}
+		res, err := r.col.DeleteOne(sessCtx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}})
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, errProjectNotFound
+		}
+		return nil, r.purgeDocuments(sessCtx, []uuid.UUID{id}, counts)
+	})
+	if errors.Is(err, errProjectNotFound) {
+		return nil, pkg.ErrNotFound.WithMessage("project not found in trash")
+	}
 	if err != nil {
-		return pkg.ErrInternal.WithMessage("failed to delete project").WithDetails(err.Error())
+		log.Printf("[ProjectRepo.Delete] TX error: %v", err)
+		return nil, pkg.ErrInternal.WithMessage("failed to delete project").WithDetails(err.Error())
 	}
-	return nil
+	return counts, nil
 }
 
-// CountDocuments returns the number of documents in a project.
+// CountDocuments returns the number of documents in a project, excluding trashed ones.
 func (r *ProjectRepo) CountDocuments(ctx context.Context, projectID uuid.UUID) (int, *pkg.AppError) {
-	count, err := r.docCol.CountDocuments(ctx, bson.M{"project_id": projectID})
+	count, err := r.docCol.CountDocuments(ctx, bson.M{"project_id": projectID, "deleted_at": nil})
 	if err != nil {
 		return 0, pkg.ErrInternal.WithMessage("failed to count documents").WithDetails(err.Error())
 	}
 	return int(count), nil
 }
+
+// --- Trash operations ---
+
+// errProjectNotFound aborts a trash transaction when the project is missing.
+var errProjectNotFound = errors.New("project not found")
+
+// Trash moves a project and its documents to the trash in a single
+// transaction and returns how many documents went with it. Documents that
+// were already in the trash keep their own tombstone.
+func (r *ProjectRepo) Trash(ctx context.Context, id, deletedBy uuid.UUID, at time.Time) (int, *pkg.AppError) {
+	var trashed int
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		tombstone := bson.M{"deleted_at": at, "deleted_by": deletedBy}
+		res, err := r.col.UpdateOne(sessCtx, bson.M{"_id": id, "deleted_at": nil}, bson.M{"$set": tombstone})
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errProjectNotFound
+		}
+
+		tombstone["trashed_with"] = id
+		docs, err := r.docCol.UpdateMany(sessCtx, bson.M{"project_id": id, "deleted_at": nil}, bson.M{"$set": tombstone})
+		if err != nil {
+			return nil, err
+		}
+		trashed = int(docs.ModifiedCount)
+		return nil, nil
+	})
+	if errors.Is(err, errProjectNotFound) {
+		return 0, pkg.ErrNotFound.WithMessage("project not found")
+	}
+	if err != nil {
+		log.Printf("[ProjectRepo.Trash] TX error: %v", err)
+		return 0, pkg.ErrInternal.WithMessage("failed to delete project").WithDetails(err.Error())
+	}
+	return trashed, nil
+}
+
+// FindTrashed returns a project in the trash by ID.
+func (r *ProjectRepo) FindTrashed(ctx context.Context, id uuid.UUID) (*model.Project, *pkg.AppError) {
+	proj := new(model.Project)
+	err := r.col.FindOne(ctx, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}).Decode(proj)
+	if appErr := handleMongoError(err, "trashed project"); appErr != nil {
+		return nil, appErr
+	}
+	return proj, nil
+}
+
+// FindTrashedByWorkspace returns the trashed projects of a workspace, most
+// recently trashed first.
+func (r *ProjectRepo) FindTrashedByWorkspace(ctx context.Context, workspaceID uuid.UUID) ([]model.Project, *pkg.AppError) {
+	filter := bson.M{"workspace_id": workspaceID, "deleted_at": bson.M{"$ne": nil}}
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to list trash").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var projects []model.Project
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to decode projects").WithDetails(err.Error())
+	}
+	return projects, nil
+}
+
+// CountTrashedDocuments returns the number of documents trashed together with a project.
+func (r *ProjectRepo) CountTrashedDocuments(ctx context.Context, projectID uuid.UUID) (int, *pkg.AppError) {
+	count, err := r.docCol.CountDocuments(ctx, bson.M{"trashed_with": projectID})
+	if err != nil {
+		return 0, pkg.ErrInternal.WithMessage("failed to count documents").WithDetails(err.Error())
+	}
+	return int(count), nil
+}
+
+// Restore takes a project and the documents trashed with it out of the
+// trash in a single transaction and returns how many documents came back.
+func (r *ProjectRepo) Restore(ctx context.Context, id uuid.UUID) (int, *pkg.AppError) {
+	var restored int
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		res, err := r.col.UpdateOne(sessCtx,
+			bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}},
+			bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errProjectNotFound
+		}
+
+		docs, err := r.docCol.UpdateMany(sessCtx,
+			bson.M{"trashed_with": id},
+			bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": "", "trashed_with": ""}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		restored = int(docs.ModifiedCount)
+		return nil, nil
+	})
+	if errors.Is(err, errProjectNotFound) {
+		return 0, pkg.ErrNotFound.WithMessage("trashed project not found")
+	}
+	if err != nil {
+		log.Printf("[ProjectRepo.Restore] TX error: %v", err)
+		return 0, pkg.ErrInternal.WithMessage("failed to restore project").WithDetails(err.Error())
+	}
+	return restored, nil
+}
+
+// DeleteExpired permanently removes the projects trashed before the cutoff,
+// with the documents trashed with them, in a single transaction.
+func (r *ProjectRepo) DeleteExpired(ctx context.Context, before time.Time) (*CascadeCounts, *pkg.AppError) {
+	counts := new(CascadeCounts)
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		*counts = CascadeCounts{}
+		filter := bson.M{"deleted_at": bson.M{"$lt": before}}
+		cursor, err := r.col.Find(sessCtx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return nil, err
+		}
+		var rows []struct {
+			ID uuid.UUID `bson:"_id"`
+		}
+		if err := cursor.All(sessCtx, &rows); err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, nil
+		}
+
+		ids := make([]uuid.UUID, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		if err := r.purgeDocuments(sessCtx, ids, counts); err != nil {
+			return nil, err
+		}
+		counts.Projects, err = deleteMany(sessCtx, r.col, bson.M{"_id": bson.M{"$in": ids}})
+		return nil, err
+	})
+	if err != nil {
+		log.Printf("[ProjectRepo.DeleteExpired] TX error: %v", err)
+		return nil, pkg.ErrInternal.WithMessage("failed to purge projects").WithDetails(err.Error())
+	}
+	return counts, nil
+}
+
+// purgeDocuments removes the documents trashed together with the given
+// projects. Documents that were trashed on their own before their project
+// keep their tombstone and become orphans, so they can still be restored.
+// Must run inside runInTx.
+func (r *ProjectRepo) purgeDocuments(ctx context.Context, projectIDs []uuid.UUID, counts *CascadeCounts) error {
+	if err := deleteDocuments(ctx, r.db, bson.M{"trashed_with": bson.M{"$in": projectIDs}}, counts); err != nil {
+		return err
+	}
+	_, err := r.docCol.UpdateMany(ctx,
+		bson.M{"project_id": bson.M{"$in": projectIDs}},
+		bson.M{"$set": bson.M{"project_id": nil}},
+	)
+	return err
+}
@@ -54,3 +54,32 @@ func (r *UserRepo) Upsert(ctx context.Context, user *model.UserProfile) *pkg.App
 	}
 	return nil
 }
+
+// FindByIDs returns the profiles of the given users. Unknown IDs are skipped.
+func (r *UserRepo) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.UserProfile, *pkg.AppError) {
+	if len(ids) == 0 {
+		return []model.UserProfile{}, nil
+	}
+	cursor, err := r.col.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to list user profiles").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var users []model.UserProfile
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to decode user profiles").WithDetails(err.Error())
+	}
+	return users, nil
+}
+
+// FindByEmail returns a user profile by email, ignoring case.
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*model.UserProfile, *pkg.AppError) {
+	user := new(model.UserProfile)
+	opts := options.FindOne().SetCollation(&options.Collation{Locale: "en", Strength: 2})
+	err := r.col.FindOne(ctx, bson.M{"email": email}, opts).Decode(user)
+	if appErr := handleMongoError(err, "user profile"); appErr != nil {
+		return nil, appErr
+	}
+	return user, nil
+}
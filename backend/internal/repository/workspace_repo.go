@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -13,19 +15,26 @@ import (
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
 )
 
-// WorkspaceRepo handles workspaces and workspace_members collection operations.
+// WorkspaceRepo handles workspaces, workspace_members, workspace_invites,
+// workspace_invite_links and workspace_transfers collection operations.
 type WorkspaceRepo struct {
-	db        *mongo.Database
-	wsCol     *mongo.Collection
-	memberCol *mongo.Collection
+	db          *mongo.Database
+	wsCol       *mongo.Collection
+	memberCol   *mongo.Collection
+	inviteCol   *mongo.Collection
+	linkCol     *mongo.Collection
+	transferCol *mongo.Collection
 }
 
 // NewWorkspaceRepo creates a new WorkspaceRepo.
 func NewWorkspaceRepo(db *mongo.Database) *WorkspaceRepo {
 	return &WorkspaceRepo{
-		db:        db,
-		wsCol:     db.Collection("workspaces"),
-		memberCol: db.Collection("workspace_members"),
+		db:          db,
+		wsCol:       db.Collection("workspaces"),
+		memberCol:   db.Collection("workspace_members"),
+		inviteCol:   db.Collection("workspace_invites"),
+		linkCol:     db.Collection("workspace_invite_links"),
+		transferCol: db.Collection("workspace_transfers"),
 	}
 }
 
@@ -121,14 +130,41 @@ func (r *WorkspaceRepo) Update(ctx context.Context, ws *model.Workspace) *pkg.Ap
 	return nil
 }
 
-// Delete removes a workspace by ID.
-// Note: CASCADE deletes for projects/documents should be handled at the application level.
-func (r *WorkspaceRepo) Delete(ctx context.Context, id uuid.UUID) *pkg.AppError {
-	_, err := r.wsCol.DeleteOne(ctx, bson.M{"_id": id})
+// Delete removes a workspace with its members, invites, invite links,
+// projects and documents in a single transaction.
+func (r *WorkspaceRepo) Delete(ctx context.Context, id uuid.UUID) (*CascadeCounts, *pkg.AppError) {
+	counts := new(CascadeCounts)
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		*counts = CascadeCounts{}
+		byWorkspace := bson.M{"workspace_id": id}
+		if err := deleteDocuments(sessCtx, r.db, byWorkspace, counts); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if counts.Projects, err = deleteMany(sessCtx, r.db.Collection("projects"), byWorkspace); err != nil {
+			return nil, err
+		}
+		if counts.InviteLinks, err = deleteMany(sessCtx, r.linkCol, byWorkspace); err != nil {
+			return nil, err
+		}
+		if counts.Invites, err = deleteMany(sessCtx, r.inviteCol, byWorkspace); err != nil {
+			return nil, err
+		}
+		if counts.Members, err = deleteMany(sessCtx, r.memberCol, byWorkspace); err != nil {
+			return nil, err
+		}
+		if _, err = r.transferCol.DeleteOne(sessCtx, bson.M{"_id": id}); err != nil {
+			return nil, err
+		}
+		_, err = r.wsCol.DeleteOne(sessCtx, bson.M{"_id": id})
+		return nil, err
+	})
 	if err != nil {
-		return pkg.ErrInternal.WithMessage("failed to delete workspace").WithDetails(err.Error())
+		log.Printf("[WorkspaceRepo.Delete] TX error: %v", err)
+		return nil, pkg.ErrInternal.WithMessage("failed to delete workspace").WithDetails(err.Error())
 	}
-	return nil
+	return counts, nil
 }
 
 // --- WorkspaceMember operations ---
@@ -156,6 +192,59 @@ func (r *WorkspaceRepo) GetMemberRole(ctx context.Context, workspaceID, userID u
 	return member.Role, nil
 }
 
+// FindMembers returns all members of a workspace, earliest joined first.
+func (r *WorkspaceRepo) FindMembers(ctx context.Context, workspaceID uuid.UUID) ([]model.WorkspaceMember, *pkg.AppError) {
+	opts := options.Find().SetSort(bson.D{{Key: "joined_at", Value: 1}})
+	cursor, err := r.memberCol.Find(ctx, bson.M{"workspace_id": workspaceID}, opts)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to list members").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var members []model.WorkspaceMember
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to decode members").WithDetails(err.Error())
+	}
+	return members, nil
+}
+
+// FindMember returns a single membership.
+func (r *WorkspaceRepo) FindMember(ctx context.Context, workspaceID, userID uuid.UUID) (*model.WorkspaceMember, *pkg.AppError) {
+	member := new(model.WorkspaceMember)
+	filter := bson.M{"workspace_id": workspaceID, "user_id": userID}
+	err := r.memberCol.FindOne(ctx, filter).Decode(member)
+	if appErr := handleMongoError(err, "member"); appErr != nil {
+		return nil, appErr
+	}
+	return member, nil
+}
+
+// UpdateMemberRole changes a member's role.
+func (r *WorkspaceRepo) UpdateMemberRole(ctx context.Context, workspaceID, userID uuid.UUID, role string) *pkg.AppError {
+	filter := bson.M{"workspace_id": workspaceID, "user_id": userID}
+	res, err := r.memberCol.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to update member").WithDetails(err.Error())
+	}
+	if res.MatchedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("member not found")
+	}
+	return nil
+}
+
+// DeleteMember removes a user from a workspace.
+func (r *WorkspaceRepo) DeleteMember(ctx context.Context, workspaceID, userID uuid.UUID) *pkg.AppError {
+	filter := bson.M{"workspace_id": workspaceID, "user_id": userID}
+	res, err := r.memberCol.DeleteOne(ctx, filter)
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to remove member").WithDetails(err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("member not found")
+	}
+	return nil
+}
+
 // CountMembers returns the number of members in a workspace.
 func (r *WorkspaceRepo) CountMembers(ctx context.Context, workspaceID uuid.UUID) (int, *pkg.AppError) {
 	count, err := r.memberCol.CountDocuments(ctx, bson.M{"workspace_id": workspaceID})
@@ -182,3 +271,242 @@ func (r *WorkspaceRepo) InsertWithOwner(ctx context.Context, ws *model.Workspace
 	}
 	return nil
 }
+
+// --- WorkspaceInvite operations ---
+
+// errInviteUsed aborts an accept transaction when the invite was accepted concurrently.
+var errInviteUsed = errors.New("invite already accepted")
+
+// InsertInvite stores an invite, replacing any pending invite for the same
+// email in the workspace.
+func (r *WorkspaceRepo) InsertInvite(ctx context.Context, invite *model.WorkspaceInvite) *pkg.AppError {
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		pending := bson.M{"workspace_id": invite.WorkspaceID, "email": invite.Email, "accepted_at": nil}
+		if _, err := r.inviteCol.DeleteMany(sessCtx, pending); err != nil {
+			return nil, err
+		}
+		if _, err := r.inviteCol.InsertOne(sessCtx, invite); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	if err != nil {
+		log.Printf("[WorkspaceRepo.InsertInvite] TX error: %v", err)
+		return pkg.ErrInternal.WithMessage("failed to create invite").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// FindInviteByToken returns an invite by its token.
+func (r *WorkspaceRepo) FindInviteByToken(ctx context.Context, token string) (*model.WorkspaceInvite, *pkg.AppError) {
+	invite := new(model.WorkspaceInvite)
+	err := r.inviteCol.FindOne(ctx, bson.M{"token": token}).Decode(invite)
+	if appErr := handleMongoError(err, "invite"); appErr != nil {
+		return nil, appErr
+	}
+	return invite, nil
+}
+
+// AcceptInvite marks a pending invite accepted and adds the member in a
+// single transaction. Returns ErrConflict if the invite was already used.
+func (r *WorkspaceRepo) AcceptInvite(ctx context.Context, invite *model.WorkspaceInvite, member *model.WorkspaceMember) *pkg.AppError {
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		filter := bson.M{"_id": invite.ID, "accepted_at": nil}
+		update := bson.M{"$set": bson.M{"accepted_at": member.JoinedAt, "accepted_by": member.UserID}}
+		res, err := r.inviteCol.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errInviteUsed
+		}
+		if _, err := r.memberCol.InsertOne(sessCtx, member); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errInviteUsed) {
+		return pkg.ErrConflict.WithMessage("invite has already been accepted")
+	}
+	if err != nil {
+		log.Printf("[WorkspaceRepo.AcceptInvite] TX error: %v", err)
+		return pkg.ErrInternal.WithMessage("failed to accept invite").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// --- WorkspaceInviteLink operations ---
+
+// errInviteLinkUnusable aborts a join transaction when the link was revoked,
+// expired or used up concurrently.
+var errInviteLinkUnusable = errors.New("invite link no longer usable")
+
+// InsertInviteLink stores a new invite link.
+func (r *WorkspaceRepo) InsertInviteLink(ctx context.Context, link *model.WorkspaceInviteLink) *pkg.AppError {
+	_, err := r.linkCol.InsertOne(ctx, link)
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to create invite link").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// FindInviteLinks returns a workspace's links that are not revoked, newest first.
+func (r *WorkspaceRepo) FindInviteLinks(ctx context.Context, workspaceID uuid.UUID) ([]model.WorkspaceInviteLink, *pkg.AppError) {
+	filter := bson.M{"workspace_id": workspaceID, "revoked_at": nil}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.linkCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to list invite links").WithDetails(err.Error())
+	}
+	defer cursor.Close(ctx)
+
+	var links []model.WorkspaceInviteLink
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to decode invite links").WithDetails(err.Error())
+	}
+	return links, nil
+}
+
+// FindInviteLinkByToken returns an invite link by its token.
+func (r *WorkspaceRepo) FindInviteLinkByToken(ctx context.Context, token string) (*model.WorkspaceInviteLink, *pkg.AppError) {
+	link := new(model.WorkspaceInviteLink)
+	err := r.linkCol.FindOne(ctx, bson.M{"token": token}).Decode(link)
+	if appErr := handleMongoError(err, "invite link"); appErr != nil {
+		return nil, appErr
+	}
+	return link, nil
+}
+
+// RevokeInviteLink marks a workspace's link revoked.
+func (r *WorkspaceRepo) RevokeInviteLink(ctx context.Context, workspaceID, linkID uuid.UUID, at time.Time) *pkg.AppError {
+	filter := bson.M{"_id": linkID, "workspace_id": workspaceID, "revoked_at": nil}
+	res, err := r.linkCol.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to revoke invite link").WithDetails(err.Error())
+	}
+	if res.MatchedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("invite link not found")
+	}
+	return nil
+}
+
+// UseInviteLink counts a use of the link and adds the member in a single
+// transaction. The use is only counted while the link is unrevoked,
+// unexpired and under its use cap, so concurrent joins cannot exceed it.
+func (r *WorkspaceRepo) UseInviteLink(ctx context.Context, link *model.WorkspaceInviteLink, member *model.WorkspaceMember) *pkg.AppError {
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		filter := bson.M{
+			"_id":        link.ID,
+			"revoked_at": nil,
+			"expires_at": bson.M{"$gt": member.JoinedAt},
+			"$or": bson.A{
+				bson.M{"max_uses": nil},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+			},
+		}
+		res, err := r.linkCol.UpdateOne(sessCtx, filter, bson.M{"$inc": bson.M{"uses": 1}})
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errInviteLinkUnusable
+		}
+		if _, err := r.memberCol.InsertOne(sessCtx, member); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errInviteLinkUnusable) {
+		return pkg.ErrNotFound.WithMessage("invite link is no longer valid")
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return pkg.ErrConflict.WithMessage("you are already a member of this workspace")
+	}
+	if err != nil {
+		log.Printf("[WorkspaceRepo.UseInviteLink] TX error: %v", err)
+		return pkg.ErrInternal.WithMessage("failed to join workspace").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// --- WorkspaceTransfer operations ---
+
+// errOwnerChanged aborts a transfer transaction when the workspace changed hands concurrently.
+var errOwnerChanged = errors.New("workspace owner changed")
+
+// SetTransfer creates or replaces the pending ownership transfer of a workspace.
+func (r *WorkspaceRepo) SetTransfer(ctx context.Context, transfer *model.WorkspaceTransfer) *pkg.AppError {
+	_, err := r.transferCol.ReplaceOne(ctx, bson.M{"_id": transfer.WorkspaceID}, transfer, options.Replace().SetUpsert(true))
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to save ownership transfer").WithDetails(err.Error())
+	}
+	return nil
+}
+
+// FindTransfer returns the pending ownership transfer of a workspace.
+func (r *WorkspaceRepo) FindTransfer(ctx context.Context, workspaceID uuid.UUID) (*model.WorkspaceTransfer, *pkg.AppError) {
+	transfer := new(model.WorkspaceTransfer)
+	err := r.transferCol.FindOne(ctx, bson.M{"_id": workspaceID}).Decode(transfer)
+	if appErr := handleMongoError(err, "ownership transfer"); appErr != nil {
+		return nil, appErr
+	}
+	return transfer, nil
+}
+
+// DeleteTransfer removes the pending ownership transfer of a workspace.
+func (r *WorkspaceRepo) DeleteTransfer(ctx context.Context, workspaceID uuid.UUID) *pkg.AppError {
+	res, err := r.transferCol.DeleteOne(ctx, bson.M{"_id": workspaceID})
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to cancel ownership transfer").WithDetails(err.Error())
+	}
+	if res.DeletedCount == 0 {
+		return pkg.ErrNotFound.WithMessage("ownership transfer not found")
+	}
+	return nil
+}
+
+// TransferOwnership hands a workspace from one owner to another in a single
+// transaction: the workspace's owner_id moves to toID, toID's membership
+// becomes owner (added if missing) and fromID is demoted to previousOwnerRole.
+// Any pending transfer is removed. Returns ErrConflict if fromID no longer
+// owns the workspace.
+func (r *WorkspaceRepo) TransferOwnership(ctx context.Context, workspaceID, fromID, toID uuid.UUID, previousOwnerRole string, at time.Time) *pkg.AppError {
+	err := runInTx(ctx, r.db, func(sessCtx context.Context) (interface{}, error) {
+		res, err := r.wsCol.UpdateOne(sessCtx,
+			bson.M{"_id": workspaceID, "owner_id": fromID},
+			bson.M{"$set": bson.M{"owner_id": toID, "updated_at": at}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errOwnerChanged
+		}
+
+		_, err = r.memberCol.UpdateOne(sessCtx,
+			bson.M{"workspace_id": workspaceID, "user_id": toID},
+			bson.M{"$set": bson.M{"role": "owner"}, "$setOnInsert": bson.M{"joined_at": at}},
+			options.UpdateOne().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.memberCol.UpdateOne(sessCtx,
+			bson.M{"workspace_id": workspaceID, "user_id": fromID},
+			bson.M{"$set": bson.M{"role": previousOwnerRole}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.transferCol.DeleteOne(sessCtx, bson.M{"_id": workspaceID})
+		return nil, err
+	})
+	if errors.Is(err, errOwnerChanged) {
+		return pkg.ErrConflict.WithMessage("workspace ownership has changed since the transfer was requested")
+	}
+	if err != nil {
+		log.Printf("[WorkspaceRepo.TransferOwnership] TX error: %v", err)
+		return pkg.ErrInternal.WithMessage("failed to transfer ownership").WithDetails(err.Error())
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/config"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/handler"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/middleware"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/ws"
 )
 
 // Handlers groups all handler structs for route registration.
@@ -15,31 +16,48 @@ type Handlers struct {
 	Workspace *handler.WorkspaceHandler
 	Project   *handler.ProjectHandler
 	Document  *handler.DocumentHandler
+	Collab    *handler.CollabHandler
+	Trash     *handler.TrashHandler
 }
 
 // Setup registers all routes with middleware.
-// Middleware order: Recover → RequestID → Logger → CORS → [Auth for protected routes]
-func Setup(app *fiber.App, cfg *config.Config, h Handlers) {
+// Middleware order: Recover → RequestID → Logger → CORS → [Auth → RateLimit for protected routes]
+// Public OAuth routes are rate limited per IP, protected routes per user.
+// A nil limiter disables rate limiting.
+func Setup(app *fiber.App, cfg *config.Config, h Handlers, limiter middleware.Limiter) {
 	// Global middleware stack (applied to all routes)
 	app.Use(middleware.Recover())
 	app.Use(middleware.RequestID())
 	app.Use(middleware.Logger())
 	app.Use(middleware.CORS(cfg.FrontendURL))
 
+	// Realtime collaboration (WebSocket, JWT via query param or subprotocol)
+	app.Use("/ws", ws.UpgradeMiddleware())
+	app.Get("/ws/:documentId", middleware.WSAuth(cfg.JWTSecret), h.Collab.Authorize, h.Collab.Connect)
+
 	// API group
 	api := app.Group("/api")
 
+	// Rate limit scopes (requests per minute)
+	globalLimit := middleware.RateLimit(limiter, "global", cfg.RateLimits.Global)
+	writeLimit := middleware.RateLimit(limiter, "write", cfg.RateLimits.Write, middleware.WriteMethods...)
+	exportLimit := middleware.RateLimit(limiter, "export", cfg.RateLimits.Export)
+
 	// --- Public endpoints (no auth required) ---
 	api.Get("/health", h.Health.Check)
 
 	// OAuth routes (public — these initiate and handle the OAuth flow)
-	api.Get("/auth/google", h.Auth.GoogleLogin)
-	api.Get("/auth/google/callback", h.Auth.GoogleCallback)
-	api.Get("/auth/github", h.Auth.GitHubLogin)
-	api.Get("/auth/github/callback", h.Auth.GitHubCallback)
+	api.Get("/auth/google", globalLimit, h.Auth.GoogleLogin)
+	api.Get("/auth/google/callback", globalLimit, h.Auth.GoogleCallback)
+	api.Get("/auth/github", globalLimit, h.Auth.GitHubLogin)
+	api.Get("/auth/github/callback", globalLimit, h.Auth.GitHubCallback)
+
+	// Shared documents (public read-only links)
+	api.Get("/public/documents/:token", globalLimit, h.Document.GetShared)
+	api.Get("/public/documents/:token/svg", globalLimit, exportLimit, h.Document.RenderShared)
 
 	// --- Protected endpoints (auth required) ---
-	protected := api.Group("", middleware.Auth(cfg.JWTSecret))
+	protected := api.Group("", middleware.Auth(cfg.JWTSecret), globalLimit, writeLimit)
 
 	// Auth
 	protected.Get("/auth/me", h.Auth.Me)
@@ -50,17 +68,63 @@ func Setup(app *fiber.App, cfg *config.Config, h Handlers) {
 	protected.Put("/workspaces/:id", h.Workspace.Update)
 	protected.Delete("/workspaces/:id", h.Workspace.Delete)
 
+	// Workspace members & invites
+	protected.Get("/workspaces/:id/members", h.Workspace.ListMembers)
+	protected.Put("/workspaces/:id/members/:userId", h.Workspace.UpdateMember)
+	protected.Delete("/workspaces/:id/members/:userId", h.Workspace.RemoveMember)
+	protected.Post("/workspaces/:id/invites", h.Workspace.Invite)
+	protected.Post("/invites/:token/accept", h.Workspace.AcceptInvite)
+	protected.Get("/workspaces/:id/invite-links", h.Workspace.ListInviteLinks)
+	protected.Post("/workspaces/:id/invite-links", h.Workspace.CreateInviteLink)
+	protected.Delete("/workspaces/:id/invite-links/:linkId", h.Workspace.RevokeInviteLink)
+	protected.Post("/invite-links/:token/join", h.Workspace.JoinByInviteLink)
+
+	// Workspace ownership transfer
+	protected.Get("/workspaces/:id/transfer", h.Workspace.GetTransfer)
+	protected.Post("/workspaces/:id/transfer", h.Workspace.RequestTransfer)
+	protected.Post("/workspaces/:id/transfer/accept", h.Workspace.AcceptTransfer)
+	protected.Delete("/workspaces/:id/transfer", h.Workspace.CancelTransfer)
+	protected.Post("/admin/workspaces/:id/transfer", h.Workspace.ForceTransfer)
+
 	// Projects (nested under workspaces for listing)
 	protected.Get("/workspaces/:id/projects", h.Project.ListByWorkspace)
 	protected.Post("/projects", h.Project.Create)
 	protected.Put("/projects/:id", h.Project.Update)
 	protected.Delete("/projects/:id", h.Project.Delete)
 
+	// Trash
+	protected.Get("/workspaces/:id/trash", h.Trash.List)
+	protected.Post("/trash/projects/:id/restore", h.Trash.RestoreProject)
+	protected.Delete("/trash/projects/:id", h.Trash.PurgeProject)
+	protected.Post("/trash/documents/:id/restore", h.Trash.RestoreDocument)
+	protected.Delete("/trash/documents/:id", h.Trash.PurgeDocument)
+
 	// Documents
 	protected.Get("/documents/recent", h.Document.Recent) // Must be before :id route
 	protected.Get("/projects/:id/documents", h.Document.ListByProject)
 	protected.Get("/documents/:id", h.Document.GetByID)
 	protected.Post("/documents", h.Document.Create)
+	protected.Post("/documents/import/ascii", h.Document.ImportASCII)
 	protected.Put("/documents/:id", h.Document.Update)
 	protected.Delete("/documents/:id", h.Document.Delete)
+	protected.Get("/documents/:id/dsl", h.Document.ExportDSL)
+	protected.Post("/documents/:id/dsl", h.Document.ImportDSL)
+	protected.Get("/documents/:id/versions", h.Document.ListVersions)
+	protected.Get("/documents/:id/versions/:version", h.Document.GetVersion)
+	protected.Post("/documents/:id/versions/:version/restore", h.Document.RestoreVersion)
+	protected.Get("/documents/:id/diff", h.Document.Diff)
+
+	// Sharing
+	protected.Get("/documents/:id/grants", h.Document.ListGrants)
+	protected.Post("/documents/:id/grants", h.Document.Grant)
+	protected.Delete("/documents/:id/grants/:userId", h.Document.RevokeGrant)
+	protected.Get("/documents/:id/share", h.Document.GetShare)
+	protected.Post("/documents/:id/share", h.Document.CreateShare)
+	protected.Delete("/documents/:id/share", h.Document.DeleteShare)
+
+	// Export
+	protected.Post("/documents/:id/export", exportLimit, h.Document.Export)
+
+	// Collaboration
+	protected.Get("/documents/:id/presence", h.Collab.Presence)
 }
@@ -3,26 +3,41 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/ascii"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/diagram"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/diff"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/dsl"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/dto"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/mermaid"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/repository"
 )
 
+// roleRank orders roles, so that the stronger of a workspace role and a
+// document grant applies.
+var roleRank = map[string]int{"viewer": 1, "editor": 2, "owner": 3}
+
+// emptyView is the view of a document without position, style or routing overrides.
+const emptyView = `{"positions":{},"styles":{},"routing":{}}`
+
 // DocumentService handles document business logic with authorization.
 type DocumentService struct {
 	docRepo  *repository.DocumentRepo
 	projRepo *repository.ProjectRepo
+	userRepo *repository.UserRepo
 	wsSvc    *WorkspaceService
 }
 
 // NewDocumentService creates a new DocumentService.
-func NewDocumentService(docRepo *repository.DocumentRepo, projRepo *repository.ProjectRepo, wsSvc *WorkspaceService) *DocumentService {
-	return &DocumentService{docRepo: docRepo, projRepo: projRepo, wsSvc: wsSvc}
+func NewDocumentService(docRepo *repository.DocumentRepo, projRepo *repository.ProjectRepo, userRepo *repository.UserRepo, wsSvc *WorkspaceService) *DocumentService {
+	return &DocumentService{docRepo: docRepo, projRepo: projRepo, userRepo: userRepo, wsSvc: wsSvc}
 }
 
 // ListByProject returns paginated documents for a project. Requires workspace membership.
@@ -48,26 +63,93 @@ func (s *DocumentService) ListByProject(ctx context.Context, userID, projectID u
 	return &dto.DocumentListResp{Data: items, Meta: meta}, nil
 }
 
-// GetByID returns a single document with full content. Requires workspace membership.
+// GetByID returns a single document with full content.
+// Requires workspace membership or a grant on the document.
 func (s *DocumentService) GetByID(ctx context.Context, userID, docID uuid.UUID) (*dto.DocumentResp, *pkg.AppError) {
 	doc, appErr := s.docRepo.FindByID(ctx, docID)
 	if appErr != nil {
 		return nil, appErr
 	}
 
-	// Check workspace membership
-	if _, appErr := s.wsSvc.RequireMembership(ctx, doc.WorkspaceID, userID); appErr != nil {
+	if _, _, appErr := s.documentRole(ctx, doc, userID); appErr != nil {
 		return nil, appErr
 	}
 
 	return toDocumentResp(doc), nil
 }
 
+// RequireAccess returns the caller's role (owner, editor or viewer) for a
+// document, from their workspace membership or a grant on the document.
+// Used to authorize realtime collaboration connections.
+func (s *DocumentService) RequireAccess(ctx context.Context, userID, docID uuid.UUID) (string, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return "", appErr
+	}
+	role, _, appErr := s.documentRole(ctx, doc, userID)
+	return role, appErr
+}
+
+// LoadContent returns a document with its decoded content. Used by realtime
+// rooms, which authorize their clients separately.
+func (s *DocumentService) LoadContent(ctx context.Context, docID uuid.UUID) (*model.Document, *document.DocumentContent, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	content, appErr := decodeContent(doc.Content)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	return doc, content, nil
+}
+
+// SaveContent persists content edited in a realtime room as a new version,
+// archiving the superseded one. The write is a compare-and-swap on baseVersion;
+// returns the new version, or ErrConflict if the document changed meanwhile.
+// Content is checked against the diagram type like a REST update; the view
+// is not, since rooms do not edit it.
+func (s *DocumentService) SaveContent(ctx context.Context, docID, userID uuid.UUID, content json.RawMessage, baseVersion int) (int, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return 0, appErr
+	}
+	if doc.Version != baseVersion {
+		return 0, pkg.ErrConflict.
+			WithMessage("document was modified by someone else").
+			WithDetails(map[string]int{"current_version": doc.Version})
+	}
+	if appErr := validateDocument(doc.DiagramType, content, nil, true); appErr != nil {
+		return 0, appErr
+	}
+
+	prev := *doc
+	doc.Content = content
+	doc.Version++
+	doc.UpdatedBy = &userID
+	doc.UpdatedAt = time.Now()
+	if appErr := s.docRepo.UpdateWithSnapshot(ctx, doc, toVersionSnapshot(&prev)); appErr != nil {
+		return 0, appErr
+	}
+	return doc.Version, nil
+}
+
 // Create creates a new document. Requires editor or owner role.
 func (s *DocumentService) Create(ctx context.Context, userID uuid.UUID, req dto.CreateDocumentReq) (*dto.DocumentResp, *pkg.AppError) {
 	if appErr := pkg.Validate(req); appErr != nil {
 		return nil, appErr
 	}
+	if req.Mermaid != nil {
+		if appErr := importMermaid(&req); appErr != nil {
+			return nil, appErr
+		}
+	}
+	diagramType, ok := diagram.Lookup(req.DiagramType)
+	if !ok {
+		return nil, pkg.ErrUnprocessable.
+			WithMessage("validation failed").
+			WithDetails("DiagramType must be one of: " + strings.Join(diagram.Names(), " "))
+	}
 
 	workspaceID, err := uuid.Parse(req.WorkspaceID)
 	if err != nil {
@@ -89,6 +171,9 @@ func (s *DocumentService) Create(ctx context.Context, userID uuid.UUID, req dto.
 		if err != nil {
 			return nil, pkg.ErrBadRequest.WithMessage("invalid project_id")
 		}
+		if appErr := s.requireProjectIn(ctx, pid, workspaceID); appErr != nil {
+			return nil, appErr
+		}
 		projectID = &pid
 	}
 
@@ -98,14 +183,23 @@ func (s *DocumentService) Create(ctx context.Context, userID uuid.UUID, req dto.
 	}
 
 	// Default content/view
-	content := json.RawMessage(`{"nodes":[],"edges":[]}`)
+	var content json.RawMessage
 	if req.Content != nil {
 		content = *req.Content
+	} else {
+		raw, err := json.Marshal(diagramType.DefaultContent)
+		if err != nil {
+			return nil, pkg.ErrInternal.WithMessage("failed to encode content").WithDetails(err.Error())
+		}
+		content = raw
 	}
-	view := json.RawMessage(`{"positions":{},"styles":{},"routing":{}}`)
+	view := json.RawMessage(emptyView)
 	if req.View != nil {
 		view = *req.View
 	}
+	if appErr := validateDocument(req.DiagramType, content, view, true); appErr != nil {
+		return nil, appErr
+	}
 
 	doc := &model.Document{
 		ID:          uuid.New(),
@@ -117,6 +211,7 @@ func (s *DocumentService) Create(ctx context.Context, userID uuid.UUID, req dto.
 		View:        view,
 		Version:     1,
 		CreatedBy:   &userID,
+		UpdatedBy:   &userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
@@ -128,22 +223,42 @@ func (s *DocumentService) Create(ctx context.Context, userID uuid.UUID, req dto.
 	return toDocumentResp(doc), nil
 }
 
-// Update modifies a document. Requires editor or owner role.
-// Increments version on content/view changes.
+// Update modifies a document. Requires editor or owner role, from the
+// workspace or a grant on the document; only workspace members may move it
+// to another project. Increments version on content/view changes, archiving
+// the superseded content/view in document_versions.
+//
+// The write is a compare-and-swap on the version that was read, so concurrent
+// updates return ErrConflict instead of silently overwriting each other. When
+// req.ExpectedVersion is set, it must also match the stored version.
 func (s *DocumentService) Update(ctx context.Context, userID, docID uuid.UUID, req dto.UpdateDocumentReq) (*dto.DocumentResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+
 	doc, appErr := s.docRepo.FindByID(ctx, docID)
 	if appErr != nil {
 		return nil, appErr
 	}
 
-	role, appErr := s.wsSvc.RequireMembership(ctx, doc.WorkspaceID, userID)
+	role, member, appErr := s.documentRole(ctx, doc, userID)
 	if appErr != nil {
 		return nil, appErr
 	}
 	if role == "viewer" {
 		return nil, pkg.ErrForbidden.WithMessage("viewers cannot update documents")
 	}
+	if req.ProjectID != nil && !member {
+		return nil, pkg.ErrForbidden.WithMessage("only workspace members can move documents")
+	}
+
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != doc.Version {
+		return nil, pkg.ErrConflict.
+			WithMessage("document was modified by someone else").
+			WithDetails(map[string]int{"current_version": doc.Version})
+	}
 
+	prev := *doc
 	bumpVersion := false
 
 	if req.Title != nil {
@@ -157,6 +272,9 @@ func (s *DocumentService) Update(ctx context.Context, userID, docID uuid.UUID, r
 			if err != nil {
 				return nil, pkg.ErrBadRequest.WithMessage("invalid project_id")
 			}
+			if appErr := s.requireProjectIn(ctx, pid, doc.WorkspaceID); appErr != nil {
+				return nil, appErr
+			}
 			doc.ProjectID = &pid
 		}
 	}
@@ -168,20 +286,31 @@ func (s *DocumentService) Update(ctx context.Context, userID, docID uuid.UUID, r
 		doc.View = *req.View
 		bumpVersion = true
 	}
+	if bumpVersion {
+		if appErr := validateDocument(doc.DiagramType, doc.Content, doc.View, req.Content != nil); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	doc.UpdatedAt = time.Now()
 
 	if bumpVersion {
 		doc.Version++
+		doc.UpdatedBy = &userID
+		if appErr := s.docRepo.UpdateWithSnapshot(ctx, doc, toVersionSnapshot(&prev)); appErr != nil {
+			return nil, appErr
+		}
+		return toDocumentResp(doc), nil
 	}
-	doc.UpdatedAt = time.Now()
 
-	if appErr := s.docRepo.Update(ctx, doc); appErr != nil {
+	if appErr := s.docRepo.Update(ctx, doc, prev.Version); appErr != nil {
 		return nil, appErr
 	}
 
 	return toDocumentResp(doc), nil
 }
 
-// Delete removes a document. Owner role only.
+// Delete moves a document to the trash. Owner role only.
 func (s *DocumentService) Delete(ctx context.Context, userID, docID uuid.UUID) *pkg.AppError {
 	doc, appErr := s.docRepo.FindByID(ctx, docID)
 	if appErr != nil {
@@ -196,7 +325,7 @@ func (s *DocumentService) Delete(ctx context.Context, userID, docID uuid.UUID) *
 		return pkg.ErrForbidden.WithMessage("only workspace owners can delete documents")
 	}
 
-	return s.docRepo.Delete(ctx, docID)
+	return s.docRepo.Trash(ctx, docID, userID, time.Now())
 }
 
 // ListRecent returns the N most recently updated documents across all user's workspaces.
@@ -232,6 +361,486 @@ func (s *DocumentService) ListRecent(ctx context.Context, userID uuid.UUID, limi
 	return &dto.RecentDocumentResp{Data: items}, nil
 }
 
+// ListVersions returns paginated archived versions of a document, newest first.
+// Requires workspace membership or a grant on the document.
+// The current version is not included; it is the document itself.
+func (s *DocumentService) ListVersions(ctx context.Context, userID, docID uuid.UUID, pq dto.PaginationQuery) (*dto.DocumentVersionListResp, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if _, _, appErr := s.documentRole(ctx, doc, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	versions, total, appErr := s.docRepo.FindVersions(ctx, docID, pq.PerPage, pq.Offset())
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	items := make([]dto.DocumentVersionListItem, 0, len(versions))
+	for _, v := range versions {
+		items = append(items, dto.DocumentVersionListItem{
+			Version:   v.Version,
+			Title:     v.Title,
+			CreatedBy: uuidPtrString(v.CreatedBy),
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	meta := dto.NewPaginationMeta(pq, total)
+	return &dto.DocumentVersionListResp{Data: items, Meta: meta}, nil
+}
+
+// GetVersion returns the content/view of a document at a given version.
+// Requires workspace membership or a grant on the document.
+// Asking for the current version returns the live document.
+func (s *DocumentService) GetVersion(ctx context.Context, userID, docID uuid.UUID, version int) (*dto.DocumentVersionResp, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if _, _, appErr := s.documentRole(ctx, doc, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	v, appErr := s.findVersion(ctx, doc, version)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return toDocumentVersionResp(v, v.Version == doc.Version), nil
+}
+
+// RestoreVersion makes an archived version the new current version. Requires editor or owner role.
+// The restore is itself a content update: the current version is archived and the version number bumps.
+func (s *DocumentService) RestoreVersion(ctx context.Context, userID, docID uuid.UUID, version int) (*dto.DocumentResp, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	role, _, appErr := s.documentRole(ctx, doc, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role == "viewer" {
+		return nil, pkg.ErrForbidden.WithMessage("viewers cannot restore versions")
+	}
+
+	if version == doc.Version {
+		return nil, pkg.ErrBadRequest.WithMessage("version is already the current version")
+	}
+
+	snapshot, appErr := s.docRepo.FindVersion(ctx, docID, version)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return s.Update(ctx, userID, docID, dto.UpdateDocumentReq{
+		Content: &snapshot.Content,
+		View:    &snapshot.View,
+	})
+}
+
+// Diff compares the content and view of two versions of a document.
+// Requires workspace membership or a grant on the document.
+// A `to` of 0 means the current version.
+func (s *DocumentService) Diff(ctx context.Context, userID, docID uuid.UUID, from, to int) (*dto.DocumentDiffResp, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if _, _, appErr := s.documentRole(ctx, doc, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	if to == 0 {
+		to = doc.Version
+	}
+
+	fromVer, appErr := s.findVersion(ctx, doc, from)
+	if appErr != nil {
+		return nil, appErr
+	}
+	toVer, appErr := s.findVersion(ctx, doc, to)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	fromContent, appErr := decodeContent(fromVer.Content)
+	if appErr != nil {
+		return nil, appErr
+	}
+	toContent, appErr := decodeContent(toVer.Content)
+	if appErr != nil {
+		return nil, appErr
+	}
+	fromView, appErr := decodeView(fromVer.View)
+	if appErr != nil {
+		return nil, appErr
+	}
+	toView, appErr := decodeView(toVer.View)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return &dto.DocumentDiffResp{
+		DocumentID: doc.ID.String(),
+		From:       from,
+		To:         to,
+		Result:     *diff.Compare(fromContent, toContent, fromView, toView),
+	}, nil
+}
+
+// ExportDSL returns the document content serialized as GraDiOl DSL text.
+// Requires workspace membership or a grant on the document.
+func (s *DocumentService) ExportDSL(ctx context.Context, userID, docID uuid.UUID) (string, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return "", appErr
+	}
+
+	if _, _, appErr := s.documentRole(ctx, doc, userID); appErr != nil {
+		return "", appErr
+	}
+
+	content, appErr := decodeContent(doc.Content)
+	if appErr != nil {
+		return "", appErr
+	}
+
+	return dsl.Serialize(content, doc.DiagramType, doc.Title), nil
+}
+
+// ImportDSL replaces the document content with the diagram parsed from DSL text.
+// The DSL header must match the document's diagram type; its title, if given, renames the document.
+// Requires editor or owner role. Bumps the version like any content update.
+func (s *DocumentService) ImportDSL(ctx context.Context, userID, docID uuid.UUID, req dto.ImportDSLReq) (*dto.DocumentResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	role, _, appErr := s.documentRole(ctx, doc, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role == "viewer" {
+		return nil, pkg.ErrForbidden.WithMessage("viewers cannot update documents")
+	}
+
+	update, appErr := dslUpdate(doc, req.Source)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return s.Update(ctx, userID, docID, *update)
+}
+
+// dslUpdate builds the update that replaces doc's content with the parsed DSL
+// source. The parser numbers nodes and edges afresh (n1, e1, ...), so the view
+// overrides of the old content are reset rather than attached to whatever now
+// carries their IDs. The update is conditional on the version that was read.
+func dslUpdate(doc *model.Document, source string) (*dto.UpdateDocumentReq, *pkg.AppError) {
+	ast, content, err := dsl.ParseContent(source)
+	if err != nil {
+		return nil, pkg.ErrUnprocessable.WithMessage("invalid DSL").WithDetails(err)
+	}
+	if ast.DiagramType != doc.DiagramType {
+		return nil, pkg.ErrUnprocessable.WithMessage("DSL diagram type @" + ast.DiagramType + " does not match document type " + doc.DiagramType)
+	}
+
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to encode content").WithDetails(err.Error())
+	}
+	rawContent := json.RawMessage(raw)
+	view := json.RawMessage(emptyView)
+
+	update := &dto.UpdateDocumentReq{Content: &rawContent, View: &view, ExpectedVersion: &doc.Version}
+	if ast.Title != "" {
+		update.Title = &ast.Title
+	}
+	return update, nil
+}
+
+// importMermaid converts the Mermaid source of a create request into its
+// content and diagram type.
+func importMermaid(req *dto.CreateDocumentReq) *pkg.AppError {
+	diagramType, content, err := mermaid.Parse(*req.Mermaid)
+	if err != nil {
+		return pkg.ErrUnprocessable.WithMessage("invalid Mermaid diagram").WithDetails(err)
+	}
+	if req.DiagramType != "" && req.DiagramType != diagramType {
+		return pkg.ErrUnprocessable.WithMessage("Mermaid diagram is a " + diagramType + ", not a " + req.DiagramType)
+	}
+
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return pkg.ErrInternal.WithMessage("failed to encode content").WithDetails(err.Error())
+	}
+	rawContent := json.RawMessage(raw)
+	req.DiagramType = diagramType
+	req.Content = &rawContent
+	return nil
+}
+
+// PreviewASCII parses box-and-arrow ASCII art into diagram content without
+// saving it.
+func (s *DocumentService) PreviewASCII(req dto.ImportASCIIReq) (*dto.ASCIIPreviewResp, *pkg.AppError) {
+	return parseASCII(req)
+}
+
+// ImportASCII creates a new document from box-and-arrow ASCII art.
+// Requires editor or owner role, like Create.
+func (s *DocumentService) ImportASCII(ctx context.Context, userID uuid.UUID, req dto.ImportASCIIReq) (*dto.DocumentResp, *pkg.AppError) {
+	preview, appErr := parseASCII(req)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return s.Create(ctx, userID, dto.CreateDocumentReq{
+		WorkspaceID: req.WorkspaceID,
+		ProjectID:   req.ProjectID,
+		Title:       req.Title,
+		DiagramType: preview.DiagramType,
+		Content:     &preview.Content,
+	})
+}
+
+// parseASCII validates an ASCII import request and converts its source.
+func parseASCII(req dto.ImportASCIIReq) (*dto.ASCIIPreviewResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	diagramType := req.DiagramType
+	if diagramType == "" {
+		diagramType = "flowchart"
+	}
+	if _, ok := diagram.Lookup(diagramType); !ok {
+		return nil, pkg.ErrUnprocessable.
+			WithMessage("validation failed").
+			WithDetails("DiagramType must be one of: " + strings.Join(diagram.Names(), " "))
+	}
+
+	res, err := ascii.Parse(req.Source, diagramType)
+	if err != nil {
+		return nil, pkg.ErrUnprocessable.WithMessage("invalid ASCII diagram").WithDetails(err.Error())
+	}
+	raw, err := json.Marshal(res.Content)
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to encode content").WithDetails(err.Error())
+	}
+	if appErr := validateDocument(diagramType, raw, nil, true); appErr != nil {
+		return nil, appErr
+	}
+
+	warnings := res.Warnings
+	if warnings == nil {
+		warnings = []string{}
+	}
+	return &dto.ASCIIPreviewResp{DiagramType: diagramType, Content: raw, Warnings: warnings}, nil
+}
+
+// ListGrants returns the per-document grants of a document. Requires
+// workspace owner or editor role.
+func (s *DocumentService) ListGrants(ctx context.Context, userID, docID uuid.UUID) ([]dto.DocumentGrantResp, *pkg.AppError) {
+	if _, appErr := s.findForSharing(ctx, userID, docID); appErr != nil {
+		return nil, appErr
+	}
+
+	grants, appErr := s.docRepo.FindGrants(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	ids := make([]uuid.UUID, len(grants))
+	for i, g := range grants {
+		ids[i] = g.UserID
+	}
+	users, appErr := s.userRepo.FindByIDs(ctx, ids)
+	if appErr != nil {
+		return nil, appErr
+	}
+	profiles := make(map[uuid.UUID]*model.UserProfile, len(users))
+	for i := range users {
+		profiles[users[i].ID] = &users[i]
+	}
+
+	items := make([]dto.DocumentGrantResp, 0, len(grants))
+	for _, g := range grants {
+		items = append(items, toGrantResp(&g, profiles[g.UserID]))
+	}
+	return items, nil
+}
+
+// GrantAccess gives a user, found by email, a role on a single document.
+// Requires workspace owner or editor role.
+func (s *DocumentService) GrantAccess(ctx context.Context, userID, docID uuid.UUID, req dto.DocumentGrantReq) (*dto.DocumentGrantResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	if _, appErr := s.findForSharing(ctx, userID, docID); appErr != nil {
+		return nil, appErr
+	}
+
+	user, appErr := s.userRepo.FindByEmail(ctx, strings.TrimSpace(req.Email))
+	if appErr != nil {
+		if appErr.Code == pkg.ErrNotFound.Code {
+			return nil, pkg.ErrNotFound.WithMessage("no user with this email has signed in yet")
+		}
+		return nil, appErr
+	}
+
+	grant := &model.DocumentGrant{
+		DocumentID: docID,
+		UserID:     user.ID,
+		Role:       req.Role,
+		GrantedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	if appErr := s.docRepo.UpsertGrant(ctx, grant); appErr != nil {
+		return nil, appErr
+	}
+
+	resp := toGrantResp(grant, user)
+	return &resp, nil
+}
+
+// RevokeAccess removes a user's grant on a document. Requires workspace
+// owner or editor role.
+func (s *DocumentService) RevokeAccess(ctx context.Context, userID, docID, targetID uuid.UUID) *pkg.AppError {
+	if _, appErr := s.findForSharing(ctx, userID, docID); appErr != nil {
+		return appErr
+	}
+	return s.docRepo.DeleteGrant(ctx, docID, targetID)
+}
+
+// GetShare returns the document's public link. Requires workspace owner or editor role.
+func (s *DocumentService) GetShare(ctx context.Context, userID, docID uuid.UUID) (*dto.DocumentShareResp, *pkg.AppError) {
+	if _, appErr := s.findForSharing(ctx, userID, docID); appErr != nil {
+		return nil, appErr
+	}
+	share, appErr := s.docRepo.FindShare(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return toShareResp(share), nil
+}
+
+// CreateShare creates the document's public read-only link, replacing
+// (and thereby revoking) any previous one. Requires workspace owner or editor role.
+func (s *DocumentService) CreateShare(ctx context.Context, userID, docID uuid.UUID) (*dto.DocumentShareResp, *pkg.AppError) {
+	if _, appErr := s.findForSharing(ctx, userID, docID); appErr != nil {
+		return nil, appErr
+	}
+
+	token, err := pkg.GenerateToken()
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to generate share token")
+	}
+	share := &model.DocumentShare{
+		DocumentID: docID,
+		Token:      token,
+		CreatedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	if appErr := s.docRepo.SetShare(ctx, share); appErr != nil {
+		return nil, appErr
+	}
+	return toShareResp(share), nil
+}
+
+// DeleteShare revokes the document's public link. Requires workspace owner or editor role.
+func (s *DocumentService) DeleteShare(ctx context.Context, userID, docID uuid.UUID) *pkg.AppError {
+	if _, appErr := s.findForSharing(ctx, userID, docID); appErr != nil {
+		return appErr
+	}
+	return s.docRepo.DeleteShare(ctx, docID)
+}
+
+// GetShared returns the document behind a public link. No authentication.
+func (s *DocumentService) GetShared(ctx context.Context, token string) (*dto.DocumentResp, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByShareToken(ctx, token)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return toDocumentResp(doc), nil
+}
+
+// findForSharing loads a document and checks that the user may manage who
+// it is shared with: workspace owners and editors. Grants do not extend to
+// sharing further.
+func (s *DocumentService) findForSharing(ctx context.Context, userID, docID uuid.UUID) (*model.Document, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	role, appErr := s.wsSvc.RequireMembership(ctx, doc.WorkspaceID, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role == "viewer" {
+		return nil, pkg.ErrForbidden.WithMessage("viewers cannot share documents")
+	}
+	return doc, nil
+}
+
+// documentRole returns the user's effective role on a document: the stronger
+// of their workspace role and their grant on the document. member reports
+// whether they belong to the document's workspace. Returns ErrForbidden if
+// they have neither.
+func (s *DocumentService) documentRole(ctx context.Context, doc *model.Document, userID uuid.UUID) (role string, member bool, appErr *pkg.AppError) {
+	return documentRole(ctx, s.wsSvc, s.docRepo, doc, userID)
+}
+
+// documentRole is DocumentService.documentRole for services that hold the
+// same repositories, such as ExportService.
+func documentRole(ctx context.Context, wsSvc *WorkspaceService, docRepo *repository.DocumentRepo, doc *model.Document, userID uuid.UUID) (role string, member bool, appErr *pkg.AppError) {
+	wsRole, appErr := wsSvc.MemberRole(ctx, doc.WorkspaceID, userID)
+	if appErr != nil {
+		return "", false, appErr
+	}
+	grantRole, appErr := docRepo.GetGrantRole(ctx, doc.ID, userID)
+	if appErr != nil {
+		return "", false, appErr
+	}
+
+	role = wsRole
+	if roleRank[grantRole] > roleRank[role] {
+		role = grantRole
+	}
+	if role == "" {
+		return "", false, pkg.ErrForbidden.WithMessage("you do not have access to this document")
+	}
+	return role, wsRole != "", nil
+}
+
+// findVersion returns the archived snapshot for version, or the live document when version is current.
+func (s *DocumentService) findVersion(ctx context.Context, doc *model.Document, version int) (*model.DocumentVersion, *pkg.AppError) {
+	if version == doc.Version {
+		return &model.DocumentVersion{
+			DocumentID: doc.ID,
+			Version:    doc.Version,
+			Title:      doc.Title,
+			Content:    doc.Content,
+			View:       doc.View,
+			CreatedBy:  versionAuthor(doc),
+			CreatedAt:  doc.UpdatedAt,
+		}, nil
+	}
+	if version < 1 || version > doc.Version {
+		return nil, pkg.ErrNotFound.WithMessage("document version not found")
+	}
+	return s.docRepo.FindVersion(ctx, doc.ID, version)
+}
+
 // findProjectForAuth finds a project and checks user membership in its workspace.
 func (s *DocumentService) findProjectForAuth(ctx context.Context, projectID, userID uuid.UUID) (*model.Project, *pkg.AppError) {
 	proj, appErr := s.projRepo.FindByID(ctx, projectID)
@@ -245,6 +854,22 @@ func (s *DocumentService) findProjectForAuth(ctx context.Context, projectID, use
 	return proj, nil
 }
 
+// requireProjectIn checks that a document can be placed in a project: the
+// project must exist outside the trash and belong to the document's workspace.
+func (s *DocumentService) requireProjectIn(ctx context.Context, projectID, workspaceID uuid.UUID) *pkg.AppError {
+	proj, appErr := s.projRepo.FindByID(ctx, projectID)
+	if appErr != nil {
+		if appErr.Code == pkg.ErrNotFound.Code {
+			return pkg.ErrUnprocessable.WithMessage("project not found or in the trash")
+		}
+		return appErr
+	}
+	if proj.WorkspaceID != workspaceID {
+		return pkg.ErrUnprocessable.WithMessage("project belongs to another workspace")
+	}
+	return nil
+}
+
 func toDocumentResp(d *model.Document) *dto.DocumentResp {
 	var projectID *string
 	if d.ProjectID != nil {
@@ -271,6 +896,111 @@ func toDocumentResp(d *model.Document) *dto.DocumentResp {
 	}
 }
 
+func toGrantResp(g *model.DocumentGrant, profile *model.UserProfile) dto.DocumentGrantResp {
+	resp := dto.DocumentGrantResp{
+		UserID:    g.UserID.String(),
+		Role:      g.Role,
+		GrantedBy: g.GrantedBy.String(),
+		CreatedAt: g.CreatedAt,
+	}
+	if profile != nil {
+		resp.Email = profile.Email
+		resp.FullName = profile.FullName
+		resp.AvatarURL = profile.AvatarURL
+	}
+	return resp
+}
+
+func toShareResp(share *model.DocumentShare) *dto.DocumentShareResp {
+	return &dto.DocumentShareResp{
+		DocumentID: share.DocumentID.String(),
+		Token:      share.Token,
+		CreatedAt:  share.CreatedAt,
+	}
+}
+
+// decodeContent decodes stored content JSON into the typed diagram model.
+func decodeContent(raw json.RawMessage) (*document.DocumentContent, *pkg.AppError) {
+	var content document.DocumentContent
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return nil, pkg.ErrUnprocessable.WithMessage("document content is not a valid diagram").WithDetails(err.Error())
+	}
+	return &content, nil
+}
+
+// validateDocument checks content and view against the rules of the diagram
+// type and reports every problem as a diagram.FieldError. When checkContent
+// is false the content is already stored and only the view is checked against
+// it; content that does not decode then skips the view check as well.
+func validateDocument(diagramType string, rawContent, rawView json.RawMessage, checkContent bool) *pkg.AppError {
+	content, appErr := decodeContent(rawContent)
+	if appErr != nil {
+		if checkContent {
+			return appErr
+		}
+		return nil
+	}
+	view, appErr := decodeView(rawView)
+	if appErr != nil {
+		return appErr
+	}
+
+	var errs []diagram.FieldError
+	if checkContent {
+		errs = diagram.ValidateContent(diagramType, content)
+	}
+	errs = append(errs, diagram.ValidateView(content, view)...)
+	if len(errs) > 0 {
+		return pkg.ErrUnprocessable.WithMessage("document content is invalid").WithDetails(errs)
+	}
+	return nil
+}
+
+// toVersionSnapshot builds the archive record for the document's current (about to be superseded) version.
+func toVersionSnapshot(d *model.Document) *model.DocumentVersion {
+	return &model.DocumentVersion{
+		ID:         uuid.New(),
+		DocumentID: d.ID,
+		Version:    d.Version,
+		Title:      d.Title,
+		Content:    d.Content,
+		View:       d.View,
+		CreatedBy:  versionAuthor(d),
+		CreatedAt:  d.UpdatedAt,
+		ArchivedAt: time.Now(),
+	}
+}
+
+// versionAuthor returns who saved the document's current version.
+// Documents created before updated_by was tracked fall back to the creator.
+func versionAuthor(d *model.Document) *uuid.UUID {
+	if d.UpdatedBy != nil {
+		return d.UpdatedBy
+	}
+	return d.CreatedBy
+}
+
+func toDocumentVersionResp(v *model.DocumentVersion, current bool) *dto.DocumentVersionResp {
+	return &dto.DocumentVersionResp{
+		DocumentID: v.DocumentID.String(),
+		Version:    v.Version,
+		Title:      v.Title,
+		Content:    v.Content,
+		View:       v.View,
+		CreatedBy:  uuidPtrString(v.CreatedBy),
+		CreatedAt:  v.CreatedAt,
+		Current:    current,
+	}
+}
+
+func uuidPtrString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
 func toDocumentListItem(d *model.Document) dto.DocumentListItem {
 	var projectID *string
 	if d.ProjectID != nil {
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+)
+
+func TestDSLUpdate(t *testing.T) {
+	const source = `@flowchart "Renamed"
+
+start "Begin"
+process "Work"
+
+start -> "Work"
+`
+	tests := []struct {
+		name        string
+		diagramType string
+		view        string
+		wantErr     bool
+	}{
+		{name: "empty view", diagramType: "flowchart", view: emptyView},
+		{
+			name:        "view of the replaced content",
+			diagramType: "flowchart",
+			view:        `{"positions":{"a":{"x":10,"y":20}},"styles":{"a":{"color":"red"},"ab":{"color":"blue"}},"routing":{"ab":"orthogonal"}}`,
+		},
+		{name: "other diagram type", diagramType: "erd", view: emptyView, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &model.Document{DiagramType: tt.diagramType, Version: 4, View: json.RawMessage(tt.view)}
+			update, appErr := dslUpdate(doc, source)
+			if tt.wantErr {
+				if appErr == nil {
+					t.Fatal("import was accepted")
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatalf("dslUpdate: %v", appErr)
+			}
+			if update.Title == nil || *update.Title != "Renamed" {
+				t.Errorf("title = %v, want Renamed", update.Title)
+			}
+			if update.ExpectedVersion == nil || *update.ExpectedVersion != 4 {
+				t.Errorf("expected version = %v, want 4", update.ExpectedVersion)
+			}
+			if update.View == nil || string(*update.View) != emptyView {
+				t.Fatalf("view = %v, want it reset", update.View)
+			}
+			if appErr := validateDocument(doc.DiagramType, *update.Content, *update.View, true); appErr != nil {
+				t.Errorf("imported document does not validate: %v", appErr.Details)
+			}
+		})
+	}
+}
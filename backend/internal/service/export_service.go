@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/dto"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/mermaid"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/render"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/repository"
+)
+
+// Export defaults from the API contract (POST /api/documents/:id/export).
+const (
+	defaultExportScale      = 2.0
+	defaultExportBackground = "#ffffff"
+	defaultExportPadding    = 20
+)
+
+// ExportFile is a rendered document ready to be sent as a download.
+type ExportFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// ExportService renders documents to SVG, PNG and PDF server-side, and
+// writes flowcharts and ERDs as Mermaid text.
+type ExportService struct {
+	docRepo *repository.DocumentRepo
+	wsSvc   *WorkspaceService
+}
+
+// NewExportService creates a new ExportService.
+func NewExportService(docRepo *repository.DocumentRepo, wsSvc *WorkspaceService) *ExportService {
+	return &ExportService{docRepo: docRepo, wsSvc: wsSvc}
+}
+
+// Export renders the current version of a document.
+// Requires workspace membership or a grant on the document.
+func (s *ExportService) Export(ctx context.Context, userID, docID uuid.UUID, req dto.ExportDocumentReq) (*ExportFile, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+
+	background := req.Background
+	if background == "" {
+		background = defaultExportBackground
+	}
+	bg, err := render.ParseColor(background)
+	if err != nil {
+		return nil, pkg.ErrUnprocessable.WithMessage("invalid background color").WithDetails(err.Error())
+	}
+	padding := defaultExportPadding
+	if req.Padding != nil {
+		padding = *req.Padding
+	}
+
+	doc, appErr := s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if _, _, appErr := documentRole(ctx, s.wsSvc, s.docRepo, doc, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	return s.render(doc, req, bg, padding)
+}
+
+// ExportShared renders the document behind a public link as SVG with the
+// default export options. No authentication.
+func (s *ExportService) ExportShared(ctx context.Context, token string) (*ExportFile, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindByShareToken(ctx, token)
+	if appErr != nil {
+		return nil, appErr
+	}
+	bg, _ := render.ParseColor(defaultExportBackground)
+	return s.render(doc, dto.ExportDocumentReq{Format: "svg"}, bg, defaultExportPadding)
+}
+
+// render draws a document in the requested format.
+func (s *ExportService) render(doc *model.Document, req dto.ExportDocumentReq, bg color.NRGBA, padding int) (*ExportFile, *pkg.AppError) {
+	content, appErr := decodeContent(doc.Content)
+	if appErr != nil {
+		return nil, appErr
+	}
+	view, appErr := decodeView(doc.View)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if req.Format == "mermaid" {
+		// The editor keeps positions in the view; they pick the direction.
+		for i, n := range content.Nodes {
+			if p, ok := view.Positions[n.ID]; ok {
+				content.Nodes[i].Position = p
+			}
+		}
+		text, err := mermaid.Serialize(content, doc.DiagramType)
+		if err != nil {
+			return nil, pkg.ErrUnprocessable.WithMessage(err.Error())
+		}
+		return &ExportFile{
+			Filename:    exportFilename(doc.Title, "mmd"),
+			ContentType: "text/plain; charset=utf-8",
+			Data:        []byte(text),
+		}, nil
+	}
+
+	scene := render.Build(content, view, render.Options{Padding: float64(padding), Background: bg})
+
+	switch req.Format {
+	case "svg":
+		return &ExportFile{
+			Filename:    exportFilename(doc.Title, "svg"),
+			ContentType: "image/svg+xml",
+			Data:        render.SVG(scene),
+		}, nil
+	case "png":
+		scale := req.Scale
+		if scale == 0 {
+			scale = defaultExportScale
+		}
+		data, err := render.PNG(scene, scale)
+		if errors.Is(err, render.ErrImageTooLarge) {
+			return nil, pkg.ErrUnprocessable.WithMessage("diagram is too large to export as PNG at this scale")
+		}
+		if err != nil {
+			return nil, pkg.ErrInternal.WithMessage("failed to render PNG").WithDetails(err.Error())
+		}
+		return &ExportFile{
+			Filename:    exportFilename(doc.Title, "png"),
+			ContentType: "image/png",
+			Data:        data,
+		}, nil
+	case "pdf":
+		data, err := render.PDF(scene, render.PDFOptions{
+			PageSize:    req.PageSize,
+			Orientation: req.Orientation,
+			Scale:       req.Scale,
+			Title:       doc.Title,
+			Subtitle:    fmt.Sprintf("Version %d · Updated %s", doc.Version, doc.UpdatedAt.UTC().Format("2006-01-02 15:04 UTC")),
+		})
+		if errors.Is(err, render.ErrTooManyPages) {
+			return nil, pkg.ErrUnprocessable.WithMessage(
+				fmt.Sprintf("diagram needs more than %d pages at this scale; use a larger page size or a smaller scale", render.MaxPDFPages))
+		}
+		if err != nil {
+			return nil, pkg.ErrInternal.WithMessage("failed to render PDF").WithDetails(err.Error())
+		}
+		return &ExportFile{
+			Filename:    exportFilename(doc.Title, "pdf"),
+			ContentType: "application/pdf",
+			Data:        data,
+		}, nil
+	default:
+		return nil, pkg.ErrUnprocessable.WithMessage("unsupported export format " + req.Format)
+	}
+}
+
+// decodeView decodes stored view JSON; a missing view means no overrides.
+func decodeView(raw json.RawMessage) (*document.DocumentView, *pkg.AppError) {
+	var view document.DocumentView
+	if len(raw) == 0 {
+		return &view, nil
+	}
+	if err := json.Unmarshal(raw, &view); err != nil {
+		return nil, pkg.ErrUnprocessable.WithMessage("document view is not valid").WithDetails(err.Error())
+	}
+	return &view, nil
+}
+
+// exportFilename builds "{title}.{ext}", dropping characters that are unsafe in a
+// Content-Disposition filename.
+func exportFilename(title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return -1
+		case strings.ContainsRune(`"\/:*?<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = "diagram"
+	}
+	return name + "." + ext
+}
@@ -116,22 +116,32 @@ func (s *ProjectService) Update(ctx context.Context, userID, projectID uuid.UUID
 	return toProjectResp(proj), nil
 }
 
-// Delete removes a project. Owner role only.
-func (s *ProjectService) Delete(ctx context.Context, userID, projectID uuid.UUID) *pkg.AppError {
+// Delete moves a project and its documents to the trash. Owner role only.
+func (s *ProjectService) Delete(ctx context.Context, userID, projectID uuid.UUID) (*dto.TrashProjectResp, *pkg.AppError) {
 	proj, appErr := s.projectRepo.FindByID(ctx, projectID)
 	if appErr != nil {
-		return appErr
+		return nil, appErr
 	}
 
 	role, appErr := s.wsSvc.RequireMembership(ctx, proj.WorkspaceID, userID)
 	if appErr != nil {
-		return appErr
+		return nil, appErr
 	}
 	if role != "owner" {
-		return pkg.ErrForbidden.WithMessage("only workspace owners can delete projects")
+		return nil, pkg.ErrForbidden.WithMessage("only workspace owners can delete projects")
+	}
+
+	now := time.Now()
+	trashed, appErr := s.projectRepo.Trash(ctx, projectID, userID, now)
+	if appErr != nil {
+		return nil, appErr
 	}
 
-	return s.projectRepo.Delete(ctx, projectID)
+	return &dto.TrashProjectResp{
+		ID:               projectID.String(),
+		TrashedDocuments: trashed,
+		DeletedAt:        now,
+	}, nil
 }
 
 func toProjectResp(p *model.Project) *dto.ProjectResp {
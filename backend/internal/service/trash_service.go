@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/dto"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/repository"
+)
+
+// purgeInterval is how often the purge job looks for expired trash.
+const purgeInterval = time.Hour
+
+// TrashService handles the trash bin: listing, restoring and permanently
+// deleting trashed projects and documents, and purging them once they have
+// been in the trash longer than the retention window.
+type TrashService struct {
+	docRepo   trashedDocuments
+	projRepo  trashedProjects
+	wsSvc     *WorkspaceService
+	retention time.Duration
+	done      chan struct{}
+}
+
+// trashedDocuments is the part of repository.DocumentRepo the trash bin uses.
+type trashedDocuments interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Document, *pkg.AppError)
+	FindTrashed(ctx context.Context, id uuid.UUID) (*model.Document, *pkg.AppError)
+	FindTrashedByWorkspace(ctx context.Context, workspaceID uuid.UUID) ([]model.Document, *pkg.AppError)
+	Restore(ctx context.Context, id uuid.UUID) *pkg.AppError
+	Delete(ctx context.Context, id uuid.UUID) *pkg.AppError
+	DeleteExpired(ctx context.Context, before time.Time) (*repository.CascadeCounts, *pkg.AppError)
+}
+
+// trashedProjects is the part of repository.ProjectRepo the trash bin uses.
+type trashedProjects interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Project, *pkg.AppError)
+	FindTrashed(ctx context.Context, id uuid.UUID) (*model.Project, *pkg.AppError)
+	FindTrashedByWorkspace(ctx context.Context, workspaceID uuid.UUID) ([]model.Project, *pkg.AppError)
+	CountTrashedDocuments(ctx context.Context, projectID uuid.UUID) (int, *pkg.AppError)
+	Restore(ctx context.Context, id uuid.UUID) (int, *pkg.AppError)
+	Delete(ctx context.Context, id uuid.UUID) (*repository.CascadeCounts, *pkg.AppError)
+	DeleteExpired(ctx context.Context, before time.Time) (*repository.CascadeCounts, *pkg.AppError)
+}
+
+// NewTrashService creates a new TrashService that keeps trashed items for retention.
+func NewTrashService(docRepo *repository.DocumentRepo, projRepo *repository.ProjectRepo, wsSvc *WorkspaceService, retention time.Duration) *TrashService {
+	return &TrashService{
+		docRepo:   docRepo,
+		projRepo:  projRepo,
+		wsSvc:     wsSvc,
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+}
+
+// List returns the trashed projects and documents of a workspace, most
+// recently trashed first. Requires membership.
+func (s *TrashService) List(ctx context.Context, userID, workspaceID uuid.UUID) ([]dto.TrashItemResp, *pkg.AppError) {
+	if _, appErr := s.wsSvc.RequireMembership(ctx, workspaceID, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	projects, appErr := s.projRepo.FindTrashedByWorkspace(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	docs, appErr := s.docRepo.FindTrashedByWorkspace(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	items := make([]dto.TrashItemResp, 0, len(projects)+len(docs))
+	for _, p := range projects {
+		count, appErr := s.projRepo.CountTrashedDocuments(ctx, p.ID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		items = append(items, dto.TrashItemResp{
+			Type:          "project",
+			ID:            p.ID.String(),
+			Title:         p.Name,
+			DocumentCount: &count,
+			DeletedBy:     uuidPtrString(p.DeletedBy),
+			DeletedAt:     *p.DeletedAt,
+			PurgeAt:       p.DeletedAt.Add(s.retention),
+		})
+	}
+	for _, d := range docs {
+		diagramType := d.DiagramType
+		items = append(items, dto.TrashItemResp{
+			Type:        "document",
+			ID:          d.ID.String(),
+			Title:       d.Title,
+			DiagramType: &diagramType,
+			ProjectID:   uuidPtrString(d.ProjectID),
+			DeletedBy:   uuidPtrString(d.DeletedBy),
+			DeletedAt:   *d.DeletedAt,
+			PurgeAt:     d.DeletedAt.Add(s.retention),
+		})
+	}
+
+	slices.SortStableFunc(items, func(a, b dto.TrashItemResp) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+	return items, nil
+}
+
+// RestoreDocument takes a document out of the trash. Owner role only.
+// A document whose project is in the trash cannot be restored on its own.
+func (s *TrashService) RestoreDocument(ctx context.Context, userID, docID uuid.UUID) (*dto.DocumentResp, *pkg.AppError) {
+	doc, appErr := s.docRepo.FindTrashed(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr := s.requireOwner(ctx, doc.WorkspaceID, userID, "restore documents"); appErr != nil {
+		return nil, appErr
+	}
+	if doc.TrashedWith != nil {
+		return nil, pkg.ErrConflict.WithMessage("document was deleted with its project; restore the project instead")
+	}
+	if doc.ProjectID != nil {
+		if _, appErr := s.projRepo.FindByID(ctx, *doc.ProjectID); appErr != nil {
+			if appErr.Code == pkg.ErrNotFound.Code {
+				return nil, pkg.ErrConflict.WithMessage("the document's project is in the trash; restore it first")
+			}
+			return nil, appErr
+		}
+	}
+
+	if appErr := s.docRepo.Restore(ctx, docID); appErr != nil {
+		return nil, appErr
+	}
+	doc, appErr = s.docRepo.FindByID(ctx, docID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return toDocumentResp(doc), nil
+}
+
+// RestoreProject takes a project and the documents trashed with it out of
+// the trash. Owner role only.
+func (s *TrashService) RestoreProject(ctx context.Context, userID, projectID uuid.UUID) (*dto.RestoreProjectResp, *pkg.AppError) {
+	proj, appErr := s.projRepo.FindTrashed(ctx, projectID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr := s.requireOwner(ctx, proj.WorkspaceID, userID, "restore projects"); appErr != nil {
+		return nil, appErr
+	}
+
+	restored, appErr := s.projRepo.Restore(ctx, projectID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	proj, appErr = s.projRepo.FindByID(ctx, projectID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &dto.RestoreProjectResp{Project: toProjectResp(proj), RestoredDocuments: restored}, nil
+}
+
+// PurgeDocument permanently deletes a trashed document with its version
+// history, grants and share link. Owner role only.
+func (s *TrashService) PurgeDocument(ctx context.Context, userID, docID uuid.UUID) *pkg.AppError {
+	doc, appErr := s.docRepo.FindTrashed(ctx, docID)
+	if appErr != nil {
+		return appErr
+	}
+	if appErr := s.requireOwner(ctx, doc.WorkspaceID, userID, "permanently delete documents"); appErr != nil {
+		return appErr
+	}
+	return s.docRepo.Delete(ctx, docID)
+}
+
+// PurgeProject permanently deletes a trashed project with the documents
+// trashed with it and reports how many records were removed. Owner role only.
+func (s *TrashService) PurgeProject(ctx context.Context, userID, projectID uuid.UUID) (*dto.DeleteProjectResp, *pkg.AppError) {
+	proj, appErr := s.projRepo.FindTrashed(ctx, projectID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr := s.requireOwner(ctx, proj.WorkspaceID, userID, "permanently delete projects"); appErr != nil {
+		return nil, appErr
+	}
+
+	counts, appErr := s.projRepo.Delete(ctx, projectID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return &dto.DeleteProjectResp{
+		ID:               projectID.String(),
+		Documents:        counts.Documents,
+		DocumentVersions: counts.Versions,
+		DocumentGrants:   counts.Grants,
+		DocumentShares:   counts.Shares,
+	}, nil
+}
+
+// PurgeExpired permanently deletes everything that has been in the trash
+// longer than the retention window.
+func (s *TrashService) PurgeExpired(ctx context.Context) *pkg.AppError {
+	cutoff := time.Now().Add(-s.retention)
+
+	projCounts, appErr := s.projRepo.DeleteExpired(ctx, cutoff)
+	if appErr != nil {
+		return appErr
+	}
+	docCounts, appErr := s.docRepo.DeleteExpired(ctx, cutoff)
+	if appErr != nil {
+		return appErr
+	}
+
+	if projCounts.Projects > 0 || docCounts.Documents > 0 {
+		log.Printf("[Trash] purged %d projects and %d documents trashed before %s",
+			projCounts.Projects, projCounts.Documents+docCounts.Documents, cutoff.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// StartPurge runs PurgeExpired now and then every purgeInterval until Close.
+// A non-positive retention disables the job.
+func (s *TrashService) StartPurge() {
+	if s.retention <= 0 {
+		log.Printf("[Trash] retention is not positive, purge job disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(purgeInterval)
+		defer ticker.Stop()
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			if appErr := s.PurgeExpired(ctx); appErr != nil {
+				log.Printf("[Trash] purge failed: %v", appErr)
+			}
+			cancel()
+
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the purge job.
+func (s *TrashService) Close() {
+	close(s.done)
+}
+
+// requireOwner checks that the user owns the workspace of a trashed item.
+func (s *TrashService) requireOwner(ctx context.Context, workspaceID, userID uuid.UUID, action string) *pkg.AppError {
+	role, appErr := s.wsSvc.RequireMembership(ctx, workspaceID, userID)
+	if appErr != nil {
+		return appErr
+	}
+	if role != "owner" {
+		return pkg.ErrForbidden.WithMessage("only workspace owners can " + action)
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/repository"
+)
+
+// fakeTrash keeps projects and documents in memory and implements the
+// repository filters the trash bin relies on. docs and projects expose it
+// as trashedDocuments and trashedProjects.
+type fakeTrash struct {
+	mu       sync.Mutex
+	docs     map[uuid.UUID]*model.Document
+	projects map[uuid.UUID]*model.Project
+	cutoffs  chan time.Time // receives the cutoff of every DeleteExpired call
+}
+
+func newFakeTrash() *fakeTrash {
+	return &fakeTrash{
+		docs:     map[uuid.UUID]*model.Document{},
+		projects: map[uuid.UUID]*model.Project{},
+		cutoffs:  make(chan time.Time, 16),
+	}
+}
+
+type fakeTrashDocs struct{ *fakeTrash }
+type fakeTrashProjects struct{ *fakeTrash }
+
+func (f fakeTrashDocs) FindByID(_ context.Context, id uuid.UUID) (*model.Document, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	doc, ok := f.docs[id]
+	if !ok || doc.DeletedAt != nil {
+		return nil, pkg.ErrNotFound.WithMessage("document not found")
+	}
+	copied := *doc
+	return &copied, nil
+}
+
+func (f fakeTrashDocs) FindTrashed(_ context.Context, id uuid.UUID) (*model.Document, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	doc, ok := f.docs[id]
+	if !ok || doc.DeletedAt == nil {
+		return nil, pkg.ErrNotFound.WithMessage("trashed document not found")
+	}
+	copied := *doc
+	return &copied, nil
+}
+
+func (f fakeTrashDocs) FindTrashedByWorkspace(_ context.Context, workspaceID uuid.UUID) ([]model.Document, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.Document
+	for _, doc := range f.docs {
+		if doc.WorkspaceID == workspaceID && doc.DeletedAt != nil && doc.TrashedWith == nil {
+			out = append(out, *doc)
+		}
+	}
+	return out, nil
+}
+
+func (f fakeTrashDocs) Restore(_ context.Context, id uuid.UUID) *pkg.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	doc, ok := f.docs[id]
+	if !ok || doc.DeletedAt == nil {
+		return pkg.ErrNotFound.WithMessage("trashed document not found")
+	}
+	doc.DeletedAt, doc.DeletedBy, doc.TrashedWith = nil, nil, nil
+	return nil
+}
+
+func (f fakeTrashDocs) Delete(_ context.Context, id uuid.UUID) *pkg.AppError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.docs[id]; !ok {
+		return pkg.ErrNotFound.WithMessage("document not found")
+	}
+	delete(f.docs, id)
+	return nil
+}
+
+func (f fakeTrashDocs) DeleteExpired(_ context.Context, before time.Time) (*repository.CascadeCounts, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := new(repository.CascadeCounts)
+	for id, doc := range f.docs {
+		if doc.DeletedAt != nil && doc.DeletedAt.Before(before) && doc.TrashedWith == nil {
+			delete(f.docs, id)
+			counts.Documents++
+		}
+	}
+	f.cutoffs <- before
+	return counts, nil
+}
+
+func (f fakeTrashProjects) FindByID(_ context.Context, id uuid.UUID) (*model.Project, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	proj, ok := f.projects[id]
+	if !ok || proj.DeletedAt != nil {
+		return nil, pkg.ErrNotFound.WithMessage("project not found")
+	}
+	copied := *proj
+	return &copied, nil
+}
+
+func (f fakeTrashProjects) FindTrashed(_ context.Context, id uuid.UUID) (*model.Project, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	proj, ok := f.projects[id]
+	if !ok || proj.DeletedAt == nil {
+		return nil, pkg.ErrNotFound.WithMessage("trashed project not found")
+	}
+	copied := *proj
+	return &copied, nil
+}
+
+func (f fakeTrashProjects) FindTrashedByWorkspace(_ context.Context, workspaceID uuid.UUID) ([]model.Project, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []model.Project
+	for _, proj := range f.projects {
+		if proj.WorkspaceID == workspaceID && proj.DeletedAt != nil {
+			out = append(out, *proj)
+		}
+	}
+	return out, nil
+}
+
+func (f fakeTrashProjects) CountTrashedDocuments(_ context.Context, projectID uuid.UUID) (int, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, doc := range f.docs {
+		if doc.TrashedWith != nil && *doc.TrashedWith == projectID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f fakeTrashProjects) Restore(_ context.Context, id uuid.UUID) (int, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	proj, ok := f.projects[id]
+	if !ok || proj.DeletedAt == nil {
+		return 0, pkg.ErrNotFound.WithMessage("trashed project not found")
+	}
+	proj.DeletedAt, proj.DeletedBy = nil, nil
+	restored := 0
+	for _, doc := range f.docs {
+		if doc.TrashedWith != nil && *doc.TrashedWith == id {
+			doc.DeletedAt, doc.DeletedBy, doc.TrashedWith = nil, nil, nil
+			restored++
+		}
+	}
+	return restored, nil
+}
+
+func (f fakeTrashProjects) Delete(_ context.Context, id uuid.UUID) (*repository.CascadeCounts, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	proj, ok := f.projects[id]
+	if !ok || proj.DeletedAt == nil {
+		return nil, pkg.ErrNotFound.WithMessage("project not found in trash")
+	}
+	delete(f.projects, id)
+	return f.purgeDocuments([]uuid.UUID{id}), nil
+}
+
+func (f fakeTrashProjects) DeleteExpired(_ context.Context, before time.Time) (*repository.CascadeCounts, *pkg.AppError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []uuid.UUID
+	for id, proj := range f.projects {
+		if proj.DeletedAt != nil && proj.DeletedAt.Before(before) {
+			delete(f.projects, id)
+			ids = append(ids, id)
+		}
+	}
+	counts := f.purgeDocuments(ids)
+	counts.Projects = len(ids)
+	f.cutoffs <- before
+	return counts, nil
+}
+
+// purgeDocuments mirrors ProjectRepo.purgeDocuments: documents trashed with
+// the projects go, the projects' other documents lose their project.
+func (f fakeTrashProjects) purgeDocuments(projectIDs []uuid.UUID) *repository.CascadeCounts {
+	counts := new(repository.CascadeCounts)
+	for id, doc := range f.docs {
+		switch {
+		case doc.TrashedWith != nil && slices.Contains(projectIDs, *doc.TrashedWith):
+			delete(f.docs, id)
+			counts.Documents++
+		case doc.ProjectID != nil && slices.Contains(projectIDs, *doc.ProjectID):
+			doc.ProjectID = nil
+		}
+	}
+	return counts
+}
+
+// trashFixture is a workspace with an owner and an editor, a trashed
+// project holding one document trashed with it, a document from that
+// project trashed on its own before it, and a live project with a
+// document trashed on its own.
+type trashFixture struct {
+	svc                        *TrashService
+	store                      *fakeTrash
+	workspaceID, owner, editor uuid.UUID
+	trashedProject, liveProj   uuid.UUID
+	docWithProject             uuid.UUID // trashed with trashedProject
+	docInTrashedProject        uuid.UUID // trashed on its own, project trashed later
+	docInLiveProject           uuid.UUID // trashed on its own
+}
+
+func newTrashFixture(retention time.Duration) *trashFixture {
+	wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+	f := &trashFixture{store: newFakeTrash()}
+	f.owner, f.editor = users.addUser("owner@example.com"), users.addUser("editor@example.com")
+	f.workspaceID = wsRepo.addWorkspace(f.owner, map[uuid.UUID]string{f.editor: "editor"})
+
+	at := func(ago time.Duration) *time.Time {
+		t := time.Now().Add(-ago)
+		return &t
+	}
+	f.trashedProject, f.liveProj = uuid.New(), uuid.New()
+	f.store.projects[f.trashedProject] = &model.Project{ID: f.trashedProject, WorkspaceID: f.workspaceID, Name: "Old", DeletedAt: at(time.Hour), DeletedBy: &f.owner}
+	f.store.projects[f.liveProj] = &model.Project{ID: f.liveProj, WorkspaceID: f.workspaceID, Name: "Live"}
+
+	f.docWithProject, f.docInTrashedProject, f.docInLiveProject = uuid.New(), uuid.New(), uuid.New()
+	f.store.docs[f.docWithProject] = &model.Document{ID: f.docWithProject, ProjectID: &f.trashedProject, WorkspaceID: f.workspaceID,
+		Title: "With project", DiagramType: "flowchart", DeletedAt: at(time.Hour), DeletedBy: &f.owner, TrashedWith: &f.trashedProject}
+	f.store.docs[f.docInTrashedProject] = &model.Document{ID: f.docInTrashedProject, ProjectID: &f.trashedProject, WorkspaceID: f.workspaceID,
+		Title: "Before project", DiagramType: "flowchart", DeletedAt: at(2 * time.Hour), DeletedBy: &f.editor}
+	f.store.docs[f.docInLiveProject] = &model.Document{ID: f.docInLiveProject, ProjectID: &f.liveProj, WorkspaceID: f.workspaceID,
+		Title: "Alone", DiagramType: "erd", DeletedAt: at(time.Minute), DeletedBy: &f.editor}
+
+	wsSvc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+	f.svc = &TrashService{
+		docRepo:   fakeTrashDocs{f.store},
+		projRepo:  fakeTrashProjects{f.store},
+		wsSvc:     wsSvc,
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+	return f
+}
+
+func TestTrashList(t *testing.T) {
+	f := newTrashFixture(30 * 24 * time.Hour)
+
+	items, appErr := f.svc.List(context.Background(), f.editor, f.workspaceID)
+	checkErr(t, appErr, nil)
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Type+" "+item.Title)
+		if !item.PurgeAt.Equal(item.DeletedAt.Add(30 * 24 * time.Hour)) {
+			t.Errorf("%s: purge_at = %s, deleted_at = %s", item.Title, item.PurgeAt, item.DeletedAt)
+		}
+		if item.Type == "project" && (item.DocumentCount == nil || *item.DocumentCount != 1) {
+			t.Errorf("project document count = %v, want 1", item.DocumentCount)
+		}
+	}
+	want := []string{"document Alone", "project Old", "document Before project"}
+	if !slices.Equal(got, want) {
+		t.Errorf("trash = %v, want %v (newest first, without documents trashed with their project)", got, want)
+	}
+
+	_, appErr = f.svc.List(context.Background(), uuid.New(), f.workspaceID)
+	checkErr(t, appErr, pkg.ErrForbidden)
+}
+
+func TestRestoreDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     func(*trashFixture) uuid.UUID
+		userID  func(*trashFixture) uuid.UUID
+		wantErr *pkg.AppError
+	}{
+		{name: "trashed on its own", doc: func(f *trashFixture) uuid.UUID { return f.docInLiveProject }},
+		{name: "trashed with its project", doc: func(f *trashFixture) uuid.UUID { return f.docWithProject }, wantErr: pkg.ErrConflict},
+		{name: "its project was trashed later", doc: func(f *trashFixture) uuid.UUID { return f.docInTrashedProject }, wantErr: pkg.ErrConflict},
+		{name: "not the owner", doc: func(f *trashFixture) uuid.UUID { return f.docInLiveProject },
+			userID: func(f *trashFixture) uuid.UUID { return f.editor }, wantErr: pkg.ErrForbidden},
+		{name: "not in the trash", doc: func(*trashFixture) uuid.UUID { return uuid.New() }, wantErr: pkg.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTrashFixture(time.Hour)
+			userID := f.owner
+			if tt.userID != nil {
+				userID = tt.userID(f)
+			}
+			docID := tt.doc(f)
+
+			resp, appErr := f.svc.RestoreDocument(context.Background(), userID, docID)
+			checkErr(t, appErr, tt.wantErr)
+			if appErr != nil {
+				if doc, ok := f.store.docs[docID]; ok && doc.DeletedAt == nil {
+					t.Error("rejected restore took the document out of the trash")
+				}
+				return
+			}
+			if resp.ID != docID.String() || f.store.docs[docID].DeletedAt != nil {
+				t.Errorf("document %s is still in the trash", docID)
+			}
+		})
+	}
+}
+
+func TestRestoreProject(t *testing.T) {
+	f := newTrashFixture(time.Hour)
+
+	_, appErr := f.svc.RestoreProject(context.Background(), f.editor, f.trashedProject)
+	checkErr(t, appErr, pkg.ErrForbidden)
+
+	resp, appErr := f.svc.RestoreProject(context.Background(), f.owner, f.trashedProject)
+	checkErr(t, appErr, nil)
+	if resp.RestoredDocuments != 1 || f.store.docs[f.docWithProject].DeletedAt != nil {
+		t.Errorf("restored %d documents, want the one trashed with the project", resp.RestoredDocuments)
+	}
+	if f.store.docs[f.docInTrashedProject].DeletedAt == nil {
+		t.Error("document trashed on its own was restored with the project")
+	}
+
+	// with its project back, the document trashed before it can come back too
+	_, appErr = f.svc.RestoreDocument(context.Background(), f.owner, f.docInTrashedProject)
+	checkErr(t, appErr, nil)
+}
+
+func TestPurgeProject(t *testing.T) {
+	f := newTrashFixture(time.Hour)
+
+	_, appErr := f.svc.PurgeProject(context.Background(), f.editor, f.trashedProject)
+	checkErr(t, appErr, pkg.ErrForbidden)
+	_, appErr = f.svc.PurgeProject(context.Background(), f.owner, f.liveProj)
+	checkErr(t, appErr, pkg.ErrNotFound)
+
+	resp, appErr := f.svc.PurgeProject(context.Background(), f.owner, f.trashedProject)
+	checkErr(t, appErr, nil)
+	if resp.Documents != 1 {
+		t.Errorf("purged %d documents, want 1", resp.Documents)
+	}
+	if _, ok := f.store.docs[f.docWithProject]; ok {
+		t.Error("document trashed with the project survived")
+	}
+	doc, ok := f.store.docs[f.docInTrashedProject]
+	if !ok || doc.DeletedAt == nil || doc.ProjectID != nil {
+		t.Errorf("document trashed on its own = %+v, want it kept in the trash without a project", doc)
+	}
+}
+
+func TestPurgeDocument(t *testing.T) {
+	f := newTrashFixture(time.Hour)
+
+	checkErr(t, f.svc.PurgeDocument(context.Background(), f.editor, f.docInLiveProject), pkg.ErrForbidden)
+	checkErr(t, f.svc.PurgeDocument(context.Background(), f.owner, f.docInLiveProject), nil)
+	if _, ok := f.store.docs[f.docInLiveProject]; ok {
+		t.Error("purged document is still stored")
+	}
+	checkErr(t, f.svc.PurgeDocument(context.Background(), f.owner, f.docInLiveProject), pkg.ErrNotFound)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	// Trashed 1m, 1h and 2h ago; only what is older than the retention goes.
+	f := newTrashFixture(90 * time.Minute)
+
+	before := time.Now()
+	checkErr(t, f.svc.PurgeExpired(context.Background()), nil)
+	after := time.Now()
+
+	for range 2 {
+		cutoff := <-f.store.cutoffs
+		if cutoff.Before(before.Add(-90*time.Minute)) || cutoff.After(after.Add(-90*time.Minute)) {
+			t.Errorf("cutoff = %s, want now - retention", cutoff)
+		}
+	}
+	if _, ok := f.store.docs[f.docInTrashedProject]; ok {
+		t.Error("document trashed 2h ago survived a 90m retention")
+	}
+	if _, ok := f.store.projects[f.trashedProject]; !ok {
+		t.Error("project trashed 1h ago was purged with a 90m retention")
+	}
+	if _, ok := f.store.docs[f.docWithProject]; !ok {
+		t.Error("document trashed with a kept project was purged")
+	}
+	if _, ok := f.store.docs[f.docInLiveProject]; !ok {
+		t.Error("document trashed 1m ago was purged")
+	}
+}
+
+func TestStartPurge(t *testing.T) {
+	t.Run("runs right away", func(t *testing.T) {
+		f := newTrashFixture(30 * time.Minute)
+		f.svc.StartPurge()
+		defer f.svc.Close()
+
+		for range 2 {
+			select {
+			case <-f.store.cutoffs:
+			case <-time.After(time.Second):
+				t.Fatal("purge job did not run on start")
+			}
+		}
+		f.store.mu.Lock()
+		defer f.store.mu.Unlock()
+		if len(f.store.projects) != 1 || len(f.store.docs) != 1 {
+			t.Errorf("left %d projects and %d documents, want 1 and 1", len(f.store.projects), len(f.store.docs))
+		}
+	})
+
+	for _, retention := range []time.Duration{0, -time.Hour} {
+		t.Run("disabled with retention "+retention.String(), func(t *testing.T) {
+			f := newTrashFixture(retention)
+			f.svc.StartPurge()
+			defer f.svc.Close()
+
+			select {
+			case <-f.store.cutoffs:
+				t.Fatal("purge job ran with a non-positive retention")
+			case <-time.After(50 * time.Millisecond):
+			}
+			if len(f.store.docs) != 3 {
+				t.Errorf("%d documents left, want 3", len(f.store.docs))
+			}
+		})
+	}
+}
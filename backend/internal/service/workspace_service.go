@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,14 +14,62 @@ import (
 	"github.com/RenzIP/Graphic-Diagram-Online/internal/repository"
 )
 
+// inviteTTL is how long an email invite can be accepted.
+const inviteTTL = 7 * 24 * time.Hour
+
+// transferTTL is how long the new owner has to accept an ownership transfer.
+const transferTTL = 7 * 24 * time.Hour
+
 // WorkspaceService handles workspace business logic with authorization.
 type WorkspaceService struct {
-	wsRepo *repository.WorkspaceRepo
+	wsRepo      workspaceStore
+	userRepo    userStore
+	adminEmails []string
 }
 
-// NewWorkspaceService creates a new WorkspaceService.
-func NewWorkspaceService(wsRepo *repository.WorkspaceRepo) *WorkspaceService {
-	return &WorkspaceService{wsRepo: wsRepo}
+// workspaceStore is the part of repository.WorkspaceRepo the service uses.
+type workspaceStore interface {
+	FindByMember(ctx context.Context, userID uuid.UUID, limit, offset int) ([]model.Workspace, int, *pkg.AppError)
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Workspace, *pkg.AppError)
+	FindBySlug(ctx context.Context, slug string) (*model.Workspace, *pkg.AppError)
+	Update(ctx context.Context, ws *model.Workspace) *pkg.AppError
+	Delete(ctx context.Context, id uuid.UUID) (*repository.CascadeCounts, *pkg.AppError)
+	InsertWithOwner(ctx context.Context, ws *model.Workspace, member *model.WorkspaceMember) *pkg.AppError
+
+	GetMemberRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, *pkg.AppError)
+	FindMembers(ctx context.Context, workspaceID uuid.UUID) ([]model.WorkspaceMember, *pkg.AppError)
+	FindMember(ctx context.Context, workspaceID, userID uuid.UUID) (*model.WorkspaceMember, *pkg.AppError)
+	UpdateMemberRole(ctx context.Context, workspaceID, userID uuid.UUID, role string) *pkg.AppError
+	DeleteMember(ctx context.Context, workspaceID, userID uuid.UUID) *pkg.AppError
+	CountMembers(ctx context.Context, workspaceID uuid.UUID) (int, *pkg.AppError)
+
+	InsertInvite(ctx context.Context, invite *model.WorkspaceInvite) *pkg.AppError
+	FindInviteByToken(ctx context.Context, token string) (*model.WorkspaceInvite, *pkg.AppError)
+	AcceptInvite(ctx context.Context, invite *model.WorkspaceInvite, member *model.WorkspaceMember) *pkg.AppError
+
+	InsertInviteLink(ctx context.Context, link *model.WorkspaceInviteLink) *pkg.AppError
+	FindInviteLinks(ctx context.Context, workspaceID uuid.UUID) ([]model.WorkspaceInviteLink, *pkg.AppError)
+	FindInviteLinkByToken(ctx context.Context, token string) (*model.WorkspaceInviteLink, *pkg.AppError)
+	RevokeInviteLink(ctx context.Context, workspaceID, linkID uuid.UUID, at time.Time) *pkg.AppError
+	UseInviteLink(ctx context.Context, link *model.WorkspaceInviteLink, member *model.WorkspaceMember) *pkg.AppError
+
+	SetTransfer(ctx context.Context, transfer *model.WorkspaceTransfer) *pkg.AppError
+	FindTransfer(ctx context.Context, workspaceID uuid.UUID) (*model.WorkspaceTransfer, *pkg.AppError)
+	DeleteTransfer(ctx context.Context, workspaceID uuid.UUID) *pkg.AppError
+	TransferOwnership(ctx context.Context, workspaceID, fromID, toID uuid.UUID, previousOwnerRole string, at time.Time) *pkg.AppError
+}
+
+// userStore is the part of repository.UserRepo the service uses.
+type userStore interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*model.UserProfile, *pkg.AppError)
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]model.UserProfile, *pkg.AppError)
+	FindByEmail(ctx context.Context, email string) (*model.UserProfile, *pkg.AppError)
+}
+
+// NewWorkspaceService creates a new WorkspaceService. Users whose email is in
+// adminEmails may force ownership transfers.
+func NewWorkspaceService(wsRepo *repository.WorkspaceRepo, userRepo *repository.UserRepo, adminEmails []string) *WorkspaceService {
+	return &WorkspaceService{wsRepo: wsRepo, userRepo: userRepo, adminEmails: adminEmails}
 }
 
 // ListByUser returns paginated workspaces the user belongs to.
@@ -134,18 +184,39 @@ func (s *WorkspaceService) Update(ctx context.Context, userID, workspaceID uuid.
 	return toWorkspaceResp(ws), nil
 }
 
-// Delete removes a workspace. Owner only. CASCADE removes projects/documents.
-func (s *WorkspaceService) Delete(ctx context.Context, userID, workspaceID uuid.UUID) *pkg.AppError {
+// Delete removes a workspace with its members, invites, projects and
+// documents, and reports how many of each were removed. Owner only.
+func (s *WorkspaceService) Delete(ctx context.Context, userID, workspaceID uuid.UUID) (*dto.DeleteWorkspaceResp, *pkg.AppError) {
 	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
 	if appErr != nil {
-		return appErr
+		return nil, appErr
 	}
 
 	if ws.OwnerID != userID {
-		return pkg.ErrForbidden.WithMessage("only the workspace owner can delete it")
+		return nil, pkg.ErrForbidden.WithMessage("only the workspace owner can delete it")
+	}
+
+	counts, appErr := s.wsRepo.Delete(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
 	}
 
-	return s.wsRepo.Delete(ctx, workspaceID)
+	return &dto.DeleteWorkspaceResp{
+		ID:               workspaceID.String(),
+		Members:          counts.Members,
+		Invites:          counts.Invites,
+		InviteLinks:      counts.InviteLinks,
+		Projects:         counts.Projects,
+		Documents:        counts.Documents,
+		DocumentVersions: counts.Versions,
+		DocumentGrants:   counts.Grants,
+		DocumentShares:   counts.Shares,
+	}, nil
+}
+
+// MemberRole returns the user's role in the workspace, or empty string if not a member.
+func (s *WorkspaceService) MemberRole(ctx context.Context, workspaceID, userID uuid.UUID) (string, *pkg.AppError) {
+	return s.wsRepo.GetMemberRole(ctx, workspaceID, userID)
 }
 
 // RequireMembership checks that the user is a member of the workspace and returns the role.
@@ -161,6 +232,333 @@ func (s *WorkspaceService) RequireMembership(ctx context.Context, workspaceID, u
 	return role, nil
 }
 
+// requireOwner loads a workspace and checks that the user owns it.
+func (s *WorkspaceService) requireOwner(ctx context.Context, workspaceID, userID uuid.UUID, action string) (*model.Workspace, *pkg.AppError) {
+	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if ws.OwnerID != userID {
+		return nil, pkg.ErrForbidden.WithMessage("only the workspace owner can " + action)
+	}
+	return ws, nil
+}
+
+// ListMembers returns the members of a workspace with their profiles. Any member may list them.
+func (s *WorkspaceService) ListMembers(ctx context.Context, userID, workspaceID uuid.UUID) ([]dto.WorkspaceMemberResp, *pkg.AppError) {
+	if _, appErr := s.RequireMembership(ctx, workspaceID, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	members, appErr := s.wsRepo.FindMembers(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	ids := make([]uuid.UUID, len(members))
+	for i, m := range members {
+		ids[i] = m.UserID
+	}
+	users, appErr := s.userRepo.FindByIDs(ctx, ids)
+	if appErr != nil {
+		return nil, appErr
+	}
+	profiles := make(map[uuid.UUID]*model.UserProfile, len(users))
+	for i := range users {
+		profiles[users[i].ID] = &users[i]
+	}
+
+	items := make([]dto.WorkspaceMemberResp, 0, len(members))
+	for _, m := range members {
+		items = append(items, toMemberResp(&m, profiles[m.UserID]))
+	}
+	return items, nil
+}
+
+// InviteMember creates a pending invite for an email address. Owner only.
+// Inviting the same email again replaces its pending invite.
+func (s *WorkspaceService) InviteMember(ctx context.Context, userID, workspaceID uuid.UUID, req dto.InviteMemberReq) (*dto.WorkspaceInviteResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	if _, appErr := s.requireOwner(ctx, workspaceID, userID, "invite members"); appErr != nil {
+		return nil, appErr
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if user, _ := s.userRepo.FindByEmail(ctx, email); user != nil {
+		role, appErr := s.wsRepo.GetMemberRole(ctx, workspaceID, user.ID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if role != "" {
+			return nil, pkg.ErrConflict.WithMessage(email + " is already a member of this workspace")
+		}
+	}
+
+	token, err := pkg.GenerateToken()
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to generate invite token")
+	}
+	now := time.Now()
+	invite := &model.WorkspaceInvite{
+		ID:          uuid.New(),
+		WorkspaceID: workspaceID,
+		Email:       email,
+		Role:        req.Role,
+		Token:       token,
+		InvitedBy:   userID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(inviteTTL),
+	}
+	if appErr := s.wsRepo.InsertInvite(ctx, invite); appErr != nil {
+		return nil, appErr
+	}
+
+	return &dto.WorkspaceInviteResp{
+		ID:          invite.ID.String(),
+		WorkspaceID: invite.WorkspaceID.String(),
+		Email:       invite.Email,
+		Role:        invite.Role,
+		Token:       invite.Token,
+		CreatedAt:   invite.CreatedAt,
+		ExpiresAt:   invite.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvite adds the user to the invite's workspace with the invited role.
+// The invite must be pending, unexpired and addressed to the user's email.
+func (s *WorkspaceService) AcceptInvite(ctx context.Context, userID uuid.UUID, token string) (*dto.WorkspaceListItem, *pkg.AppError) {
+	invite, appErr := s.wsRepo.FindInviteByToken(ctx, token)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if invite.AcceptedAt != nil {
+		return nil, pkg.ErrConflict.WithMessage("invite has already been accepted")
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		return nil, pkg.ErrNotFound.WithMessage("invite has expired")
+	}
+
+	user, appErr := s.userRepo.FindByID(ctx, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !strings.EqualFold(user.Email, invite.Email) {
+		return nil, pkg.ErrForbidden.WithMessage("this invite was sent to a different email address")
+	}
+
+	role, appErr := s.wsRepo.GetMemberRole(ctx, invite.WorkspaceID, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role != "" {
+		return nil, pkg.ErrConflict.WithMessage("you are already a member of this workspace")
+	}
+
+	member := &model.WorkspaceMember{
+		WorkspaceID: invite.WorkspaceID,
+		UserID:      userID,
+		Role:        invite.Role,
+		JoinedAt:    time.Now(),
+	}
+	if appErr := s.wsRepo.AcceptInvite(ctx, invite, member); appErr != nil {
+		return nil, appErr
+	}
+
+	return s.membershipItem(ctx, invite.WorkspaceID, member.Role)
+}
+
+// UpdateMemberRole changes a member's role. Owner only; the owner's own
+// role cannot be changed.
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, userID, workspaceID, memberID uuid.UUID, req dto.UpdateMemberRoleReq) (*dto.WorkspaceMemberResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	ws, appErr := s.requireOwner(ctx, workspaceID, userID, "change member roles")
+	if appErr != nil {
+		return nil, appErr
+	}
+	if memberID == ws.OwnerID {
+		return nil, pkg.ErrForbidden.WithMessage("the workspace owner's role cannot be changed")
+	}
+
+	if appErr := s.wsRepo.UpdateMemberRole(ctx, workspaceID, memberID, req.Role); appErr != nil {
+		return nil, appErr
+	}
+
+	member, appErr := s.wsRepo.FindMember(ctx, workspaceID, memberID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	profile, _ := s.userRepo.FindByID(ctx, memberID)
+	resp := toMemberResp(member, profile)
+	return &resp, nil
+}
+
+// RemoveMember removes a user from a workspace. The owner may remove anyone
+// but themselves; other members may only remove themselves (leave).
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID, workspaceID, memberID uuid.UUID) *pkg.AppError {
+	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
+	if appErr != nil {
+		return appErr
+	}
+	if memberID == ws.OwnerID {
+		return pkg.ErrForbidden.WithMessage("the workspace owner cannot be removed")
+	}
+	if memberID != userID && ws.OwnerID != userID {
+		return pkg.ErrForbidden.WithMessage("only the workspace owner can remove members")
+	}
+
+	return s.wsRepo.DeleteMember(ctx, workspaceID, memberID)
+}
+
+// CreateInviteLink creates a shareable link that lets anyone holding it join
+// with the chosen role. Owner only.
+func (s *WorkspaceService) CreateInviteLink(ctx context.Context, userID, workspaceID uuid.UUID, req dto.CreateInviteLinkReq) (*dto.InviteLinkResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	if _, appErr := s.requireOwner(ctx, workspaceID, userID, "create invite links"); appErr != nil {
+		return nil, appErr
+	}
+
+	ttl := inviteTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	token, err := pkg.GenerateToken()
+	if err != nil {
+		return nil, pkg.ErrInternal.WithMessage("failed to generate invite token")
+	}
+	now := time.Now()
+	link := &model.WorkspaceInviteLink{
+		ID:          uuid.New(),
+		WorkspaceID: workspaceID,
+		Token:       token,
+		Role:        req.Role,
+		MaxUses:     req.MaxUses,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	if appErr := s.wsRepo.InsertInviteLink(ctx, link); appErr != nil {
+		return nil, appErr
+	}
+
+	return toInviteLinkResp(link), nil
+}
+
+// ListInviteLinks returns the workspace's invite links that were not revoked. Owner only.
+func (s *WorkspaceService) ListInviteLinks(ctx context.Context, userID, workspaceID uuid.UUID) ([]dto.InviteLinkResp, *pkg.AppError) {
+	if _, appErr := s.requireOwner(ctx, workspaceID, userID, "view invite links"); appErr != nil {
+		return nil, appErr
+	}
+
+	links, appErr := s.wsRepo.FindInviteLinks(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	items := make([]dto.InviteLinkResp, 0, len(links))
+	for i := range links {
+		items = append(items, *toInviteLinkResp(&links[i]))
+	}
+	return items, nil
+}
+
+// RevokeInviteLink disables an invite link. Owner only. Members who
+// already joined through it stay.
+func (s *WorkspaceService) RevokeInviteLink(ctx context.Context, userID, workspaceID, linkID uuid.UUID) *pkg.AppError {
+	if _, appErr := s.requireOwner(ctx, workspaceID, userID, "revoke invite links"); appErr != nil {
+		return appErr
+	}
+	return s.wsRepo.RevokeInviteLink(ctx, workspaceID, linkID, time.Now())
+}
+
+// JoinByInviteLink adds the user to the link's workspace with the link's role.
+func (s *WorkspaceService) JoinByInviteLink(ctx context.Context, userID uuid.UUID, token string) (*dto.WorkspaceListItem, *pkg.AppError) {
+	link, appErr := s.wsRepo.FindInviteLinkByToken(ctx, token)
+	if appErr != nil {
+		return nil, appErr
+	}
+	now := time.Now()
+	if link.RevokedAt != nil || now.After(link.ExpiresAt) || (link.MaxUses != nil && link.Uses >= *link.MaxUses) {
+		return nil, pkg.ErrNotFound.WithMessage("invite link is no longer valid")
+	}
+
+	role, appErr := s.wsRepo.GetMemberRole(ctx, link.WorkspaceID, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role != "" {
+		return nil, pkg.ErrConflict.WithMessage("you are already a member of this workspace")
+	}
+
+	member := &model.WorkspaceMember{
+		WorkspaceID: link.WorkspaceID,
+		UserID:      userID,
+		Role:        link.Role,
+		JoinedAt:    now,
+	}
+	if appErr := s.wsRepo.UseInviteLink(ctx, link, member); appErr != nil {
+		return nil, appErr
+	}
+
+	return s.membershipItem(ctx, link.WorkspaceID, member.Role)
+}
+
+// membershipItem describes a workspace from the point of view of a member with role.
+func (s *WorkspaceService) membershipItem(ctx context.Context, workspaceID uuid.UUID, role string) (*dto.WorkspaceListItem, *pkg.AppError) {
+	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	memberCount, appErr := s.wsRepo.CountMembers(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return &dto.WorkspaceListItem{
+		ID:          ws.ID.String(),
+		Name:        ws.Name,
+		Slug:        ws.Slug,
+		OwnerID:     ws.OwnerID.String(),
+		Description: ws.Description,
+		Role:        role,
+		MemberCount: memberCount,
+		CreatedAt:   ws.CreatedAt,
+		UpdatedAt:   ws.UpdatedAt,
+	}, nil
+}
+
+func toMemberResp(m *model.WorkspaceMember, profile *model.UserProfile) dto.WorkspaceMemberResp {
+	resp := dto.WorkspaceMemberResp{
+		UserID:   m.UserID.String(),
+		Role:     m.Role,
+		JoinedAt: m.JoinedAt,
+	}
+	if profile != nil {
+		resp.Email = profile.Email
+		resp.FullName = profile.FullName
+		resp.AvatarURL = profile.AvatarURL
+	}
+	return resp
+}
+
+func toInviteLinkResp(link *model.WorkspaceInviteLink) *dto.InviteLinkResp {
+	return &dto.InviteLinkResp{
+		ID:          link.ID.String(),
+		WorkspaceID: link.WorkspaceID.String(),
+		Token:       link.Token,
+		Role:        link.Role,
+		MaxUses:     link.MaxUses,
+		Uses:        link.Uses,
+		CreatedAt:   link.CreatedAt,
+		ExpiresAt:   link.ExpiresAt,
+		RevokedAt:   link.RevokedAt,
+	}
+}
+
 func toWorkspaceResp(ws *model.Workspace) *dto.WorkspaceResp {
 	return &dto.WorkspaceResp{
 		ID:          ws.ID.String(),
@@ -172,3 +570,171 @@ func toWorkspaceResp(ws *model.Workspace) *dto.WorkspaceResp {
 		UpdatedAt:   ws.UpdatedAt,
 	}
 }
+
+// RequestTransfer proposes handing the workspace to another member, who must
+// accept it. Replaces any pending transfer. Owner only.
+func (s *WorkspaceService) RequestTransfer(ctx context.Context, userID, workspaceID uuid.UUID, req dto.TransferOwnershipReq) (*dto.OwnershipTransferResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	targetID, _ := uuid.Parse(req.UserID)
+	if _, appErr := s.requireOwner(ctx, workspaceID, userID, "transfer ownership"); appErr != nil {
+		return nil, appErr
+	}
+	if targetID == userID {
+		return nil, pkg.ErrBadRequest.WithMessage("you already own this workspace")
+	}
+
+	role, appErr := s.wsRepo.GetMemberRole(ctx, workspaceID, targetID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if role == "" {
+		return nil, pkg.ErrUnprocessable.WithMessage("ownership can only be transferred to a workspace member")
+	}
+
+	now := time.Now()
+	transfer := &model.WorkspaceTransfer{
+		WorkspaceID:       workspaceID,
+		FromUserID:        userID,
+		ToUserID:          targetID,
+		PreviousOwnerRole: previousOwnerRole(req),
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(transferTTL),
+	}
+	if appErr := s.wsRepo.SetTransfer(ctx, transfer); appErr != nil {
+		return nil, appErr
+	}
+	return toTransferResp(transfer), nil
+}
+
+// GetTransfer returns the pending ownership transfer. Only the owner and the
+// proposed new owner can see it.
+func (s *WorkspaceService) GetTransfer(ctx context.Context, userID, workspaceID uuid.UUID) (*dto.OwnershipTransferResp, *pkg.AppError) {
+	transfer, appErr := s.findTransfer(ctx, userID, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return toTransferResp(transfer), nil
+}
+
+// AcceptTransfer completes a pending ownership transfer. Only the proposed
+// new owner can accept, only while still a member, and only while the user
+// who requested it still owns the workspace.
+func (s *WorkspaceService) AcceptTransfer(ctx context.Context, userID, workspaceID uuid.UUID) (*dto.WorkspaceResp, *pkg.AppError) {
+	transfer, appErr := s.findTransfer(ctx, userID, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if transfer.ToUserID != userID {
+		return nil, pkg.ErrForbidden.WithMessage("only the proposed new owner can accept the transfer")
+	}
+	if time.Now().After(transfer.ExpiresAt) {
+		return nil, pkg.ErrNotFound.WithMessage("ownership transfer has expired")
+	}
+	if _, appErr := s.RequireMembership(ctx, workspaceID, userID); appErr != nil {
+		return nil, appErr
+	}
+	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if ws.OwnerID != transfer.FromUserID {
+		return nil, pkg.ErrConflict.WithMessage("workspace ownership has changed since the transfer was requested")
+	}
+
+	return s.transfer(ctx, workspaceID, transfer.FromUserID, userID, transfer.PreviousOwnerRole)
+}
+
+// CancelTransfer withdraws (owner) or declines (proposed new owner) a
+// pending ownership transfer.
+func (s *WorkspaceService) CancelTransfer(ctx context.Context, userID, workspaceID uuid.UUID) *pkg.AppError {
+	if _, appErr := s.findTransfer(ctx, userID, workspaceID); appErr != nil {
+		return appErr
+	}
+	return s.wsRepo.DeleteTransfer(ctx, workspaceID)
+}
+
+// ForceTransfer hands the workspace to another user immediately, without
+// the owner's or the new owner's confirmation. Admins only; the new owner
+// is added to the workspace if they are not a member.
+func (s *WorkspaceService) ForceTransfer(ctx context.Context, userID, workspaceID uuid.UUID, req dto.TransferOwnershipReq) (*dto.WorkspaceResp, *pkg.AppError) {
+	if appErr := pkg.Validate(req); appErr != nil {
+		return nil, appErr
+	}
+	targetID, _ := uuid.Parse(req.UserID)
+	if appErr := s.requireAdmin(ctx, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if ws.OwnerID == targetID {
+		return nil, pkg.ErrBadRequest.WithMessage("user already owns this workspace")
+	}
+	if _, appErr := s.userRepo.FindByID(ctx, targetID); appErr != nil {
+		return nil, appErr
+	}
+
+	log.Printf("[WorkspaceService.ForceTransfer] admin %s transferred workspace %s from %s to %s", userID, workspaceID, ws.OwnerID, targetID)
+	return s.transfer(ctx, workspaceID, ws.OwnerID, targetID, previousOwnerRole(req))
+}
+
+// transfer moves ownership and returns the updated workspace.
+func (s *WorkspaceService) transfer(ctx context.Context, workspaceID, fromID, toID uuid.UUID, previousRole string) (*dto.WorkspaceResp, *pkg.AppError) {
+	if appErr := s.wsRepo.TransferOwnership(ctx, workspaceID, fromID, toID, previousRole, time.Now()); appErr != nil {
+		return nil, appErr
+	}
+	ws, appErr := s.wsRepo.FindByID(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return toWorkspaceResp(ws), nil
+}
+
+// findTransfer returns the pending transfer of a workspace if the user is
+// one of its two parties.
+func (s *WorkspaceService) findTransfer(ctx context.Context, userID, workspaceID uuid.UUID) (*model.WorkspaceTransfer, *pkg.AppError) {
+	transfer, appErr := s.wsRepo.FindTransfer(ctx, workspaceID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if transfer.FromUserID != userID && transfer.ToUserID != userID {
+		return nil, pkg.ErrForbidden.WithMessage("only the owner and the proposed new owner can manage the transfer")
+	}
+	return transfer, nil
+}
+
+// requireAdmin checks that the user's email is one of the configured admin emails.
+func (s *WorkspaceService) requireAdmin(ctx context.Context, userID uuid.UUID) *pkg.AppError {
+	user, appErr := s.userRepo.FindByID(ctx, userID)
+	if appErr != nil {
+		return appErr
+	}
+	for _, email := range s.adminEmails {
+		if strings.EqualFold(email, user.Email) {
+			return nil
+		}
+	}
+	return pkg.ErrForbidden.WithMessage("admin access required")
+}
+
+func previousOwnerRole(req dto.TransferOwnershipReq) string {
+	if req.PreviousOwnerRole == "" {
+		return "editor"
+	}
+	return req.PreviousOwnerRole
+}
+
+func toTransferResp(t *model.WorkspaceTransfer) *dto.OwnershipTransferResp {
+	return &dto.OwnershipTransferResp{
+		WorkspaceID:       t.WorkspaceID.String(),
+		FromUserID:        t.FromUserID.String(),
+		ToUserID:          t.ToUserID.String(),
+		PreviousOwnerRole: t.PreviousOwnerRole,
+		CreatedAt:         t.CreatedAt,
+		ExpiresAt:         t.ExpiresAt,
+	}
+}
@@ -0,0 +1,550 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/dto"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+)
+
+// fakeWorkspaceRepo keeps workspaces, members and invites in memory.
+// Methods a test does not expect to be called panic through the nil
+// embedded interface.
+type fakeWorkspaceRepo struct {
+	workspaceStore
+	workspaces map[uuid.UUID]*model.Workspace
+	members    map[uuid.UUID]map[uuid.UUID]string     // workspace → user → role
+	invites    map[string]*model.WorkspaceInvite      // by token
+	links      map[string]*model.WorkspaceInviteLink  // by token
+	transfers  map[uuid.UUID]*model.WorkspaceTransfer // by workspace
+}
+
+func newFakeWorkspaceRepo() *fakeWorkspaceRepo {
+	return &fakeWorkspaceRepo{
+		workspaces: map[uuid.UUID]*model.Workspace{},
+		members:    map[uuid.UUID]map[uuid.UUID]string{},
+		invites:    map[string]*model.WorkspaceInvite{},
+		links:      map[string]*model.WorkspaceInviteLink{},
+		transfers:  map[uuid.UUID]*model.WorkspaceTransfer{},
+	}
+}
+
+// addWorkspace creates a workspace owned by ownerID with the given other members.
+func (r *fakeWorkspaceRepo) addWorkspace(ownerID uuid.UUID, members map[uuid.UUID]string) uuid.UUID {
+	id := uuid.New()
+	r.workspaces[id] = &model.Workspace{ID: id, Name: "Team", Slug: "team", OwnerID: ownerID}
+	r.members[id] = map[uuid.UUID]string{ownerID: "owner"}
+	for userID, role := range members {
+		r.members[id][userID] = role
+	}
+	return id
+}
+
+func (r *fakeWorkspaceRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Workspace, *pkg.AppError) {
+	ws, ok := r.workspaces[id]
+	if !ok {
+		return nil, pkg.ErrNotFound.WithMessage("workspace not found")
+	}
+	copied := *ws
+	return &copied, nil
+}
+
+func (r *fakeWorkspaceRepo) GetMemberRole(_ context.Context, workspaceID, userID uuid.UUID) (string, *pkg.AppError) {
+	return r.members[workspaceID][userID], nil
+}
+
+func (r *fakeWorkspaceRepo) FindMember(_ context.Context, workspaceID, userID uuid.UUID) (*model.WorkspaceMember, *pkg.AppError) {
+	role, ok := r.members[workspaceID][userID]
+	if !ok {
+		return nil, pkg.ErrNotFound.WithMessage("member not found")
+	}
+	return &model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeWorkspaceRepo) UpdateMemberRole(_ context.Context, workspaceID, userID uuid.UUID, role string) *pkg.AppError {
+	if _, ok := r.members[workspaceID][userID]; !ok {
+		return pkg.ErrNotFound.WithMessage("member not found")
+	}
+	r.members[workspaceID][userID] = role
+	return nil
+}
+
+func (r *fakeWorkspaceRepo) DeleteMember(_ context.Context, workspaceID, userID uuid.UUID) *pkg.AppError {
+	if _, ok := r.members[workspaceID][userID]; !ok {
+		return pkg.ErrNotFound.WithMessage("member not found")
+	}
+	delete(r.members[workspaceID], userID)
+	return nil
+}
+
+func (r *fakeWorkspaceRepo) CountMembers(_ context.Context, workspaceID uuid.UUID) (int, *pkg.AppError) {
+	return len(r.members[workspaceID]), nil
+}
+
+func (r *fakeWorkspaceRepo) InsertInvite(_ context.Context, invite *model.WorkspaceInvite) *pkg.AppError {
+	r.invites[invite.Token] = invite
+	return nil
+}
+
+func (r *fakeWorkspaceRepo) FindInviteByToken(_ context.Context, token string) (*model.WorkspaceInvite, *pkg.AppError) {
+	invite, ok := r.invites[token]
+	if !ok {
+		return nil, pkg.ErrNotFound.WithMessage("invite not found")
+	}
+	copied := *invite
+	return &copied, nil
+}
+
+func (r *fakeWorkspaceRepo) AcceptInvite(_ context.Context, invite *model.WorkspaceInvite, member *model.WorkspaceMember) *pkg.AppError {
+	stored := r.invites[invite.Token]
+	if stored.AcceptedAt != nil {
+		return pkg.ErrConflict.WithMessage("invite has already been accepted")
+	}
+	stored.AcceptedAt, stored.AcceptedBy = &member.JoinedAt, &member.UserID
+	r.members[member.WorkspaceID][member.UserID] = member.Role
+	return nil
+}
+
+func (r *fakeWorkspaceRepo) FindInviteLinkByToken(_ context.Context, token string) (*model.WorkspaceInviteLink, *pkg.AppError) {
+	link, ok := r.links[token]
+	if !ok {
+		return nil, pkg.ErrNotFound.WithMessage("invite link not found")
+	}
+	copied := *link
+	return &copied, nil
+}
+
+// UseInviteLink applies the same conditions as the repository's filtered update.
+func (r *fakeWorkspaceRepo) UseInviteLink(_ context.Context, link *model.WorkspaceInviteLink, member *model.WorkspaceMember) *pkg.AppError {
+	stored := r.links[link.Token]
+	if stored.RevokedAt != nil || !stored.ExpiresAt.After(member.JoinedAt) || (stored.MaxUses != nil && stored.Uses >= *stored.MaxUses) {
+		return pkg.ErrNotFound.WithMessage("invite link is no longer valid")
+	}
+	if _, ok := r.members[member.WorkspaceID][member.UserID]; ok {
+		return pkg.ErrConflict.WithMessage("you are already a member of this workspace")
+	}
+	stored.Uses++
+	r.members[member.WorkspaceID][member.UserID] = member.Role
+	return nil
+}
+
+func (r *fakeWorkspaceRepo) FindTransfer(_ context.Context, workspaceID uuid.UUID) (*model.WorkspaceTransfer, *pkg.AppError) {
+	transfer, ok := r.transfers[workspaceID]
+	if !ok {
+		return nil, pkg.ErrNotFound.WithMessage("ownership transfer not found")
+	}
+	copied := *transfer
+	return &copied, nil
+}
+
+// TransferOwnership applies the same owner_id check as the repository.
+func (r *fakeWorkspaceRepo) TransferOwnership(_ context.Context, workspaceID, fromID, toID uuid.UUID, previousOwnerRole string, at time.Time) *pkg.AppError {
+	ws := r.workspaces[workspaceID]
+	if ws.OwnerID != fromID {
+		return pkg.ErrConflict.WithMessage("workspace ownership has changed since the transfer was requested")
+	}
+	ws.OwnerID, ws.UpdatedAt = toID, at
+	r.members[workspaceID][toID] = "owner"
+	r.members[workspaceID][fromID] = previousOwnerRole
+	delete(r.transfers, workspaceID)
+	return nil
+}
+
+// fakeUserRepo keeps user profiles in memory.
+type fakeUserRepo struct {
+	users map[uuid.UUID]*model.UserProfile
+}
+
+// addUser creates a user with the given email.
+func (r *fakeUserRepo) addUser(email string) uuid.UUID {
+	id := uuid.New()
+	if r.users == nil {
+		r.users = map[uuid.UUID]*model.UserProfile{}
+	}
+	r.users[id] = &model.UserProfile{ID: id, Email: email}
+	return id
+}
+
+func (r *fakeUserRepo) FindByID(_ context.Context, id uuid.UUID) (*model.UserProfile, *pkg.AppError) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, pkg.ErrNotFound.WithMessage("user not found")
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepo) FindByIDs(_ context.Context, ids []uuid.UUID) ([]model.UserProfile, *pkg.AppError) {
+	var out []model.UserProfile
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			out = append(out, *user)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakeUserRepo) FindByEmail(_ context.Context, email string) (*model.UserProfile, *pkg.AppError) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, pkg.ErrNotFound.WithMessage("user not found")
+}
+
+// checkErr fails the test unless appErr has the code of want (nil: no error).
+func checkErr(t *testing.T, appErr, want *pkg.AppError) {
+	t.Helper()
+	switch {
+	case want == nil && appErr != nil:
+		t.Fatalf("unexpected error: %s", appErr.Error())
+	case want != nil && appErr == nil:
+		t.Fatalf("error = nil, want %s", want.Code)
+	case want != nil && appErr.Code != want.Code:
+		t.Fatalf("error = %s, want %s", appErr.Error(), want.Code)
+	}
+}
+
+func TestInviteMember(t *testing.T) {
+	wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+	owner, editor := users.addUser("owner@example.com"), users.addUser("editor@example.com")
+	wsID := wsRepo.addWorkspace(owner, map[uuid.UUID]string{editor: "editor"})
+	svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+	tests := []struct {
+		name    string
+		userID  uuid.UUID
+		email   string
+		wantErr *pkg.AppError
+	}{
+		{name: "new address", userID: owner, email: "New@Example.com"},
+		{name: "existing member", userID: owner, email: "editor@example.com", wantErr: pkg.ErrConflict},
+		{name: "not the owner", userID: editor, email: "other@example.com", wantErr: pkg.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, appErr := svc.InviteMember(context.Background(), tt.userID, wsID, dto.InviteMemberReq{Email: tt.email, Role: "viewer"})
+			checkErr(t, appErr, tt.wantErr)
+			if appErr != nil {
+				return
+			}
+			if resp.Email != "new@example.com" || resp.Token == "" || !resp.ExpiresAt.After(time.Now().Add(inviteTTL-time.Minute)) {
+				t.Errorf("invite = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestAcceptInvite(t *testing.T) {
+	tests := []struct {
+		name    string
+		invite  func(*model.WorkspaceInvite)
+		email   string
+		member  bool
+		wantErr *pkg.AppError
+	}{
+		{name: "pending invite", email: "guest@example.com"},
+		{name: "email differs in case", email: "Guest@Example.com"},
+		{name: "already accepted", email: "guest@example.com", wantErr: pkg.ErrConflict, invite: func(i *model.WorkspaceInvite) {
+			at := time.Now()
+			i.AcceptedAt = &at
+		}},
+		{name: "expired", email: "guest@example.com", wantErr: pkg.ErrNotFound, invite: func(i *model.WorkspaceInvite) {
+			i.ExpiresAt = time.Now().Add(-time.Minute)
+		}},
+		{name: "different email", email: "someone@example.com", wantErr: pkg.ErrForbidden},
+		{name: "already a member", email: "guest@example.com", member: true, wantErr: pkg.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+			owner, guest := users.addUser("owner@example.com"), users.addUser(tt.email)
+			wsID := wsRepo.addWorkspace(owner, nil)
+			if tt.member {
+				wsRepo.members[wsID][guest] = "viewer"
+			}
+			invite := &model.WorkspaceInvite{
+				ID: uuid.New(), WorkspaceID: wsID, Email: "guest@example.com", Role: "editor",
+				Token: "token", InvitedBy: owner, ExpiresAt: time.Now().Add(time.Hour),
+			}
+			if tt.invite != nil {
+				tt.invite(invite)
+			}
+			wsRepo.invites[invite.Token] = invite
+			svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+			item, appErr := svc.AcceptInvite(context.Background(), guest, "token")
+			checkErr(t, appErr, tt.wantErr)
+			if appErr != nil {
+				if !tt.member && wsRepo.members[wsID][guest] != "" {
+					t.Error("rejected invite added the user")
+				}
+				return
+			}
+			if item.Role != "editor" || item.MemberCount != 2 || wsRepo.members[wsID][guest] != "editor" {
+				t.Errorf("joined as %+v, members %v", item, wsRepo.members[wsID])
+			}
+		})
+	}
+}
+
+func TestUpdateMemberRole(t *testing.T) {
+	wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+	owner, editor, viewer := users.addUser("owner@example.com"), users.addUser("editor@example.com"), users.addUser("viewer@example.com")
+	wsID := wsRepo.addWorkspace(owner, map[uuid.UUID]string{editor: "editor", viewer: "viewer"})
+	svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		memberID uuid.UUID
+		role     string
+		wantErr  *pkg.AppError
+	}{
+		{name: "owner promotes a viewer", userID: owner, memberID: viewer, role: "editor"},
+		{name: "owner cannot demote themselves", userID: owner, memberID: owner, role: "editor", wantErr: pkg.ErrForbidden},
+		{name: "editor cannot change roles", userID: editor, memberID: viewer, role: "viewer", wantErr: pkg.ErrForbidden},
+		{name: "editor cannot demote the owner", userID: editor, memberID: owner, role: "viewer", wantErr: pkg.ErrForbidden},
+		{name: "not a member", userID: owner, memberID: uuid.New(), role: "editor", wantErr: pkg.ErrNotFound},
+		{name: "owner role cannot be granted", userID: owner, memberID: editor, role: "owner", wantErr: pkg.ErrUnprocessable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, appErr := svc.UpdateMemberRole(context.Background(), tt.userID, wsID, tt.memberID, dto.UpdateMemberRoleReq{Role: tt.role})
+			checkErr(t, appErr, tt.wantErr)
+			if appErr == nil && resp.Role != tt.role {
+				t.Errorf("role = %s, want %s", resp.Role, tt.role)
+			}
+		})
+	}
+	if wsRepo.members[wsID][owner] != "owner" {
+		t.Errorf("owner's role is now %q", wsRepo.members[wsID][owner])
+	}
+}
+
+func TestRemoveMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string // owner | editor
+		target  string // owner | editor | viewer
+		wantErr *pkg.AppError
+	}{
+		{name: "owner removes a member", actor: "owner", target: "viewer"},
+		{name: "member leaves", actor: "editor", target: "editor"},
+		{name: "owner cannot leave", actor: "owner", target: "owner", wantErr: pkg.ErrForbidden},
+		{name: "member cannot remove the owner", actor: "editor", target: "owner", wantErr: pkg.ErrForbidden},
+		{name: "member cannot remove others", actor: "editor", target: "viewer", wantErr: pkg.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+			ids := map[string]uuid.UUID{
+				"owner":  users.addUser("owner@example.com"),
+				"editor": users.addUser("editor@example.com"),
+				"viewer": users.addUser("viewer@example.com"),
+			}
+			wsID := wsRepo.addWorkspace(ids["owner"], map[uuid.UUID]string{ids["editor"]: "editor", ids["viewer"]: "viewer"})
+			svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+			appErr := svc.RemoveMember(context.Background(), ids[tt.actor], wsID, ids[tt.target])
+			checkErr(t, appErr, tt.wantErr)
+			_, stillMember := wsRepo.members[wsID][ids[tt.target]]
+			if stillMember != (tt.wantErr != nil) {
+				t.Errorf("target still a member: %v", stillMember)
+			}
+			if wsRepo.members[wsID][ids["owner"]] != "owner" {
+				t.Error("the workspace lost its owner")
+			}
+		})
+	}
+}
+
+func TestJoinByInviteLink(t *testing.T) {
+	intp := func(v int) *int { return &v }
+	tests := []struct {
+		name     string
+		link     func(*model.WorkspaceInviteLink)
+		member   bool
+		wantErr  *pkg.AppError
+		wantUses int
+	}{
+		{name: "unlimited link", wantUses: 1},
+		{name: "last use", link: func(l *model.WorkspaceInviteLink) { l.MaxUses, l.Uses = intp(3), 2 }, wantUses: 3},
+		{name: "use cap reached", link: func(l *model.WorkspaceInviteLink) { l.MaxUses, l.Uses = intp(3), 3 }, wantErr: pkg.ErrNotFound, wantUses: 3},
+		{name: "expired", link: func(l *model.WorkspaceInviteLink) { l.ExpiresAt = time.Now().Add(-time.Minute) }, wantErr: pkg.ErrNotFound},
+		{name: "revoked", link: func(l *model.WorkspaceInviteLink) {
+			at := time.Now()
+			l.RevokedAt = &at
+		}, wantErr: pkg.ErrNotFound},
+		{name: "already a member", member: true, wantErr: pkg.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+			owner, guest := users.addUser("owner@example.com"), users.addUser("guest@example.com")
+			wsID := wsRepo.addWorkspace(owner, nil)
+			if tt.member {
+				wsRepo.members[wsID][guest] = "editor"
+			}
+			link := &model.WorkspaceInviteLink{
+				ID: uuid.New(), WorkspaceID: wsID, Token: "token", Role: "viewer",
+				CreatedBy: owner, ExpiresAt: time.Now().Add(time.Hour),
+			}
+			if tt.link != nil {
+				tt.link(link)
+			}
+			wsRepo.links[link.Token] = link
+			svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+			item, appErr := svc.JoinByInviteLink(context.Background(), guest, "token")
+			checkErr(t, appErr, tt.wantErr)
+			if link.Uses != tt.wantUses {
+				t.Errorf("uses = %d, want %d", link.Uses, tt.wantUses)
+			}
+			role := wsRepo.members[wsID][guest]
+			switch {
+			case tt.member && role != "editor":
+				t.Errorf("existing member's role changed to %q", role)
+			case !tt.member && appErr != nil && role != "":
+				t.Errorf("rejected join added the user as %q", role)
+			case appErr == nil && (item.Role != "viewer" || role != "viewer"):
+				t.Errorf("joined as %+v, stored role %q", item, role)
+			}
+		})
+	}
+}
+
+func TestJoinByInviteLinkCapIsShared(t *testing.T) {
+	wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+	owner := users.addUser("owner@example.com")
+	wsID := wsRepo.addWorkspace(owner, nil)
+	maxUses := 2
+	wsRepo.links["token"] = &model.WorkspaceInviteLink{
+		ID: uuid.New(), WorkspaceID: wsID, Token: "token", Role: "editor",
+		MaxUses: &maxUses, CreatedBy: owner, ExpiresAt: time.Now().Add(time.Hour),
+	}
+	svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+	for i, want := range []*pkg.AppError{nil, nil, pkg.ErrNotFound} {
+		_, appErr := svc.JoinByInviteLink(context.Background(), users.addUser(fmt.Sprintf("user%d@example.com", i)), "token")
+		checkErr(t, appErr, want)
+	}
+	if n := len(wsRepo.members[wsID]); n != 3 {
+		t.Errorf("workspace has %d members, want 3", n)
+	}
+}
+
+func TestAcceptTransfer(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     string // owner | target | editor
+		transfer  func(tr *model.WorkspaceTransfer, ids map[string]uuid.UUID)
+		leftFirst bool // the target left the workspace before accepting
+		wantErr   *pkg.AppError
+	}{
+		{name: "target accepts", actor: "target"},
+		{name: "owner cannot accept", actor: "owner", wantErr: pkg.ErrForbidden},
+		{name: "other member cannot accept", actor: "editor", wantErr: pkg.ErrForbidden},
+		{name: "expired", actor: "target", wantErr: pkg.ErrNotFound, transfer: func(tr *model.WorkspaceTransfer, _ map[string]uuid.UUID) {
+			tr.ExpiresAt = time.Now().Add(-time.Minute)
+		}},
+		{name: "target left the workspace", actor: "target", leftFirst: true, wantErr: pkg.ErrForbidden},
+		{name: "owner changed since the request", actor: "target", wantErr: pkg.ErrConflict, transfer: func(tr *model.WorkspaceTransfer, ids map[string]uuid.UUID) {
+			tr.FromUserID = ids["editor"]
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+			ids := map[string]uuid.UUID{
+				"owner":  users.addUser("owner@example.com"),
+				"target": users.addUser("target@example.com"),
+				"editor": users.addUser("editor@example.com"),
+			}
+			wsID := wsRepo.addWorkspace(ids["owner"], map[uuid.UUID]string{ids["target"]: "editor", ids["editor"]: "editor"})
+			if tt.leftFirst {
+				delete(wsRepo.members[wsID], ids["target"])
+			}
+			transfer := &model.WorkspaceTransfer{
+				WorkspaceID: wsID, FromUserID: ids["owner"], ToUserID: ids["target"],
+				PreviousOwnerRole: "viewer", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour),
+			}
+			if tt.transfer != nil {
+				tt.transfer(transfer, ids)
+			}
+			wsRepo.transfers[wsID] = transfer
+			svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users}
+
+			resp, appErr := svc.AcceptTransfer(context.Background(), ids[tt.actor], wsID)
+			checkErr(t, appErr, tt.wantErr)
+			owner := wsRepo.workspaces[wsID].OwnerID
+			if appErr != nil {
+				if owner != ids["owner"] {
+					t.Errorf("rejected transfer changed the owner")
+				}
+				return
+			}
+			if resp.OwnerID != ids["target"].String() || owner != ids["target"] {
+				t.Errorf("owner = %s, want the target", owner)
+			}
+			if role := wsRepo.members[wsID][ids["owner"]]; role != "viewer" {
+				t.Errorf("previous owner's role = %q, want viewer", role)
+			}
+			if _, ok := wsRepo.transfers[wsID]; ok {
+				t.Error("accepted transfer is still pending")
+			}
+		})
+	}
+}
+
+func TestForceTransfer(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string // admin | owner
+		target  string // owner | member | outsider
+		wantErr *pkg.AppError
+	}{
+		{name: "admin transfers to a member", actor: "admin", target: "member"},
+		{name: "admin transfers to a non-member", actor: "admin", target: "outsider"},
+		{name: "owner is not an admin", actor: "owner", target: "member", wantErr: pkg.ErrForbidden},
+		{name: "target already owns it", actor: "admin", target: "owner", wantErr: pkg.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wsRepo, users := newFakeWorkspaceRepo(), &fakeUserRepo{}
+			ids := map[string]uuid.UUID{
+				"admin":    users.addUser("Admin@Example.com"),
+				"owner":    users.addUser("owner@example.com"),
+				"member":   users.addUser("member@example.com"),
+				"outsider": users.addUser("outsider@example.com"),
+			}
+			wsID := wsRepo.addWorkspace(ids["owner"], map[uuid.UUID]string{ids["member"]: "viewer"})
+			svc := &WorkspaceService{wsRepo: wsRepo, userRepo: users, adminEmails: []string{"admin@example.com"}}
+
+			_, appErr := svc.ForceTransfer(context.Background(), ids[tt.actor], wsID, dto.TransferOwnershipReq{UserID: ids[tt.target].String()})
+			checkErr(t, appErr, tt.wantErr)
+			owner := wsRepo.workspaces[wsID].OwnerID
+			switch {
+			case appErr != nil && owner != ids["owner"]:
+				t.Errorf("rejected transfer changed the owner")
+			case appErr == nil && (owner != ids[tt.target] || wsRepo.members[wsID][owner] != "owner"):
+				t.Errorf("owner = %s (%q), want %s", owner, wsRepo.members[wsID][owner], tt.target)
+			case appErr == nil && wsRepo.members[wsID][ids["owner"]] != "editor":
+				t.Errorf("previous owner's role = %q, want editor", wsRepo.members[wsID][ids["owner"]])
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// redisTimeout bounds Redis calls made while handling a client message.
+const redisTimeout = 2 * time.Second
+
+// envelope is what a room publishes to the other instances hosting it.
+// Content operations carry the original op so that every instance keeps
+// its in-memory document in step.
+type envelope struct {
+	Instance string          `json:"instance"`
+	Exclude  string          `json:"exclude,omitempty"` // client ID that must not receive Data
+	Data     json.RawMessage `json:"data"`
+	Op       *Message        `json:"op,omitempty"`
+}
+
+// publish sends env to the other instances hosting the room, if any.
+func (r *Room) publish(env envelope) {
+	if r.hub.broker == nil {
+		return
+	}
+	env.Instance = r.hub.instanceID
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := r.hub.broker.Publish(ctx, r.ID, payload); err != nil {
+		log.Printf("[WS] room %s: publish failed: %v", r.ID, err)
+	}
+}
+
+// nextSeq returns the next operation sequence number. Callers hold stateMu.
+// With a broker the counter lives in Redis so that seq is monotonic across
+// all instances hosting the room.
+func (r *Room) nextSeq() int64 {
+	if r.hub.broker != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+		seq, err := r.hub.broker.NextSeq(ctx, r.ID)
+		if err == nil {
+			return max(seq, r.seq+1)
+		}
+		log.Printf("[WS] room %s: shared seq unavailable, using local counter: %v", r.ID, err)
+	}
+	return r.seq + 1
+}
+
+// currentSeq returns the last operation sequence number assigned to the room
+// by any instance, or the local counter without a broker. Callers hold stateMu.
+func (r *Room) currentSeq(ctx context.Context) int64 {
+	if r.hub.broker != nil {
+		seq, err := r.hub.broker.CurrentSeq(ctx, r.ID)
+		if err == nil {
+			return max(seq, r.seq)
+		}
+		log.Printf("[WS] room %s: shared seq unavailable, using local counter: %v", r.ID, err)
+	}
+	return r.seq
+}
+
+// receiveRemote handles a message published by another instance.
+func (r *Room) receiveRemote(env envelope) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	if env.Op != nil {
+		r.receiveOp(*env.Op, env.Exclude)
+		return
+	}
+
+	var msg Message
+	if err := json.Unmarshal(env.Data, &msg); err == nil && msg.Type == TypeDocumentSaved {
+		// Our content already includes the saved ops, so the next flush
+		// can build on the new version instead of conflicting with it.
+		r.version = max(r.version, msg.Version)
+	}
+	r.deliver(env.Data, env.Exclude)
+}
+
+// receiveOp applies a content operation from another instance. The origin
+// instance persists the op; here it only updates the in-memory document so
+// later flushes from this instance include it. Callers hold stateMu.
+//
+// Local clients get the op as it applied here rather than as the origin
+// broadcast it: ops may arrive out of seq order, and property changes
+// superseded by a newer write are left out.
+func (r *Room) receiveOp(op Message, excludeID string) {
+	r.seq = max(r.seq, op.Seq)
+	out, err := applyOp(r.diagramType, &r.content, r.clocks, op)
+	if err != nil {
+		log.Printf("[WS] room %s: remote op %d does not apply: %v", r.ID, op.Seq, err)
+		return
+	}
+	if (out.Type == TypeNodeUpdated || out.Type == TypeEdgeUpdated) && len(out.Changes) == 0 {
+		return
+	}
+
+	out.Seq, out.By = op.Seq, op.By
+	r.logOp(out)
+	if data, err := json.Marshal(out); err == nil {
+		r.deliver(data, excludeID)
+	}
+}
+
+func (h *Hub) subscribe(roomID string) {
+	if h.broker == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := h.broker.Join(ctx, roomID); err != nil {
+		log.Printf("[WS] room %s: subscribe failed: %v", roomID, err)
+	}
+}
+
+func (h *Hub) unsubscribe(roomID string) {
+	if h.broker == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := h.broker.Leave(ctx, roomID); err != nil {
+		log.Printf("[WS] room %s: unsubscribe failed: %v", roomID, err)
+	}
+}
+
+// receive delivers messages from other instances to the local rooms.
+// Messages this instance published itself are skipped.
+func (h *Hub) receive() {
+	for msg := range h.broker.Messages() {
+		var env envelope
+		if err := json.Unmarshal(msg.Payload, &env); err != nil || env.Instance == h.instanceID {
+			continue
+		}
+
+		h.mu.RLock()
+		room, ok := h.rooms[msg.RoomID]
+		h.mu.RUnlock()
+		if ok {
+			room.receiveRemote(env)
+		}
+	}
+}
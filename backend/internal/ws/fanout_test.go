@@ -0,0 +1,172 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
+)
+
+// fakeBroker records what a hub publishes and hands out seq numbers from a
+// counter that other instances may have advanced.
+type fakeBroker struct {
+	mu        sync.Mutex
+	seq       int64
+	published []envelope
+	messages  chan redis.RoomMessage
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{messages: make(chan redis.RoomMessage, 16)}
+}
+
+func (b *fakeBroker) Publish(_ context.Context, _ string, payload []byte) error {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = append(b.published, env)
+	return nil
+}
+
+func (b *fakeBroker) Join(context.Context, string) error  { return nil }
+func (b *fakeBroker) Leave(context.Context, string) error { return nil }
+func (b *fakeBroker) Messages() <-chan redis.RoomMessage  { return b.messages }
+func (b *fakeBroker) Close() error                        { return nil }
+
+func (b *fakeBroker) NextSeq(context.Context, string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	return b.seq, nil
+}
+
+func (b *fakeBroker) CurrentSeq(context.Context, string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq, nil
+}
+
+// newClusterRoom is a test room whose hub fans out through broker. The hub's
+// receive loop is not started; tests run it once their messages are queued.
+func newClusterRoom(t *testing.T, broker *fakeBroker) (*Hub, *Room) {
+	t.Helper()
+	hub, room := newTestRoom(t, &fakeStore{})
+	hub.broker = broker
+	return hub, room
+}
+
+// remote queues an envelope as published by instance.
+func remote(t *testing.T, broker *fakeBroker, instance string, env envelope) {
+	t.Helper()
+	env.Instance = instance
+	payload, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broker.messages <- redis.RoomMessage{RoomID: testRoomID, Payload: payload}
+}
+
+func remoteOp(t *testing.T, broker *fakeBroker, instance string, op Message) {
+	t.Helper()
+	data, _ := json.Marshal(op)
+	remote(t, broker, instance, envelope{Exclude: op.By, Data: data, Op: &op})
+}
+
+// drain runs the hub's receive loop over everything queued so far.
+func drain(hub *Hub, broker *fakeBroker) {
+	close(broker.messages)
+	hub.receive()
+}
+
+func TestApplyPublishesOp(t *testing.T) {
+	broker := newFakeBroker()
+	hub, room := newClusterRoom(t, broker)
+	client := newTestClient("c1", "editor")
+	joinClient(t, room, client, 0)
+
+	room.apply(client, addNode("b"))
+
+	if len(broker.published) != 1 {
+		t.Fatalf("published %d envelopes, want 1", len(broker.published))
+	}
+	env := broker.published[0]
+	if env.Instance != hub.instanceID || env.Exclude != "c1" || env.Op == nil || env.Op.Seq != 1 || env.Op.Type != TypeAddNode {
+		t.Errorf("envelope = %+v, want the add_node op at seq 1 from this instance", env)
+	}
+}
+
+func TestReceiveDropsOwnEnvelopes(t *testing.T) {
+	broker := newFakeBroker()
+	hub, room := newClusterRoom(t, broker)
+	client := newTestClient("c1", "viewer")
+	joinClient(t, room, client, 0)
+
+	remoteOp(t, broker, hub.instanceID, Message{Type: TypeAddNode, Seq: 1, By: "x", Node: map[string]interface{}{"id": "mine", "type": "process"}})
+	remoteOp(t, broker, "other", Message{Type: TypeAddNode, Seq: 2, By: "y", Node: map[string]interface{}{"id": "theirs", "type": "process"}})
+	remote(t, broker, hub.instanceID, envelope{Data: json.RawMessage(`{"type":"cursor_update","user_id":"x"}`)})
+	drain(hub, broker)
+
+	got := received(t, client)
+	if len(got) != 1 || got[0].Type != TypeNodeAdded || got[0].Seq != 2 || got[0].By != "y" {
+		t.Errorf("client got %+v, want only the other instance's node_added", got)
+	}
+	if n := len(room.content.Nodes); n != 2 || room.content.Nodes[1].ID != "theirs" {
+		t.Errorf("content nodes = %+v, want a and theirs", room.content.Nodes)
+	}
+}
+
+func TestReceiveOpsOutOfSeqOrder(t *testing.T) {
+	broker := newFakeBroker()
+	hub, room := newClusterRoom(t, broker)
+	client := newTestClient("c1", "editor")
+	joinClient(t, room, client, 0)
+
+	update := func(seq int64, label string) Message {
+		return Message{Type: TypeUpdateNode, Seq: seq, By: "y", NodeID: "a", Changes: map[string]interface{}{"label": label}}
+	}
+	remoteOp(t, broker, "other", update(5, "newer"))
+	remoteOp(t, broker, "other", update(3, "older"))
+	drain(hub, broker)
+
+	got := received(t, client)
+	if len(got) != 1 || got[0].Seq != 5 || got[0].Changes["label"] != "newer" {
+		t.Errorf("client got %+v, want only seq 5; seq 3 lost to it", got)
+	}
+	if room.content.Nodes[0].Label != "newer" || room.seq != 5 {
+		t.Errorf("label = %q at seq %d, want newer at 5", room.content.Nodes[0].Label, room.seq)
+	}
+
+	// The shared counter lags behind ops already seen; the next local op
+	// still sorts after them.
+	broker.seq = 1
+	room.apply(client, update(0, "local"))
+	if ack := received(t, client); len(ack) != 1 || ack[0].Type != TypeOpAck || ack[0].Seq != 6 {
+		t.Errorf("local op got %+v, want op_ack at seq 6", ack)
+	}
+	if room.content.Nodes[0].Label != "local" {
+		t.Errorf("label = %q, want local", room.content.Nodes[0].Label)
+	}
+}
+
+func TestReceiveSavedAdvancesVersion(t *testing.T) {
+	broker := newFakeBroker()
+	hub, room := newClusterRoom(t, broker)
+	client := newTestClient("c1", "viewer")
+	joinClient(t, room, client, 0)
+
+	data, _ := json.Marshal(Message{Type: TypeDocumentSaved, Version: 7, Seq: 4})
+	remote(t, broker, "other", envelope{Data: data})
+	drain(hub, broker)
+
+	if room.version != 7 {
+		t.Errorf("version = %d, want 7", room.version)
+	}
+	if got := received(t, client); len(got) != 1 || got[0].Type != TypeDocumentSaved {
+		t.Errorf("client got %+v, want document_saved", got)
+	}
+}
@@ -1,14 +1,31 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	gws "github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/middleware"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
 )
 
+// joinWait is how long a new connection may take to send join_room before
+// it is joined with the full room state.
+const joinWait = 2 * time.Second
+
+// roleRecheck is how long a client's role is trusted before its next edit
+// checks it again, so members removed or demoted while connected lose
+// write access within that time.
+const roleRecheck = 30 * time.Second
+
 // UpgradeMiddleware checks for WebSocket upgrade requests
 func UpgradeMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -19,39 +36,112 @@ func UpgradeMiddleware() fiber.Handler {
 	}
 }
 
-// HandleWebSocket returns a Fiber handler for WebSocket connections
+// HandleWebSocket returns a Fiber handler for WebSocket connections.
+// The upgrade request must already be authenticated and authorized:
+// it expects ctx.Locals("userId") and ctx.Locals("role") (see middleware.WSAuth).
 func HandleWebSocket(hub *Hub) fiber.Handler {
 	return gws.New(func(c *gws.Conn) {
 		documentID := c.Params("documentId")
 		clientID := uuid.New().String()
 
+		userID, _ := c.Locals("userId").(uuid.UUID)
+		role, _ := c.Locals("role").(string)
+		if userID == uuid.Nil || role == "" {
+			_ = c.WriteMessage(gws.CloseMessage, gws.FormatCloseMessage(gws.ClosePolicyViolation, "unauthorized"))
+			return
+		}
+		email, _ := c.Locals("email").(string)
+		name, _ := c.Locals("name").(string)
+		avatarURL, _ := c.Locals("avatarUrl").(string)
+
 		client := &Client{
-			ID:   clientID,
-			Name: "User-" + clientID[:8],
-			Conn: c.Conn,
-			Room: documentID,
+			ID:        clientID,
+			UserID:    userID,
+			Name:      displayName(name, email, clientID),
+			AvatarURL: avatarURL,
+			Role:      role,
+			JoinedAt:  time.Now().UTC(),
+			Conn:      c.Conn,
+			Room:      documentID,
+			send:      make(chan []byte, sendBuffer),
+			checked:   time.Now(),
 		}
 
-		room := hub.GetOrCreateRoom(documentID)
-		room.AddClient(client)
+		// The connection must not be written to once the handler returns,
+		// so the writer is drained before that.
+		written := make(chan struct{})
+		go func() {
+			client.writePump()
+			close(written)
+		}()
+		defer func() {
+			client.Close()
+			<-written
+		}()
 
-		log.Printf("[WS] Client %s joined room %s", clientID, documentID)
+		// The client is registered now but only joined, and sent the room
+		// state, on join_room (which may carry last_seq), its first other
+		// message, or after joinWait, whichever comes first.
+		room := hub.GetOrCreateRoom(documentID, client)
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		appErr := room.load(ctx)
+		cancel()
+		if appErr != nil {
+			log.Printf("[WS] Failed to load room %s: %s", documentID, appErr.Error())
+			sendError(client, "Failed to load document")
+			room.RemoveClient(clientID)
+			hub.RemoveRoomIfEmpty(documentID)
+			return
+		}
 
-		// Send room state to the new client
-		sendRoomState(client, room)
+		var (
+			once   sync.Once
+			joined bool
+		)
+		join := func(lastSeq int64) {
+			once.Do(func() {
+				room.join(client, lastSeq)
+				hub.setPresence(room, client)
+				joined = true
 
-		// Notify others
-		broadcastJSON(room, clientID, Message{
-			Type: TypeUserJoined,
-			User: map[string]interface{}{
-				"id":   clientID,
-				"name": client.Name,
-			},
-		})
+				log.Printf("[WS] Client %s joined room %s", clientID, documentID)
+
+				// Notify others
+				broadcastJSON(room, clientID, Message{
+					Type: TypeUserJoined,
+					User: userInfo(client),
+				})
+			})
+		}
+		joinTimer := time.AfterFunc(joinWait, func() { join(0) })
+
+		done := make(chan struct{})
+		hub.keepalive(c, room, client, done)
 
 		// Read loop
 		defer func() {
+			close(done)
+			joinTimer.Stop()
+			once.Do(func() {}) // no late join; makes joined safe to read
 			room.RemoveClient(clientID)
+			if !joined {
+				hub.RemoveRoomIfEmpty(documentID)
+				return
+			}
+			hub.removePresence(room, clientID)
+
+			ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+			for _, nodeID := range room.ReleaseLocks(ctx, clientID) {
+				broadcastJSON(room, "", Message{Type: TypeNodeUnlocked, NodeID: nodeID})
+			}
+			cancel()
+
+			if room.IsEmpty() {
+				// Last one out persists pending edits before the room goes away
+				ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+				room.flush(ctx)
+				cancel()
+			}
 			hub.RemoveRoomIfEmpty(documentID)
 
 			broadcastJSON(room, "", Message{
@@ -67,6 +157,7 @@ func HandleWebSocket(hub *Hub) fiber.Handler {
 			if err != nil {
 				break
 			}
+			_ = c.SetReadDeadline(time.Now().Add(pongWait))
 
 			var msg Message
 			if err := json.Unmarshal(msgBytes, &msg); err != nil {
@@ -74,62 +165,80 @@ func HandleWebSocket(hub *Hub) fiber.Handler {
 				continue
 			}
 
+			if msg.Type == TypeJoinRoom {
+				join(msg.LastSeq)
+			} else {
+				join(0)
+			}
 			handleMessage(client, room, msg)
 		}
-	})
+	}, gws.Config{Subprotocols: []string{middleware.BearerSubprotocol}})
 }
 
 func handleMessage(client *Client, room *Room, msg Message) {
+	if isMutation(msg.Type) && !recheckRole(client, room) {
+		return
+	}
+	if isMutation(msg.Type) && !client.CanEdit() {
+		sendError(client, "Viewers cannot edit this document")
+		return
+	}
+
 	switch msg.Type {
-	case TypeLockNode:
-		if room.LockNode(msg.NodeID, client.ID) {
+	case TypeJoinRoom:
+		// The room is chosen by the URL; join_room only confirms it.
+		if msg.RoomID != "" && msg.RoomID != room.ID {
+			sendError(client, "Connected to a different room")
+		}
+
+	case TypeLockNode, TypeRenewLock:
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+
+		var ok bool
+		var err error
+		if msg.Type == TypeLockNode {
+			ok, err = room.LockNode(ctx, msg.NodeID, client.ID)
+		} else {
+			ok, err = room.RenewLock(ctx, msg.NodeID, client.ID)
+		}
+		switch {
+		case err != nil:
+			log.Printf("[WS] room %s: lock on %s failed: %v", room.ID, msg.NodeID, err)
+			sendError(client, "Failed to lock node")
+		case !ok && msg.Type == TypeLockNode:
+			sendError(client, "Node is already locked")
+		case !ok:
+			sendError(client, "Lock expired or held by someone else")
+		default:
+			// Renewals are broadcast too, so other clients reset their expiry timers
 			broadcastJSON(room, "", Message{
 				Type:   TypeNodeLocked,
 				NodeID: msg.NodeID,
 				By:     client.ID,
+				TTL:    room.lockTTLSeconds(),
 			})
-		} else {
-			sendError(client, "Node is already locked")
 		}
 
 	case TypeUnlockNode:
-		room.UnlockNode(msg.NodeID, client.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+		if err := room.UnlockNode(ctx, msg.NodeID, client.ID); err != nil {
+			log.Printf("[WS] room %s: unlock of %s failed: %v", room.ID, msg.NodeID, err)
+		}
 		broadcastJSON(room, "", Message{
 			Type:   TypeNodeUnlocked,
 			NodeID: msg.NodeID,
 		})
 
-	case TypeUpdateNode:
-		broadcastJSON(room, client.ID, Message{
-			Type:    TypeNodeUpdated,
-			NodeID:  msg.NodeID,
-			Changes: msg.Changes,
-		})
-
-	case TypeAddNode:
-		broadcastJSON(room, client.ID, Message{
-			Type: TypeNodeAdded,
-			Node: msg.Node,
-		})
-
 	case TypeDeleteNode:
-		room.UnlockNode(msg.NodeID, client.ID)
-		broadcastJSON(room, client.ID, Message{
-			Type:   TypeNodeDeleted,
-			NodeID: msg.NodeID,
-		})
+		ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+		defer cancel()
+		_ = room.UnlockNode(ctx, msg.NodeID, client.ID)
+		room.apply(client, msg)
 
-	case TypeAddEdge:
-		broadcastJSON(room, client.ID, Message{
-			Type: "edge_added",
-			Edge: msg.Edge,
-		})
-
-	case TypeDeleteEdge:
-		broadcastJSON(room, client.ID, Message{
-			Type:   "edge_deleted",
-			EdgeID: msg.EdgeID,
-		})
+	case TypeUpdateNode, TypeAddNode, TypeAddEdge, TypeUpdateEdge, TypeDeleteEdge:
+		room.apply(client, msg)
 
 	case TypeCursorMove:
 		broadcastJSON(room, client.ID, Message{
@@ -144,28 +253,71 @@ func handleMessage(client *Client, room *Room, msg Message) {
 	}
 }
 
-func sendRoomState(client *Client, room *Room) {
-	room.mu.RLock()
-	defer room.mu.RUnlock()
+// recheckRole confirms the client's role against the document's workspace
+// membership and grants once it is older than roleRecheck, updating it and
+// the client's presence if it changed. A client that lost access is sent an
+// error and disconnected. Returns false if the edit must be dropped.
+func recheckRole(client *Client, room *Room) bool {
+	if time.Since(client.checked) < roleRecheck {
+		return true
+	}
+	docID, err := uuid.Parse(room.ID)
+	if err != nil {
+		return false
+	}
 
-	users := make([]interface{}, 0, len(room.Clients))
-	for _, c := range room.Clients {
-		if c.ID != client.ID {
-			users = append(users, map[string]interface{}{
-				"id":   c.ID,
-				"name": c.Name,
-			})
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	role, appErr := room.hub.docSvc.RequireAccess(ctx, client.UserID, docID)
+	cancel()
+	switch {
+	case appErr == nil:
+	case appErr.Code == pkg.ErrForbidden.Code || appErr.Code == pkg.ErrNotFound.Code:
+		log.Printf("[WS] client %s lost access to room %s", client.ID, room.ID)
+		sendError(client, "You no longer have access to this document")
+		client.Close()
+		return false
+	default:
+		log.Printf("[WS] room %s: access check for %s failed: %s", room.ID, client.ID, appErr.Error())
+		sendError(client, "Failed to check access, try again")
+		return false
 	}
 
-	msg := Message{
-		Type:  TypeRoomState,
-		Users: users,
-		Locks: room.Locks,
+	client.checked = time.Now()
+	if role != client.role() {
+		client.setRole(role)
+		room.hub.setPresence(room, client)
 	}
+	return true
+}
 
-	data, _ := json.Marshal(msg)
-	_ = client.Send(data)
+// sendRoomState sends the room's other members and the document state
+// (locks, content, version and seq from state) to a joining client.
+func sendRoomState(client *Client, members []redis.PresenceEntry, state Message) {
+	users := make([]interface{}, 0, len(members))
+	for _, m := range members {
+		users = append(users, encodeMap(m))
+	}
+
+	state.Type = TypeRoomState
+	state.Users = users
+	sendJSON(client, state)
+}
+
+// userInfo is the public identity of a client as sent to other room members.
+func userInfo(c *Client) map[string]interface{} {
+	return encodeMap(c.presence())
+}
+
+// displayName prefers the profile's full name, then derives one from the
+// token's email, falling back to a short anonymous handle.
+func displayName(name, email, clientID string) string {
+	if name != "" {
+		return name
+	}
+	if name, _, ok := strings.Cut(email, "@"); ok && name != "" {
+		return name
+	}
+	return "User-" + clientID[:8]
 }
 
 func broadcastJSON(room *Room, excludeID string, msg Message) {
@@ -176,10 +328,17 @@ func broadcastJSON(room *Room, excludeID string, msg Message) {
 	room.Broadcast(data, excludeID)
 }
 
+func sendJSON(client *Client, msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	_ = client.Send(data)
+}
+
 func sendError(client *Client, message string) {
-	data, _ := json.Marshal(Message{
+	sendJSON(client, Message{
 		Type:        TypeError,
 		MessageText: message,
 	})
-	_ = client.Send(data)
 }
@@ -0,0 +1,79 @@
+package ws
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+)
+
+func TestEditRechecksRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      string        // role now, "" if the user lost access
+		accessErr *pkg.AppError // access check failure
+		wantTypes []string
+		wantNode  bool
+		wantRole  string
+		wantOpen  bool
+	}{
+		{name: "still an editor", role: "editor", wantTypes: []string{TypeOpAck}, wantNode: true, wantRole: "editor", wantOpen: true},
+		{name: "demoted to viewer", role: "viewer", wantTypes: []string{TypeError}, wantRole: "viewer", wantOpen: true},
+		{name: "removed from the workspace", wantTypes: []string{TypeError}, wantRole: "editor"},
+		{name: "check fails", accessErr: pkg.ErrInternal, wantTypes: []string{TypeError}, wantRole: "editor", wantOpen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{accessErr: tt.accessErr}
+			_, room := newTestRoom(t, store)
+			client := newTestClient("c1", "editor")
+			joinClient(t, room, client, 0)
+			if tt.role != "" {
+				store.roles = map[uuid.UUID]string{client.UserID: tt.role}
+			}
+			client.checked = time.Now().Add(-roleRecheck)
+
+			handleMessage(client, room, addNode("n1"))
+
+			if got := types(received(t, client)); !slices.Equal(got, tt.wantTypes) {
+				t.Errorf("client got %v, want %v", got, tt.wantTypes)
+			}
+			hasNode := slices.ContainsFunc(room.content.Nodes, func(n document.Node) bool { return n.ID == "n1" })
+			if hasNode != tt.wantNode {
+				t.Errorf("node applied = %v, want %v", hasNode, tt.wantNode)
+			}
+			if client.role() != tt.wantRole {
+				t.Errorf("role = %s, want %s", client.role(), tt.wantRole)
+			}
+			if client.closed == tt.wantOpen {
+				t.Errorf("client closed = %v, want %v", client.closed, !tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestRoleIsTrustedUntilRecheck(t *testing.T) {
+	store := &fakeStore{}
+	_, room := newTestRoom(t, store)
+	client := newTestClient("c1", "editor")
+	joinClient(t, room, client, 0)
+	store.roles = map[uuid.UUID]string{client.UserID: "editor"}
+
+	handleMessage(client, room, addNode("n1"))
+	handleMessage(client, room, Message{Type: TypeCursorMove})
+	if store.checks != 0 {
+		t.Fatalf("%d access checks within roleRecheck, want 0", store.checks)
+	}
+
+	client.checked = time.Now().Add(-roleRecheck)
+	handleMessage(client, room, addNode("n2"))
+	handleMessage(client, room, addNode("n3"))
+	if store.checks != 1 {
+		t.Errorf("%d access checks, want 1 per roleRecheck", store.checks)
+	}
+}
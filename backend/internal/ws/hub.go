@@ -1,122 +1,306 @@
 package ws
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
 	"sync"
+	"time"
 
 	"github.com/fasthttp/websocket"
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
 )
 
+// sendBuffer is how many outgoing messages a client may fall behind before
+// it is disconnected.
+const sendBuffer = 256
+
+// errClientClosed is returned by Send once the client's queue is closed.
+var errClientClosed = errors.New("client connection closed")
+
 // Client represents a single WebSocket connection
 type Client struct {
-	ID   string
-	Name string
-	Conn *websocket.Conn
-	Room string
-	mu   sync.Mutex
+	ID        string    // connection ID; one user may join from several tabs
+	UserID    uuid.UUID // authenticated user
+	Name      string
+	AvatarURL string
+	Role      string // owner | editor | viewer; guarded by mu once connected (see recheckRole)
+	JoinedAt  time.Time
+	Conn      *websocket.Conn
+	Room      string
+	joined    bool        // receives room messages; set by Room.join under the room's mu
+	send      chan []byte // outgoing messages, written to Conn by writePump
+	closed    bool        // send is closed; guarded by mu
+	checked   time.Time   // when Role was last confirmed; read loop only
+	mu        sync.Mutex
+}
+
+// CanEdit reports whether the client may send mutation messages.
+func (c *Client) CanEdit() bool {
+	role := c.role()
+	return role == "owner" || role == "editor"
 }
 
+func (c *Client) role() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Role
+}
+
+func (c *Client) setRole(role string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Role = role
+}
+
+// Send queues msg for the connection without waiting for the socket, so a
+// slow client never holds up a room. A client that falls sendBuffer messages
+// behind is disconnected; it catches up when it reconnects.
 func (c *Client) Send(msg []byte) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.Conn.WriteMessage(websocket.TextMessage, msg)
+	if c.closed {
+		return errClientClosed
+	}
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		log.Printf("[WS] client %s is too slow, disconnecting", c.ID)
+		c.closed = true
+		close(c.send)
+		return errClientClosed
+	}
+}
+
+// Close stops queueing messages. writePump returns once the queue is drained.
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// writePump writes queued messages to the connection until the queue is
+// closed, then closes the connection, which ends the read loop.
+func (c *Client) writePump() {
+	failed := false
+	for msg := range c.send {
+		if failed {
+			continue
+		}
+		_ = c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.Conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			failed = true
+		}
+	}
+	_ = c.Conn.Close()
 }
 
 // Room represents a collaboration room (1 document = 1 room)
 type Room struct {
 	ID      string
 	Clients map[string]*Client // clientID → Client
-	Locks   map[string]string  // nodeID → clientID
+	Locks   map[string]string  // nodeID → clientID, for locks held by clients on this instance
 	mu      sync.RWMutex
+	hub     *Hub
+
+	// Authoritative document state (see sync.go), guarded by stateMu.
+	// Held while an operation is applied and queued to the clients, so
+	// clients receive operations in seq order. Client.Send only queues;
+	// sockets are written outside the lock.
+	stateMu     sync.Mutex
+	loaded      bool
+	content     document.DocumentContent
+	diagramType string
+	clocks      fieldClocks // per-field write stamps of content (see merge.go)
+	version     int         // version stored in MongoDB
+	seq         int64       // last assigned operation sequence number
+	unsaved     []Message   // operations applied since the last flush
+	oplog       []Message   // recent operations as sent to clients (see replay.go)
+	logStart    int64       // a client that saw this seq can catch up from oplog
+	lastEditor  uuid.UUID
+	dirtySince  time.Time
+	flushTimer  *time.Timer
+	flushMu     sync.Mutex // serializes flushes
 }
 
-func NewRoom(id string) *Room {
+func NewRoom(id string, hub *Hub) *Room {
 	return &Room{
 		ID:      id,
 		Clients: make(map[string]*Client),
 		Locks:   make(map[string]string),
+		hub:     hub,
+		clocks:  make(fieldClocks),
 	}
 }
 
+// AddClient registers a connection with the room, keeping the room open.
+// The client receives room messages once it has joined.
 func (r *Room) AddClient(client *Client) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.Clients[client.ID] = client
 }
 
+// RemoveClient removes a client. Its locks are released separately
+// with ReleaseLocks.
 func (r *Room) RemoveClient(clientID string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.Clients, clientID)
-
-	// Release all locks held by this client
-	for nodeID, lockerID := range r.Locks {
-		if lockerID == clientID {
-			delete(r.Locks, nodeID)
-		}
-	}
 }
 
+// Broadcast sends msg to the room's clients on every instance, except excludeID.
 func (r *Room) Broadcast(msg []byte, excludeID string) {
+	r.deliver(msg, excludeID)
+	r.publish(envelope{Exclude: excludeID, Data: msg})
+}
+
+// deliver sends msg to the clients connected to this instance.
+func (r *Room) deliver(msg []byte, excludeID string) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	for id, client := range r.Clients {
-		if id != excludeID {
+		if id != excludeID && client.joined {
 			_ = client.Send(msg)
 		}
 	}
 }
 
-func (r *Room) LockNode(nodeID, clientID string) bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if existing, ok := r.Locks[nodeID]; ok && existing != clientID {
-		return false // Already locked by someone else
-	}
-	r.Locks[nodeID] = clientID
-	return true
-}
-
-func (r *Room) UnlockNode(nodeID, clientID string) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	if r.Locks[nodeID] == clientID {
-		delete(r.Locks, nodeID)
-	}
-}
-
 func (r *Room) IsEmpty() bool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	return len(r.Clients) == 0
 }
 
+// Broker fans out room messages between the instances hosting a room and
+// hands out the room's shared operation sequence numbers.
+// *redis.RoomBroker implements it.
+type Broker interface {
+	Publish(ctx context.Context, roomID string, payload []byte) error
+	Join(ctx context.Context, roomID string) error
+	Leave(ctx context.Context, roomID string) error
+	Messages() <-chan redis.RoomMessage
+	NextSeq(ctx context.Context, roomID string) (int64, error)
+	CurrentSeq(ctx context.Context, roomID string) (int64, error)
+	Close() error
+}
+
+// Cluster holds the Redis services that let rooms span API instances.
+// The zero value runs every room on this instance only.
+type Cluster struct {
+	Broker   Broker                 // fans out room messages
+	Locks    *redis.LockService     // cluster-wide node locks with expiry
+	Presence *redis.PresenceService // who is connected, with heartbeats
+}
+
+// ContentStore loads and persists the documents edited in rooms, and
+// re-checks the access of connected users. *service.DocumentService
+// implements it.
+type ContentStore interface {
+	RequireAccess(ctx context.Context, userID, docID uuid.UUID) (string, *pkg.AppError)
+	LoadContent(ctx context.Context, docID uuid.UUID) (*model.Document, *document.DocumentContent, *pkg.AppError)
+	SaveContent(ctx context.Context, docID, userID uuid.UUID, content json.RawMessage, baseVersion int) (int, *pkg.AppError)
+}
+
 // Hub manages all rooms
 type Hub struct {
-	rooms map[string]*Room
-	mu    sync.RWMutex
+	rooms      map[string]*Room
+	mu         sync.RWMutex
+	docSvc     ContentStore
+	broker     Broker
+	locks      *redis.LockService
+	presence   *redis.PresenceService
+	instanceID string
+	done       chan struct{}
 }
 
-func NewHub() *Hub {
-	return &Hub{
-		rooms: make(map[string]*Room),
+// NewHub creates a Hub whose rooms load and persist documents through docSvc
+// and coordinate with other instances through cluster.
+func NewHub(docSvc ContentStore, cluster Cluster) *Hub {
+	h := &Hub{
+		rooms:      make(map[string]*Room),
+		docSvc:     docSvc,
+		broker:     cluster.Broker,
+		locks:      cluster.Locks,
+		presence:   cluster.Presence,
+		instanceID: uuid.New().String(),
+		done:       make(chan struct{}),
+	}
+	if h.broker != nil {
+		go h.receive()
+	}
+	if h.presence != nil {
+		go h.sweep()
 	}
+	return h
 }
 
-func (h *Hub) GetOrCreateRoom(roomID string) *Room {
+// GetOrCreateRoom returns the room for roomID with client registered in it.
+// Registering under h.mu keeps RemoveRoomIfEmpty from dropping the room
+// between its lookup and the client's arrival, which would leave the client
+// in a room that a later connection no longer finds.
+func (h *Hub) GetOrCreateRoom(roomID string, client *Client) *Room {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	if room, ok := h.rooms[roomID]; ok {
-		return room
+	room, ok := h.rooms[roomID]
+	if !ok {
+		room = NewRoom(roomID, h)
+		h.rooms[roomID] = room
+		h.subscribe(roomID)
 	}
-	room := NewRoom(roomID)
-	h.rooms[roomID] = room
+	room.AddClient(client)
 	return room
 }
 
+// RemoveRoomIfEmpty drops a room once its last client left and its edits
+// are persisted. Callers flush the room first.
 func (h *Hub) RemoveRoomIfEmpty(roomID string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	if room, ok := h.rooms[roomID]; ok && room.IsEmpty() {
+	if room, ok := h.rooms[roomID]; ok && room.IsEmpty() && !room.Dirty() {
+		room.stopFlushTimer()
 		delete(h.rooms, roomID)
+		h.unsubscribe(roomID)
+	}
+}
+
+// closed reports whether Close was called.
+func (h *Hub) closed() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close flushes unsaved edits of every room and stops listening to other
+// instances. Called on shutdown.
+func (h *Hub) Close(ctx context.Context) {
+	close(h.done)
+
+	h.mu.RLock()
+	rooms := make([]*Room, 0, len(h.rooms))
+	for _, room := range h.rooms {
+		rooms = append(rooms, room)
+	}
+	h.mu.RUnlock()
+
+	for _, room := range rooms {
+		room.stopFlushTimer()
+		room.flush(ctx)
+	}
+	if h.broker != nil {
+		_ = h.broker.Close()
 	}
 }
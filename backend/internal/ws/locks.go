@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"maps"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
+)
+
+// LockNode acquires a node lock for a client, or refreshes it when the client
+// already holds it. With a lock service the lock is cluster-wide and expires
+// after redis.LockTTL unless renewed.
+func (r *Room) LockNode(ctx context.Context, nodeID, clientID string) (bool, error) {
+	if r.hub.locks != nil {
+		ok, err := r.hub.locks.LockNode(ctx, r.ID, nodeID, clientID)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hub.locks == nil {
+		if existing, ok := r.Locks[nodeID]; ok && existing != clientID {
+			return false, nil // Already locked by someone else
+		}
+	}
+	r.Locks[nodeID] = clientID
+	return true, nil
+}
+
+// RenewLock extends a lock held by the client. Returns false if the lock
+// expired or was taken over meanwhile.
+func (r *Room) RenewLock(ctx context.Context, nodeID, clientID string) (bool, error) {
+	if r.hub.locks != nil {
+		ok, err := r.hub.locks.RenewLock(ctx, r.ID, nodeID, clientID)
+		if err != nil || !ok {
+			r.forgetLock(nodeID, clientID)
+			return false, err
+		}
+		return true, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.Locks[nodeID] == clientID, nil
+}
+
+// UnlockNode releases a lock if it is held by the client.
+func (r *Room) UnlockNode(ctx context.Context, nodeID, clientID string) error {
+	r.forgetLock(nodeID, clientID)
+	if r.hub.locks != nil {
+		return r.hub.locks.UnlockNode(ctx, r.ID, nodeID, clientID)
+	}
+	return nil
+}
+
+// ReleaseLocks releases every lock held by a client and returns the node IDs.
+func (r *Room) ReleaseLocks(ctx context.Context, clientID string) []string {
+	r.mu.Lock()
+	var nodeIDs []string
+	for nodeID, lockerID := range r.Locks {
+		if lockerID == clientID {
+			nodeIDs = append(nodeIDs, nodeID)
+			delete(r.Locks, nodeID)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.hub.locks != nil {
+		for _, nodeID := range nodeIDs {
+			if err := r.hub.locks.UnlockNode(ctx, r.ID, nodeID, clientID); err != nil {
+				log.Printf("[WS] room %s: failed to release lock on %s: %v", r.ID, nodeID, err)
+			}
+		}
+	}
+	return nodeIDs
+}
+
+// RoomLocks returns all current locks of the room (nodeID → clientID),
+// across instances when a lock service is configured.
+func (r *Room) RoomLocks(ctx context.Context) map[string]string {
+	if r.hub.locks != nil {
+		locks, err := r.hub.locks.GetRoomLocks(ctx, r.ID)
+		if err == nil {
+			return locks
+		}
+		log.Printf("[WS] room %s: failed to list locks: %v", r.ID, err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.Locks)
+}
+
+func (r *Room) forgetLock(nodeID, clientID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Locks[nodeID] == clientID {
+		delete(r.Locks, nodeID)
+	}
+}
+
+// lockTTLSeconds is sent with node_locked so clients can drop locks whose
+// holder stopped renewing them (e.g. its instance crashed).
+func (r *Room) lockTTLSeconds() int {
+	if r.hub.locks == nil {
+		return 0
+	}
+	return int(redis.LockTTL.Seconds())
+}
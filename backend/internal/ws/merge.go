@@ -0,0 +1,239 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// Node and edge properties are last-writer-wins registers. Every accepted
+// change stamps its fields with the operation's (seq, client) pair, and a
+// change only takes effect on fields whose stamp is not newer than its own.
+// Because seq is assigned by the server (cluster-wide with Redis), every
+// instance and every client that applies the same operations ends up with
+// the same properties, whatever order the operations arrive in.
+//
+// Positions are a single register (x and y move together); data and
+// properties are maps of registers, one per key, so that two clients can
+// edit different keys.
+
+// nodeFields and edgeFields are the properties update_node and update_edge may change.
+var (
+	nodeFields = []string{"type", "label", "position", "width", "height", "color", "data", "properties"}
+	edgeFields = []string{"source", "target", "type", "label"}
+)
+
+// stamp orders concurrent writes to a register.
+type stamp struct {
+	Seq    int64
+	Client string
+}
+
+// newer reports whether s was written after t.
+func (s stamp) newer(t stamp) bool {
+	if s.Seq != t.Seq {
+		return s.Seq > t.Seq
+	}
+	return s.Client > t.Client
+}
+
+// fieldClocks holds the stamp of the last write to each register,
+// keyed by element ("node:<id>" or "edge:<id>") and then field.
+// Fields never written in the room have the zero stamp.
+type fieldClocks map[string]map[string]stamp
+
+func nodeKey(id string) string { return "node:" + id }
+func edgeKey(id string) string { return "edge:" + id }
+
+// wins reports whether a write stamped s takes effect on elem's field.
+// Replaying the operation that wrote a field wins again, so operations
+// can be reapplied after a reload.
+func (c fieldClocks) wins(elem, field string, s stamp) bool {
+	return !c[elem][field].newer(s)
+}
+
+func (c fieldClocks) set(elem, field string, s stamp) {
+	if c[elem] == nil {
+		c[elem] = make(map[string]stamp)
+	}
+	c[elem][field] = s
+}
+
+// forget drops the clocks of a deleted element.
+func (c fieldClocks) forget(elem string) {
+	delete(c, elem)
+}
+
+// opStamp is the stamp of an operation: its seq, with the sender's client
+// ID breaking ties when two instances assigned the same seq.
+func opStamp(msg Message) stamp {
+	return stamp{Seq: msg.Seq, Client: msg.By}
+}
+
+// mergeNode applies the winning fields of changes to node and returns them.
+// Fields whose registers hold a newer write are left out; if none win the
+// result is empty and node is unchanged. Unknown fields, values that do not
+// fit the node, or a merged node that check rejects reject the whole change;
+// check may be nil.
+func mergeNode(clocks fieldClocks, node *document.Node, changes map[string]interface{}, s stamp, check func(document.Node) error) (map[string]interface{}, error) {
+	if err := checkFields(changes, nodeFields); err != nil {
+		return nil, err
+	}
+
+	elem := nodeKey(node.ID)
+	fields := encodeMap(*node)
+	applied := make(map[string]interface{})
+	mapKeys := make(map[string][]string)
+	for k, v := range changes {
+		if current, ok := mapFields(node)[k]; ok {
+			merged, keys, err := mergeKeys(clocks, elem, k, current, v, s)
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) > 0 {
+				fields[k] = merged
+				applied[k] = pick(v.(map[string]interface{}), keys)
+				mapKeys[k] = keys
+			}
+			continue
+		}
+		if clocks.wins(elem, k, s) {
+			fields[k] = v
+			applied[k] = v
+		}
+	}
+	if len(applied) == 0 {
+		return applied, nil
+	}
+
+	var merged document.Node
+	if err := decodeMap(fields, &merged); err != nil {
+		return nil, fmt.Errorf("invalid changes: %w", err)
+	}
+	if check != nil {
+		if err := check(merged); err != nil {
+			return nil, err
+		}
+	}
+	*node = merged
+
+	for k := range applied {
+		if _, ok := mapKeys[k]; !ok {
+			clocks.set(elem, k, s)
+		}
+	}
+	for field, keys := range mapKeys {
+		for _, k := range keys {
+			clocks.set(elem, field+"."+k, s)
+		}
+	}
+	return applied, nil
+}
+
+// mapFields returns the node fields that are merged per key.
+func mapFields(node *document.Node) map[string]json.RawMessage {
+	return map[string]json.RawMessage{"data": node.Data, "properties": node.Properties}
+}
+
+// mergeKeys merges a change to a map field ({key: value}, null removes a
+// key) into its current value and returns the result with the keys that won.
+func mergeKeys(clocks fieldClocks, elem, field string, current json.RawMessage, change interface{}, s stamp) (map[string]interface{}, []string, error) {
+	patch, ok := change.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("%s changes must be an object of keys to update", field)
+	}
+
+	values := map[string]interface{}{}
+	if len(current) > 0 {
+		// A value that is not an object cannot be merged per key; it is replaced.
+		_ = json.Unmarshal(current, &values)
+		if values == nil {
+			values = map[string]interface{}{}
+		}
+	}
+
+	var keys []string
+	for k, v := range patch {
+		if !clocks.wins(elem, field+"."+k, s) {
+			continue
+		}
+		if v == nil {
+			delete(values, k)
+		} else {
+			values[k] = v
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return values, keys, nil
+}
+
+// mergeEdge is mergeNode for edges. Endpoints must refer to existing nodes.
+func mergeEdge(clocks fieldClocks, content *document.DocumentContent, edge *document.Edge, changes map[string]interface{}, s stamp, check func(document.Edge) error) (map[string]interface{}, error) {
+	if err := checkFields(changes, edgeFields); err != nil {
+		return nil, err
+	}
+
+	elem := edgeKey(edge.ID)
+	fields := encodeMap(*edge)
+	applied := make(map[string]interface{})
+	for k, v := range changes {
+		if clocks.wins(elem, k, s) {
+			fields[k] = v
+			applied[k] = v
+		}
+	}
+	if len(applied) == 0 {
+		return applied, nil
+	}
+
+	var merged document.Edge
+	if err := decodeMap(fields, &merged); err != nil {
+		return nil, fmt.Errorf("invalid changes: %w", err)
+	}
+	if findNode(content, merged.Source) < 0 || findNode(content, merged.Target) < 0 {
+		return nil, fmt.Errorf("edge %q would connect unknown nodes", edge.ID)
+	}
+	if check != nil {
+		if err := check(merged); err != nil {
+			return nil, err
+		}
+	}
+	*edge = merged
+
+	for k := range applied {
+		clocks.set(elem, k, s)
+	}
+	return applied, nil
+}
+
+// checkFields rejects changes to properties that are not registers,
+// including the immutable id.
+func checkFields(changes map[string]interface{}, allowed []string) error {
+	if len(changes) == 0 {
+		return errors.New("changes are required")
+	}
+	var unknown []string
+	for k := range changes {
+		if !slices.Contains(allowed, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("cannot change %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func pick(m map[string]interface{}, keys []string) map[string]interface{} {
+	out := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		out[k] = m[k]
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+func initialContent() document.DocumentContent {
+	return document.DocumentContent{
+		Nodes: []document.Node{
+			{ID: "a", Type: "process", Label: "A", Data: json.RawMessage(`{"note":"x"}`)},
+			{ID: "b", Type: "decision", Label: "B"},
+		},
+		Edges: []document.Edge{{ID: "e1", Source: "a", Target: "b"}},
+	}
+}
+
+func updateNode(seq int64, by, nodeID string, changes map[string]interface{}) Message {
+	return Message{Type: TypeUpdateNode, Seq: seq, By: by, NodeID: nodeID, Changes: changes}
+}
+
+func updateEdge(seq int64, by, edgeID string, changes map[string]interface{}) Message {
+	return Message{Type: TypeUpdateEdge, Seq: seq, By: by, EdgeID: edgeID, Changes: changes}
+}
+
+// permutations returns every ordering of ops.
+func permutations(ops []Message) [][]Message {
+	if len(ops) <= 1 {
+		return [][]Message{ops}
+	}
+	var out [][]Message
+	for i := range ops {
+		rest := append(append([]Message{}, ops[:i]...), ops[i+1:]...)
+		for _, p := range permutations(rest) {
+			out = append(out, append([]Message{ops[i]}, p...))
+		}
+	}
+	return out
+}
+
+func TestLWWConvergence(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Message
+		want string // content as JSON after every ordering
+	}{
+		{
+			name: "same field, newest seq wins",
+			ops: []Message{
+				updateNode(1, "c1", "a", map[string]interface{}{"label": "first"}),
+				updateNode(2, "c2", "a", map[string]interface{}{"label": "second"}),
+				updateNode(3, "c1", "a", map[string]interface{}{"label": "third"}),
+			},
+			want: `{"nodes":[{"id":"a","type":"process","position":{"x":0,"y":0},"label":"third","data":{"note":"x"}},` +
+				`{"id":"b","type":"decision","position":{"x":0,"y":0},"label":"B"}],` +
+				`"edges":[{"id":"e1","source":"a","target":"b"}]}`,
+		},
+		{
+			name: "equal seq, client ID breaks the tie",
+			ops: []Message{
+				updateNode(5, "c1", "a", map[string]interface{}{"color": "red"}),
+				updateNode(5, "c2", "a", map[string]interface{}{"color": "blue"}),
+			},
+			want: `{"nodes":[{"id":"a","type":"process","position":{"x":0,"y":0},"label":"A","color":"blue","data":{"note":"x"}},` +
+				`{"id":"b","type":"decision","position":{"x":0,"y":0},"label":"B"}],` +
+				`"edges":[{"id":"e1","source":"a","target":"b"}]}`,
+		},
+		{
+			name: "different fields both apply",
+			ops: []Message{
+				updateNode(1, "c1", "a", map[string]interface{}{"position": map[string]interface{}{"x": 10, "y": 20}}),
+				updateNode(2, "c2", "a", map[string]interface{}{"label": "moved"}),
+				updateNode(3, "c3", "b", map[string]interface{}{"label": "other"}),
+			},
+			want: `{"nodes":[{"id":"a","type":"process","position":{"x":10,"y":20},"label":"moved","data":{"note":"x"}},` +
+				`{"id":"b","type":"decision","position":{"x":0,"y":0},"label":"other"}],` +
+				`"edges":[{"id":"e1","source":"a","target":"b"}]}`,
+		},
+		{
+			name: "data and properties merge per key",
+			ops: []Message{
+				updateNode(1, "c1", "a", map[string]interface{}{"data": map[string]interface{}{"note": "old", "owner": "ana"}}),
+				updateNode(2, "c2", "a", map[string]interface{}{"data": map[string]interface{}{"note": nil}}),
+				updateNode(3, "c1", "a", map[string]interface{}{"properties": map[string]interface{}{"width": 200, "locked": true}}),
+				updateNode(4, "c2", "a", map[string]interface{}{"properties": map[string]interface{}{"width": 240}}),
+			},
+			want: `{"nodes":[{"id":"a","type":"process","position":{"x":0,"y":0},"label":"A","data":{"owner":"ana"},"properties":{"locked":true,"width":240}},` +
+				`{"id":"b","type":"decision","position":{"x":0,"y":0},"label":"B"}],` +
+				`"edges":[{"id":"e1","source":"a","target":"b"}]}`,
+		},
+		{
+			name: "edges",
+			ops: []Message{
+				updateEdge(1, "c1", "e1", map[string]interface{}{"label": "yes"}),
+				updateEdge(2, "c2", "e1", map[string]interface{}{"label": "no", "type": "step"}),
+			},
+			want: `{"nodes":[{"id":"a","type":"process","position":{"x":0,"y":0},"label":"A","data":{"note":"x"}},` +
+				`{"id":"b","type":"decision","position":{"x":0,"y":0},"label":"B"}],` +
+				`"edges":[{"id":"e1","source":"a","target":"b","type":"step","label":"no"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, order := range permutations(tt.ops) {
+				content, clocks := initialContent(), make(fieldClocks)
+				for _, op := range order {
+					if _, err := applyOp("flowchart", &content, clocks, op); err != nil {
+						t.Fatalf("op %d: %v", op.Seq, err)
+					}
+				}
+				got, err := json.Marshal(content)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != tt.want {
+					t.Errorf("order %v:\n got %s\nwant %s", seqs(order), got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func seqs(ops []Message) []int64 {
+	out := make([]int64, len(ops))
+	for i, op := range ops {
+		out[i] = op.Seq
+	}
+	return out
+}
+
+func TestStaleUpdateIsDropped(t *testing.T) {
+	content, clocks := initialContent(), make(fieldClocks)
+	if _, err := applyOp("flowchart", &content, clocks, updateNode(2, "c1", "a", map[string]interface{}{"label": "new"})); err != nil {
+		t.Fatal(err)
+	}
+	out, err := applyOp("flowchart", &content, clocks, updateNode(1, "c2", "a", map[string]interface{}{"label": "old", "color": "red"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out.Changes["label"]; ok {
+		t.Errorf("stale label was applied: %v", out.Changes)
+	}
+	if out.Changes["color"] != "red" {
+		t.Errorf("color change missing from %v", out.Changes)
+	}
+	if content.Nodes[0].Label != "new" || content.Nodes[0].Color != "red" {
+		t.Errorf("node = %+v", content.Nodes[0])
+	}
+}
@@ -0,0 +1,179 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/diagram"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+// applyOp applies a content operation to content and returns the message to
+// broadcast to the other clients. Operations that do not fit the current
+// content (unknown IDs, duplicates, dangling edges) or the rules of
+// diagramType (see internal/diagram) are rejected unchanged. msg.Seq and msg.By must be set: property updates are merged field by field
+// against clocks (see merge.go), and the result lists only the changes that
+// took effect.
+func applyOp(diagramType string, content *document.DocumentContent, clocks fieldClocks, msg Message) (Message, error) {
+	switch msg.Type {
+	case TypeAddNode:
+		var node document.Node
+		if err := decodeMap(msg.Node, &node); err != nil {
+			return Message{}, fmt.Errorf("invalid node: %w", err)
+		}
+		if node.ID == "" {
+			return Message{}, errors.New("node id is required")
+		}
+		if findNode(content, node.ID) >= 0 {
+			return Message{}, fmt.Errorf("node %q already exists", node.ID)
+		}
+		if err := checkNode(diagramType, content, node, node); err != nil {
+			return Message{}, err
+		}
+		content.Nodes = append(content.Nodes, node)
+		return Message{Type: TypeNodeAdded, Node: encodeMap(node)}, nil
+
+	case TypeUpdateNode:
+		i := findNode(content, msg.NodeID)
+		if i < 0 {
+			return Message{}, fmt.Errorf("node %q not found", msg.NodeID)
+		}
+		current := content.Nodes[i]
+		check := func(merged document.Node) error { return checkNode(diagramType, content, current, merged) }
+		applied, err := mergeNode(clocks, &content.Nodes[i], msg.Changes, opStamp(msg), check)
+		if err != nil {
+			return Message{}, err
+		}
+		return Message{Type: TypeNodeUpdated, NodeID: msg.NodeID, Changes: applied}, nil
+
+	case TypeDeleteNode:
+		i := findNode(content, msg.NodeID)
+		if i < 0 {
+			return Message{}, fmt.Errorf("node %q not found", msg.NodeID)
+		}
+		content.Nodes = slices.Delete(content.Nodes, i, i+1)
+		clocks.forget(nodeKey(msg.NodeID))
+		// Edges cannot outlive their endpoints
+		content.Edges = slices.DeleteFunc(content.Edges, func(e document.Edge) bool {
+			if e.Source == msg.NodeID || e.Target == msg.NodeID {
+				clocks.forget(edgeKey(e.ID))
+				return true
+			}
+			return false
+		})
+		return Message{Type: TypeNodeDeleted, NodeID: msg.NodeID}, nil
+
+	case TypeAddEdge:
+		var edge document.Edge
+		if err := decodeMap(msg.Edge, &edge); err != nil {
+			return Message{}, fmt.Errorf("invalid edge: %w", err)
+		}
+		if edge.ID == "" {
+			return Message{}, errors.New("edge id is required")
+		}
+		if findEdge(content, edge.ID) >= 0 {
+			return Message{}, fmt.Errorf("edge %q already exists", edge.ID)
+		}
+		if findNode(content, edge.Source) < 0 || findNode(content, edge.Target) < 0 {
+			return Message{}, fmt.Errorf("edge %q connects unknown nodes", edge.ID)
+		}
+		if err := checkEdge(diagramType, content, edge, nil); err != nil {
+			return Message{}, err
+		}
+		content.Edges = append(content.Edges, edge)
+		return Message{Type: TypeEdgeAdded, Edge: encodeMap(edge)}, nil
+
+	case TypeUpdateEdge:
+		i := findEdge(content, msg.EdgeID)
+		if i < 0 {
+			return Message{}, fmt.Errorf("edge %q not found", msg.EdgeID)
+		}
+		check := func(merged document.Edge) error { return checkEdge(diagramType, content, merged, nil) }
+		applied, err := mergeEdge(clocks, content, &content.Edges[i], msg.Changes, opStamp(msg), check)
+		if err != nil {
+			return Message{}, err
+		}
+		return Message{Type: TypeEdgeUpdated, EdgeID: msg.EdgeID, Changes: applied}, nil
+
+	case TypeDeleteEdge:
+		i := findEdge(content, msg.EdgeID)
+		if i < 0 {
+			return Message{}, fmt.Errorf("edge %q not found", msg.EdgeID)
+		}
+		content.Edges = slices.Delete(content.Edges, i, i+1)
+		clocks.forget(edgeKey(msg.EdgeID))
+		return Message{Type: TypeEdgeDeleted, EdgeID: msg.EdgeID}, nil
+	}
+	return Message{}, fmt.Errorf("not a content operation: %s", msg.Type)
+}
+
+// checkNode rejects a node that diagramType does not allow. When the node
+// changes type, its edges must still follow the connection rules.
+func checkNode(diagramType string, content *document.DocumentContent, current, node document.Node) error {
+	if errs := diagram.ValidateNode(diagramType, node); len(errs) > 0 {
+		return fieldError(errs[0])
+	}
+	if node.Type == current.Type {
+		return nil
+	}
+	for _, e := range content.Edges {
+		if e.Source == node.ID || e.Target == node.ID {
+			if err := checkEdge(diagramType, content, e, &node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkEdge rejects an edge that diagramType does not allow between its
+// endpoints. changed, if set, stands in for the stored node with its ID.
+func checkEdge(diagramType string, content *document.DocumentContent, edge document.Edge, changed *document.Node) error {
+	endpointType := func(id string) string {
+		if changed != nil && changed.ID == id {
+			return changed.Type
+		}
+		if i := findNode(content, id); i >= 0 {
+			return content.Nodes[i].Type
+		}
+		return ""
+	}
+	if errs := diagram.ValidateEdge(diagramType, edge, endpointType(edge.Source), endpointType(edge.Target)); len(errs) > 0 {
+		return fieldError(errs[0])
+	}
+	return nil
+}
+
+func fieldError(e diagram.FieldError) error {
+	return fmt.Errorf("%s: %s", e.Field, e.Message)
+}
+
+func findNode(content *document.DocumentContent, id string) int {
+	return slices.IndexFunc(content.Nodes, func(n document.Node) bool { return n.ID == id })
+}
+
+func findEdge(content *document.DocumentContent, id string) int {
+	return slices.IndexFunc(content.Edges, func(e document.Edge) bool { return e.ID == id })
+}
+
+// decodeMap converts a loosely typed message payload into a domain struct.
+func decodeMap(m map[string]interface{}, v any) error {
+	if m == nil {
+		return errors.New("missing payload")
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// encodeMap is the inverse of decodeMap, giving clients the normalized payload.
+func encodeMap(v any) map[string]interface{} {
+	data, _ := json.Marshal(v)
+	var m map[string]interface{}
+	_ = json.Unmarshal(data, &m)
+	return m
+}
@@ -0,0 +1,40 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+)
+
+func TestApplyOpRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		diagramType string
+		op          Message
+	}{
+		{"unknown field", "flowchart", Message{Type: TypeUpdateNode, Seq: 1, By: "c1", NodeID: "a", Changes: map[string]interface{}{"id": "z"}}},
+		{"unknown node type", "flowchart", Message{Type: TypeAddNode, Node: map[string]interface{}{"id": "c", "type": "spaceship"}}},
+		{"duplicate node", "flowchart", Message{Type: TypeAddNode, Node: map[string]interface{}{"id": "a", "type": "process"}}},
+		{"dangling edge", "flowchart", Message{Type: TypeAddEdge, Edge: map[string]interface{}{"id": "e2", "source": "a", "target": "zz"}}},
+		{"unknown edge type", "flowchart", Message{Type: TypeAddEdge, Edge: map[string]interface{}{"id": "e2", "source": "a", "target": "b", "type": "wavy"}}},
+		{"connection rule", "bpmn", Message{Type: TypeUpdateNode, Seq: 1, By: "c1", NodeID: "a", Changes: map[string]interface{}{"type": "end-event"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := document.DocumentContent{
+				Nodes: []document.Node{{ID: "a", Type: "process", Label: "A"}, {ID: "b", Type: "decision", Label: "B"}},
+				Edges: []document.Edge{{ID: "e1", Source: "a", Target: "b"}},
+			}
+			clocks := make(fieldClocks)
+			want, _ := json.Marshal(content)
+			if _, err := applyOp(tt.diagramType, &content, clocks, tt.op); err == nil {
+				t.Fatal("op was accepted")
+			}
+			if got, _ := json.Marshal(content); string(got) != string(want) {
+				t.Errorf("rejected op changed content:\n got %s\nwant %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	gws "github.com/gofiber/websocket/v2"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/redis"
+)
+
+// Keepalive settings: the server pings every pingInterval and each pong
+// refreshes the client's presence heartbeat. A connection that sends nothing
+// (not even a pong) for pongWait is considered dead and closed.
+const (
+	pingInterval  = 10 * time.Second
+	pongWait      = 30 * time.Second
+	writeWait     = 5 * time.Second
+	sweepInterval = redis.HeartbeatTTL / 2
+)
+
+// presence is the client's entry in the room's presence list.
+func (c *Client) presence() redis.PresenceEntry {
+	return redis.PresenceEntry{
+		ClientID:  c.ID,
+		UserID:    c.UserID.String(),
+		Name:      c.Name,
+		AvatarURL: c.AvatarURL,
+		Role:      c.role(),
+		JoinedAt:  c.JoinedAt,
+	}
+}
+
+// keepalive pings the connection until done is closed, and wires pongs
+// (and pings from non-browser clients) to the presence heartbeat.
+func (h *Hub) keepalive(c *gws.Conn, room *Room, client *Client, done <-chan struct{}) {
+	_ = c.SetReadDeadline(time.Now().Add(pongWait))
+	c.SetPongHandler(func(string) error {
+		_ = c.SetReadDeadline(time.Now().Add(pongWait))
+		h.heartbeat(room, client)
+		return nil
+	})
+	c.SetPingHandler(func(data string) error {
+		_ = c.SetReadDeadline(time.Now().Add(pongWait))
+		h.heartbeat(room, client)
+		return c.WriteControl(gws.PongMessage, []byte(data), time.Now().Add(writeWait))
+	})
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := c.WriteControl(gws.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (h *Hub) setPresence(room *Room, client *Client) {
+	if h.presence == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := h.presence.SetPresence(ctx, room.ID, client.presence()); err != nil {
+		log.Printf("[WS] room %s: failed to record presence: %v", room.ID, err)
+	}
+}
+
+func (h *Hub) heartbeat(room *Room, client *Client) {
+	if h.presence == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := h.presence.SetHeartbeat(ctx, room.ID, client.ID); err != nil {
+		log.Printf("[WS] room %s: heartbeat failed: %v", room.ID, err)
+	}
+}
+
+func (h *Hub) removePresence(room *Room, clientID string) {
+	if h.presence == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := h.presence.RemovePresence(ctx, room.ID, clientID); err != nil {
+		log.Printf("[WS] room %s: failed to remove presence: %v", room.ID, err)
+	}
+}
+
+// Presence returns the connections in a document's room, across all
+// instances when presence is tracked in Redis.
+func (h *Hub) Presence(ctx context.Context, roomID string) ([]redis.PresenceEntry, error) {
+	if h.presence != nil {
+		return h.presence.GetRoomUsers(ctx, roomID)
+	}
+
+	h.mu.RLock()
+	room, ok := h.rooms[roomID]
+	h.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return room.localMembers(""), nil
+}
+
+// members lists the room's connections except excludeID, falling back to
+// the local clients if Redis cannot be reached.
+func (r *Room) members(ctx context.Context, excludeID string) []redis.PresenceEntry {
+	if r.hub.presence == nil {
+		return r.localMembers(excludeID)
+	}
+	entries, err := r.hub.presence.GetRoomUsers(ctx, r.ID)
+	if err != nil {
+		log.Printf("[WS] room %s: failed to list presence: %v", r.ID, err)
+		return r.localMembers(excludeID)
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if e.ClientID != excludeID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (r *Room) localMembers(excludeID string) []redis.PresenceEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]redis.PresenceEntry, 0, len(r.Clients))
+	for id, c := range r.Clients {
+		if id != excludeID && c.joined {
+			entries = append(entries, c.presence())
+		}
+	}
+	return entries
+}
+
+// sweep periodically drops connections whose heartbeat expired (e.g. their
+// instance crashed) from the rooms hosted here and tells the clients.
+func (h *Hub) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+		}
+
+		h.mu.RLock()
+		rooms := make([]*Room, 0, len(h.rooms))
+		for _, room := range h.rooms {
+			rooms = append(rooms, room)
+		}
+		h.mu.RUnlock()
+
+		for _, room := range rooms {
+			h.sweepRoom(room)
+		}
+	}
+}
+
+func (h *Hub) sweepRoom(room *Room) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	removed, err := h.presence.SweepRoom(ctx, room.ID)
+	if err != nil {
+		log.Printf("[WS] room %s: presence sweep failed: %v", room.ID, err)
+		return
+	}
+	for _, e := range removed {
+		room.mu.RLock()
+		client, local := room.Clients[e.ClientID]
+		room.mu.RUnlock()
+		if local {
+			// Still connected here; the heartbeat only lapsed (e.g. a Redis blip)
+			h.setPresence(room, client)
+			continue
+		}
+		broadcastJSON(room, "", Message{Type: TypeUserLeft, UserID: e.ClientID})
+	}
+}
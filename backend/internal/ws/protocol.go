@@ -1,15 +1,19 @@
 package ws
 
+import "github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+
 // Message types matching frontend WSMessageType from lib/ws/client.ts
 const (
 	// Client → Server
 	TypeJoinRoom   = "join_room"
 	TypeLockNode   = "lock_node"
 	TypeUnlockNode = "unlock_node"
+	TypeRenewLock  = "renew_lock" // heartbeat for a held lock, send well within the lock TTL
 	TypeUpdateNode = "update_node"
 	TypeAddNode    = "add_node"
 	TypeDeleteNode = "delete_node"
 	TypeAddEdge    = "add_edge"
+	TypeUpdateEdge = "update_edge"
 	TypeDeleteEdge = "delete_edge"
 	TypeCursorMove = "cursor_move"
 
@@ -22,23 +26,50 @@ const (
 	TypeNodeUpdated  = "node_updated"
 	TypeNodeAdded    = "node_added"
 	TypeNodeDeleted  = "node_deleted"
+	TypeEdgeAdded    = "edge_added"
+	TypeEdgeUpdated  = "edge_updated"
+	TypeEdgeDeleted  = "edge_deleted"
 	TypeCursorUpdate = "cursor_update"
 	TypeError        = "error"
+
+	// Server → Client: realtime persistence
+	TypeOpAck         = "op_ack"         // seq assigned to the sender's operation
+	TypeDocumentState = "document_state" // full content, sent when the room had to reload
+	TypeDocumentSaved = "document_saved" // content up to seq was persisted as version
 )
 
+// isMutation reports whether a client message changes the document or its locks.
+// Viewers may only join and move their cursor.
+func isMutation(msgType string) bool {
+	switch msgType {
+	case TypeLockNode, TypeUnlockNode, TypeRenewLock, TypeUpdateNode, TypeAddNode,
+		TypeDeleteNode, TypeAddEdge, TypeUpdateEdge, TypeDeleteEdge:
+		return true
+	}
+	return false
+}
+
 // Message is a generic WebSocket message
 type Message struct {
 	Type string `json:"type"`
 
-	// join_room
-	RoomID string `json:"room_id,omitempty"`
+	// join_room; last_seq is the highest seq a reconnecting client has seen
+	RoomID  string `json:"room_id,omitempty"`
+	LastSeq int64  `json:"last_seq,omitempty"`
+
+	// content operations: op_id is chosen by the client, seq assigned by the
+	// server. update_node and update_edge changes are last-writer-wins per
+	// field, ordered by (seq, by); node_updated and edge_updated carry only
+	// the changes that won.
+	OpID string `json:"op_id,omitempty"`
+	Seq  int64  `json:"seq,omitempty"`
 
 	// node operations
 	NodeID  string                 `json:"node_id,omitempty"`
 	Node    map[string]interface{} `json:"node,omitempty"`
 	Changes map[string]interface{} `json:"changes,omitempty"`
 
-	// edge operations
+	// edge operations (update_edge reuses changes)
 	EdgeID string                 `json:"edge_id,omitempty"`
 	Edge   map[string]interface{} `json:"edge,omitempty"`
 
@@ -52,6 +83,13 @@ type Message struct {
 	User   map[string]interface{} `json:"user,omitempty"`
 	Users  []interface{}          `json:"users,omitempty"`
 	Locks  map[string]string      `json:"locks,omitempty"`
+	TTL    int                    `json:"ttl,omitempty"` // seconds until a lock expires unless renewed
+
+	// document state: room_state carries either the full content or, for a
+	// client that joined with last_seq, the operations it missed
+	Content *document.DocumentContent `json:"content,omitempty"`
+	Version int                       `json:"version,omitempty"`
+	Ops     []Message                 `json:"ops,omitempty"`
 
 	// error
 	MessageText string `json:"message,omitempty"`
@@ -0,0 +1,41 @@
+package ws
+
+// opLogSize bounds the operations a room keeps for reconnecting clients.
+// A client that missed more than this gets the full content instead.
+const opLogSize = 500
+
+// logOp records an applied operation as it was sent to clients.
+// Callers hold stateMu.
+func (r *Room) logOp(op Message) {
+	r.oplog = append(r.oplog, op)
+	if n := len(r.oplog) - opLogSize; n > 0 {
+		r.logStart = r.oplog[n-1].Seq
+		r.oplog = append(r.oplog[:0:0], r.oplog[n:]...)
+	}
+}
+
+// resetLog empties the log when the content changed outside of it
+// (loaded or reloaded from MongoDB). A client that saw r.seq may not have
+// seen that change, so only clients that saw a later operation can catch
+// up from the log. Callers hold stateMu.
+func (r *Room) resetLog() {
+	r.oplog = nil
+	r.logStart = r.seq + 1
+}
+
+// missedOps returns the operations after lastSeq, in the order this room
+// applied them. ok is false if the log cannot account for everything the
+// client missed: lastSeq is older than the log, or from a previous life of
+// the room. Callers hold stateMu.
+func (r *Room) missedOps(lastSeq int64) (ops []Message, ok bool) {
+	if lastSeq < r.logStart || lastSeq > r.seq {
+		return nil, false
+	}
+	ops = []Message{}
+	for _, op := range r.oplog {
+		if op.Seq > lastSeq {
+			ops = append(ops, op)
+		}
+	}
+	return ops, true
+}
@@ -0,0 +1,97 @@
+package ws
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReplayMissedOps(t *testing.T) {
+	_, room := newTestRoom(t, &fakeStore{})
+	editor := newTestClient("editor", "editor")
+	joinClient(t, room, editor, 0)
+	for _, id := range []string{"b", "c", "d"} {
+		room.apply(editor, addNode(id))
+	}
+	received(t, editor)
+
+	tests := []struct {
+		name    string
+		lastSeq int64
+		ops     []int64 // nil: full content instead
+	}{
+		{name: "fresh join", lastSeq: 0, ops: nil},
+		{name: "missed two", lastSeq: 1, ops: []int64{2, 3}},
+		{name: "missed nothing", lastSeq: 3, ops: []int64{}},
+		{name: "from a previous life of the room", lastSeq: 9, ops: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient("c-"+tt.name, "viewer")
+			state := joinClient(t, room, client, tt.lastSeq)
+			defer room.RemoveClient(client.ID)
+
+			if state.Seq != 3 {
+				t.Errorf("room_state seq = %d, want 3", state.Seq)
+			}
+			if tt.ops == nil {
+				if state.Content == nil || state.Ops != nil {
+					t.Fatalf("room_state = %+v, want the full content", state)
+				}
+				return
+			}
+			if state.Content != nil || len(state.Ops) != len(tt.ops) {
+				t.Fatalf("room_state = %+v, want ops %v", state, tt.ops)
+			}
+			for i, op := range state.Ops {
+				if op.Seq != tt.ops[i] || op.Type != TypeNodeAdded {
+					t.Errorf("op %d = %s at seq %d, want node_added at seq %d", i, op.Type, op.Seq, tt.ops[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayLogOverflowForcesSnapshot(t *testing.T) {
+	_, room := newTestRoom(t, &fakeStore{})
+	editor := newTestClient("editor", "editor")
+	joinClient(t, room, editor, 0)
+	for i := 0; i < opLogSize+10; i++ {
+		room.apply(editor, addNode(fmt.Sprintf("n%d", i)))
+		received(t, editor)
+	}
+
+	if len(room.oplog) != opLogSize || room.logStart != 10 {
+		t.Fatalf("log holds %d ops from %d, want %d from 10", len(room.oplog), room.logStart, opLogSize)
+	}
+	if state := joinClient(t, room, newTestClient("old", "viewer"), 9); state.Content == nil {
+		t.Error("client older than the log did not get a snapshot")
+	}
+	if state := joinClient(t, room, newTestClient("recent", "viewer"), 10); len(state.Ops) != opLogSize {
+		t.Errorf("client at the log start got %d ops, want %d", len(state.Ops), opLogSize)
+	}
+}
+
+func TestReplayThenLiveOpsAreNotDuplicated(t *testing.T) {
+	_, room := newTestRoom(t, &fakeStore{})
+	editor := newTestClient("editor", "editor")
+	joinClient(t, room, editor, 0)
+	room.apply(editor, addNode("b"))
+	room.apply(editor, addNode("c"))
+	received(t, editor)
+
+	client := newTestClient("back", "viewer")
+	state := joinClient(t, room, client, 1)
+	room.apply(editor, addNode("d"))
+
+	seen := map[int64]int{}
+	for _, op := range state.Ops {
+		seen[op.Seq]++
+	}
+	for _, msg := range received(t, client) {
+		seen[msg.Seq]++
+	}
+	if len(seen) != 2 || seen[2] != 1 || seen[3] != 1 {
+		t.Errorf("ops seen by seq = %v, want 2 and 3 once each", seen)
+	}
+}
@@ -0,0 +1,254 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+)
+
+// Debounce settings for persisting room edits to MongoDB.
+const (
+	flushDelay    = 2 * time.Second  // quiet period after the last operation
+	maxFlushDelay = 10 * time.Second // upper bound while edits keep coming
+	flushTimeout  = 10 * time.Second
+)
+
+// load reads the room's document on first use.
+func (r *Room) load(ctx context.Context) *pkg.AppError {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if r.loaded {
+		return nil
+	}
+
+	docID, err := uuid.Parse(r.ID)
+	if err != nil {
+		return pkg.ErrBadRequest.WithMessage("invalid document ID")
+	}
+	doc, content, appErr := r.hub.docSvc.LoadContent(ctx, docID)
+	if appErr != nil {
+		return appErr
+	}
+	r.content, r.version, r.diagramType, r.loaded = *content, doc.Version, doc.DiagramType, true
+	// Operations before the load are only in the stored content
+	r.seq = r.currentSeq(ctx)
+	r.resetLog()
+	return nil
+}
+
+// join starts sending room messages to a client and sends it the room
+// state, atomically with respect to operations: the client sees every
+// operation after room_state's seq, and none before. A client that has seen
+// up to lastSeq (> 0) gets the operations it missed instead of the content,
+// when the op log still covers them.
+func (r *Room) join(client *Client, lastSeq int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	locks := r.RoomLocks(ctx)
+	members := r.members(ctx, client.ID)
+	cancel()
+
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	r.mu.Lock()
+	client.joined = true
+	r.mu.Unlock()
+
+	state := Message{Locks: locks, Version: r.version, Seq: r.seq}
+	if ops, ok := r.missedOps(lastSeq); ok {
+		state.Ops = ops
+	} else {
+		content := r.snapshot()
+		state.Content = &content
+	}
+	sendRoomState(client, members, state)
+}
+
+// apply applies a content operation, assigns it the next sequence number,
+// broadcasts it to the other clients and acknowledges it to the sender.
+func (r *Room) apply(client *Client, msg Message) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	// The seq is assigned up front because it orders the op's field writes.
+	// Rejected ops leave a gap in the sequence.
+	r.seq = r.nextSeq()
+	msg.Seq, msg.By = r.seq, client.ID
+	out, err := applyOp(r.diagramType, &r.content, r.clocks, msg)
+	if err != nil {
+		sendJSON(client, Message{Type: TypeError, OpID: msg.OpID, MessageText: err.Error()})
+		return
+	}
+
+	out.Seq, out.By = r.seq, client.ID
+	r.logOp(out)
+	r.unsaved = append(r.unsaved, msg)
+	r.lastEditor = client.UserID
+	r.scheduleFlush()
+
+	data, err := json.Marshal(out)
+	if err == nil {
+		r.deliver(data, client.ID)
+		r.publish(envelope{Exclude: client.ID, Data: data, Op: &msg})
+	}
+	sendJSON(client, Message{Type: TypeOpAck, OpID: msg.OpID, Seq: r.seq})
+}
+
+// Dirty reports whether the room holds operations not yet persisted.
+func (r *Room) Dirty() bool {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	return len(r.unsaved) > 0
+}
+
+// snapshot copies the current content. Callers hold stateMu.
+func (r *Room) snapshot() document.DocumentContent {
+	return document.DocumentContent{
+		Nodes: append([]document.Node{}, r.content.Nodes...),
+		Edges: append([]document.Edge{}, r.content.Edges...),
+	}
+}
+
+// scheduleFlush (re)arms the debounce timer. Callers hold stateMu.
+func (r *Room) scheduleFlush() {
+	now := time.Now()
+	if r.dirtySince.IsZero() {
+		r.dirtySince = now
+	}
+	delay := min(flushDelay, maxFlushDelay-now.Sub(r.dirtySince))
+	if r.flushTimer == nil {
+		r.flushTimer = time.AfterFunc(delay, func() {
+			ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			defer cancel()
+			r.flush(ctx)
+		})
+		return
+	}
+	r.flushTimer.Reset(delay)
+}
+
+func (r *Room) stopFlushTimer() {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+	}
+}
+
+// flush persists unsaved operations as a new document version. If the
+// document was changed outside the room (e.g. a REST update), the room
+// reloads it, replays its unsaved operations on top and retries once.
+func (r *Room) flush(ctx context.Context) {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	docID, err := uuid.Parse(r.ID)
+	if err != nil {
+		return
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		r.stateMu.Lock()
+		if len(r.unsaved) == 0 {
+			r.stateMu.Unlock()
+			return
+		}
+		data, err := json.Marshal(r.content)
+		base, upTo, editor := r.version, r.seq, r.lastEditor
+		r.dirtySince = time.Time{}
+		r.stateMu.Unlock()
+		if err != nil {
+			log.Printf("[WS] room %s: failed to encode content: %v", r.ID, err)
+			return
+		}
+
+		version, appErr := r.hub.docSvc.SaveContent(ctx, docID, editor, data, base)
+		if appErr == nil {
+			r.stateMu.Lock()
+			r.version = version
+			r.dropUnsaved(upTo)
+			r.stateMu.Unlock()
+			broadcastJSON(r, "", Message{Type: TypeDocumentSaved, Version: version, Seq: upTo})
+			return
+		}
+
+		switch {
+		case appErr.Code == pkg.ErrNotFound.Code:
+			// The document was deleted; there is nothing left to save into.
+			log.Printf("[WS] room %s: document deleted, dropping unsaved edits", r.ID)
+			r.stateMu.Lock()
+			r.unsaved = nil
+			r.stateMu.Unlock()
+			broadcastJSON(r, "", Message{Type: TypeError, MessageText: "Document was deleted"})
+			return
+		case appErr.Code == pkg.ErrUnprocessable.Code:
+			// Ops are checked as they apply, so only content stored before
+			// the diagram rules existed gets here; retrying cannot help.
+			log.Printf("[WS] room %s: content rejected, dropping unsaved edits: %s", r.ID, appErr.Error())
+			r.stateMu.Lock()
+			r.unsaved = nil
+			r.stateMu.Unlock()
+			broadcastJSON(r, "", Message{Type: TypeError, MessageText: "Edits could not be saved: document content is invalid"})
+			return
+		case appErr.Code != pkg.ErrConflict.Code || attempt > 0:
+			if r.hub.closed() {
+				// Close already made the last attempt; no timer may outlive it
+				log.Printf("[WS] room %s: final flush failed, unsaved edits lost: %s", r.ID, appErr.Error())
+				return
+			}
+			log.Printf("[WS] room %s: flush failed, will retry: %s", r.ID, appErr.Error())
+			r.stateMu.Lock()
+			r.scheduleFlush()
+			r.stateMu.Unlock()
+			return
+		}
+
+		if appErr := r.rebase(ctx, docID); appErr != nil {
+			log.Printf("[WS] room %s: reload failed: %s", r.ID, appErr.Error())
+			return
+		}
+	}
+}
+
+// dropUnsaved forgets operations persisted up to seq. Callers hold stateMu.
+func (r *Room) dropUnsaved(seq int64) {
+	i := 0
+	for i < len(r.unsaved) && r.unsaved[i].Seq <= seq {
+		i++
+	}
+	r.unsaved = r.unsaved[i:]
+}
+
+// rebase reloads the stored document, replays the unsaved operations on top
+// and sends every client the resulting state.
+func (r *Room) rebase(ctx context.Context, docID uuid.UUID) *pkg.AppError {
+	doc, content, appErr := r.hub.docSvc.LoadContent(ctx, docID)
+	if appErr != nil {
+		return appErr
+	}
+	version := doc.Version
+
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	for _, op := range r.unsaved {
+		if _, err := applyOp(r.diagramType, content, r.clocks, op); err != nil {
+			log.Printf("[WS] room %s: op %d no longer applies after reload: %v", r.ID, op.Seq, err)
+		}
+	}
+	r.content, r.version = *content, version
+	// The log no longer leads to this content; reconnecting clients resync
+	r.resetLog()
+
+	// The reloaded state is specific to this instance, so it is not fanned out
+	state := r.snapshot()
+	if data, err := json.Marshal(Message{Type: TypeDocumentState, Content: &state, Version: version, Seq: r.seq}); err == nil {
+		r.deliver(data, "")
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/domain/document"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/model"
+	"github.com/RenzIP/Graphic-Diagram-Online/internal/pkg"
+)
+
+// fakeStore keeps one document in memory and saves with a compare-and-swap
+// on its version, like DocumentService.
+type fakeStore struct {
+	mu      sync.Mutex
+	version int
+	content document.DocumentContent
+	saveErr *pkg.AppError // returned by every save when set
+	saves   []int         // base version of each save attempt
+
+	roles     map[uuid.UUID]string // RequireAccess answers; other users have no access
+	accessErr *pkg.AppError        // returned by every access check when set
+	checks    int                  // number of access checks
+}
+
+func (s *fakeStore) RequireAccess(_ context.Context, userID, _ uuid.UUID) (string, *pkg.AppError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks++
+	if s.accessErr != nil {
+		return "", s.accessErr
+	}
+	role, ok := s.roles[userID]
+	if !ok {
+		return "", pkg.ErrForbidden
+	}
+	return role, nil
+}
+
+func (s *fakeStore) LoadContent(_ context.Context, _ uuid.UUID) (*model.Document, *document.DocumentContent, *pkg.AppError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content := document.DocumentContent{
+		Nodes: append([]document.Node{}, s.content.Nodes...),
+		Edges: append([]document.Edge{}, s.content.Edges...),
+	}
+	return &model.Document{DiagramType: "flowchart", Version: s.version}, &content, nil
+}
+
+func (s *fakeStore) SaveContent(_ context.Context, _, _ uuid.UUID, content json.RawMessage, baseVersion int) (int, *pkg.AppError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saves = append(s.saves, baseVersion)
+	if s.saveErr != nil {
+		return 0, s.saveErr
+	}
+	if baseVersion != s.version {
+		return 0, pkg.ErrConflict
+	}
+	s.content = document.DocumentContent{}
+	if err := json.Unmarshal(content, &s.content); err != nil {
+		return 0, pkg.ErrUnprocessable
+	}
+	s.version++
+	return s.version, nil
+}
+
+// testRoomID is the document every test room edits.
+var testRoomID = uuid.NewString()
+
+func newTestRoom(t *testing.T, store *fakeStore) (*Hub, *Room) {
+	t.Helper()
+	if store.content.Nodes == nil {
+		store.content = document.DocumentContent{
+			Nodes: []document.Node{{ID: "a", Type: "process", Label: "A"}},
+			Edges: []document.Edge{},
+		}
+	}
+	if store.version == 0 {
+		store.version = 1
+	}
+	hub := NewHub(store, Cluster{})
+	room := NewRoom(testRoomID, hub)
+	hub.rooms[room.ID] = room
+	if appErr := room.load(context.Background()); appErr != nil {
+		t.Fatalf("load: %v", appErr)
+	}
+	t.Cleanup(room.stopFlushTimer)
+	return hub, room
+}
+
+func newTestClient(id, role string) *Client {
+	return &Client{ID: id, UserID: uuid.New(), Role: role, send: make(chan []byte, sendBuffer), checked: time.Now()}
+}
+
+// joinClient adds a client to the room and returns its room_state.
+func joinClient(t *testing.T, room *Room, client *Client, lastSeq int64) Message {
+	t.Helper()
+	room.AddClient(client)
+	room.join(client, lastSeq)
+	msgs := received(t, client)
+	if len(msgs) != 1 || msgs[0].Type != TypeRoomState {
+		t.Fatalf("join sent %+v, want room_state", msgs)
+	}
+	return msgs[0]
+}
+
+// received returns the messages queued for a client since the last call,
+// up to its disconnection.
+func received(t *testing.T, client *Client) []Message {
+	t.Helper()
+	var msgs []Message
+	for {
+		select {
+		case data, ok := <-client.send:
+			if !ok {
+				return msgs
+			}
+			var msg Message
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("client %s got invalid JSON %s", client.ID, data)
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func types(msgs []Message) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.Type
+	}
+	return out
+}
+
+func addNode(id string) Message {
+	return Message{Type: TypeAddNode, OpID: "op-" + id, Node: map[string]interface{}{"id": id, "type": "process", "label": id}}
+}
+
+func TestJoinSendsRoomState(t *testing.T) {
+	_, room := newTestRoom(t, &fakeStore{version: 3})
+	state := joinClient(t, room, newTestClient("c1", "editor"), 0)
+
+	if state.Version != 3 || state.Seq != 0 || state.Ops != nil {
+		t.Errorf("room_state = %+v, want version 3 at seq 0", state)
+	}
+	if state.Content == nil || len(state.Content.Nodes) != 1 || state.Content.Nodes[0].ID != "a" {
+		t.Errorf("room_state content = %+v", state.Content)
+	}
+}
+
+func TestApplyBroadcastsAndAcknowledges(t *testing.T) {
+	_, room := newTestRoom(t, &fakeStore{})
+	c1, c2 := newTestClient("c1", "editor"), newTestClient("c2", "viewer")
+	joinClient(t, room, c1, 0)
+	joinClient(t, room, c2, 0)
+
+	room.apply(c1, addNode("b"))
+	room.apply(c1, Message{Type: TypeAddNode, OpID: "bad", Node: map[string]interface{}{"id": "c", "type": "spaceship"}})
+
+	got := received(t, c1)
+	if len(got) != 2 || got[0].Type != TypeOpAck || got[0].OpID != "op-b" || got[0].Seq != 1 ||
+		got[1].Type != TypeError || got[1].OpID != "bad" {
+		t.Errorf("sender got %+v, want op_ack for seq 1 and an error", got)
+	}
+	got = received(t, c2)
+	if len(got) != 1 || got[0].Type != TypeNodeAdded || got[0].Seq != 1 || got[0].By != "c1" {
+		t.Errorf("other client got %+v, want node_added at seq 1", got)
+	}
+	if !room.Dirty() {
+		t.Error("room is not dirty after an applied op")
+	}
+}
+
+func TestApplyDoesNotWaitForSlowClients(t *testing.T) {
+	_, room := newTestRoom(t, &fakeStore{})
+	fast, slow := newTestClient("fast", "editor"), newTestClient("slow", "editor")
+	joinClient(t, room, fast, 0)
+	joinClient(t, room, slow, 0)
+	slow.send = make(chan []byte, 1) // nobody drains it
+
+	for i := 0; i < 3; i++ {
+		room.apply(fast, addNode(string(rune('b'+i))))
+	}
+
+	if got := received(t, fast); len(got) != 3 {
+		t.Errorf("sender got %d acks, want 3", len(got))
+	}
+	if err := slow.Send([]byte(`{}`)); err != errClientClosed {
+		t.Errorf("slow client Send = %v, want it disconnected", err)
+	}
+}
+
+func TestFlushSavesUnsavedOps(t *testing.T) {
+	store := &fakeStore{version: 2}
+	_, room := newTestRoom(t, store)
+	client := newTestClient("c1", "editor")
+	joinClient(t, room, client, 0)
+
+	room.apply(client, addNode("b"))
+	received(t, client)
+	room.flush(context.Background())
+
+	if len(store.saves) != 1 || store.saves[0] != 2 || store.version != 3 {
+		t.Fatalf("saves = %v, version = %d, want one save on version 2", store.saves, store.version)
+	}
+	if len(store.content.Nodes) != 2 {
+		t.Errorf("saved nodes = %+v, want a and b", store.content.Nodes)
+	}
+	if room.Dirty() {
+		t.Error("room is still dirty after a successful flush")
+	}
+	got := received(t, client)
+	if len(got) != 1 || got[0].Type != TypeDocumentSaved || got[0].Version != 3 || got[0].Seq != 1 {
+		t.Errorf("client got %+v, want document_saved for version 3 at seq 1", got)
+	}
+
+	room.flush(context.Background())
+	if len(store.saves) != 1 {
+		t.Errorf("clean room saved again: %v", store.saves)
+	}
+}
+
+func TestFlushRebasesOnConflict(t *testing.T) {
+	store := &fakeStore{}
+	_, room := newTestRoom(t, store)
+	client := newTestClient("c1", "editor")
+	joinClient(t, room, client, 0)
+	room.apply(client, addNode("b"))
+	received(t, client)
+
+	// A REST update lands between the op and the flush
+	store.content.Nodes = append(store.content.Nodes, document.Node{ID: "rest", Type: "process"})
+	store.version = 5
+	room.flush(context.Background())
+
+	if len(store.saves) != 2 || store.saves[0] != 1 || store.saves[1] != 5 || store.version != 6 {
+		t.Fatalf("saves = %v, version = %d, want a conflict on 1 and a save on 5", store.saves, store.version)
+	}
+	var ids []string
+	for _, n := range store.content.Nodes {
+		ids = append(ids, n.ID)
+	}
+	if len(ids) != 3 || ids[0] != "a" || ids[1] != "rest" || ids[2] != "b" {
+		t.Errorf("saved nodes = %v, want the REST node and the room's op", ids)
+	}
+	if got := types(received(t, client)); len(got) != 2 || got[0] != TypeDocumentState || got[1] != TypeDocumentSaved {
+		t.Errorf("client got %v, want document_state then document_saved", got)
+	}
+
+	// The reload reset the op log: a client that saw seq 1 may have missed
+	// the REST update and gets the content instead of ops.
+	state := joinClient(t, room, newTestClient("c2", "viewer"), 1)
+	if state.Content == nil || len(state.Content.Nodes) != 3 {
+		t.Errorf("rejoin after rebase = %+v, want the full content", state)
+	}
+}
+
+func TestFlushDropsEditsThatCannotBeSaved(t *testing.T) {
+	for _, appErr := range []*pkg.AppError{pkg.ErrNotFound, pkg.ErrUnprocessable} {
+		t.Run(appErr.Code, func(t *testing.T) {
+			_, room := newTestRoom(t, &fakeStore{saveErr: appErr})
+			client := newTestClient("c1", "editor")
+			joinClient(t, room, client, 0)
+			room.apply(client, addNode("b"))
+			received(t, client)
+
+			room.flush(context.Background())
+			if room.Dirty() {
+				t.Error("edits were kept")
+			}
+			if got := received(t, client); len(got) != 1 || got[0].Type != TypeError {
+				t.Errorf("client got %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestFlushRetriesUntilHubCloses(t *testing.T) {
+	hub, room := newTestRoom(t, &fakeStore{saveErr: pkg.ErrInternal})
+	client := newTestClient("c1", "editor")
+	joinClient(t, room, client, 0)
+	room.apply(client, addNode("b"))
+
+	room.flush(context.Background())
+	if !room.flushTimer.Stop() {
+		t.Error("failed flush did not schedule a retry")
+	}
+
+	hub.Close(context.Background())
+	if room.flushTimer.Stop() {
+		t.Error("flush scheduled a retry after the hub closed")
+	}
+}